@@ -0,0 +1,24 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// dashboardInstanceCmd represents the dashboard instance command
+var dashboardInstanceCmd = &cobra.Command{
+	Use:   "instance",
+	Short: "Manage dashboard template instances",
+	Long: `Manage instances of a dashboard template (e.g. one postgres
+dashboard per database). Each instance has its own meta variables
+and registration (registration-<template id>@<instance>.json).
+Instance names may only contain letters, digits, '_' and '.'.`,
+}
+
+func init() {
+	dashboardCmd.AddCommand(dashboardInstanceCmd)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/circonus-labs/cosi-tool/internal/registration"
+	"github.com/spf13/cobra"
+)
+
+// dashboardInstanceAddCmd represents the dashboard instance add command
+var dashboardInstanceAddCmd = &cobra.Command{
+	Use:   "add <template id> <instance>",
+	Short: "Add and register a dashboard instance",
+	Long: `Save the meta variables for a dashboard template instance and
+create the dashboard. The instance name is available to the template
+as {{.DashboardInstance}}, meta variables override the template meta.
+The system must already be registered.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, _ := cmd.Flags().GetStringArray("meta")
+		meta, err := dashboard.ParseInstanceMeta(settings)
+		if err != nil {
+			return err
+		}
+		if err := dashboard.SaveInstance(defaults.RegPath, args[0], args[1], meta); err != nil {
+			return err
+		}
+		r, err := registration.NewPlugin(client, []string{args[0]})
+		if err != nil {
+			return err
+		}
+		return r.RegisterPlugin()
+	},
+}
+
+func init() {
+	dashboardInstanceCmd.AddCommand(dashboardInstanceAddCmd)
+
+	{
+		const (
+			longOpt     = "meta"
+			description = "Instance meta variable (Key=value), may be repeated"
+		)
+
+		dashboardInstanceAddCmd.Flags().StringArray(longOpt, []string{}, description)
+	}
+}
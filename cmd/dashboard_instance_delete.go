@@ -0,0 +1,28 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/spf13/cobra"
+)
+
+// dashboardInstanceDeleteCmd represents the dashboard instance delete command
+var dashboardInstanceDeleteCmd = &cobra.Command{
+	Use:   "delete <template id> <instance>",
+	Short: "Delete a dashboard instance",
+	Long: `Delete the dashboard for a template instance from Circonus and
+remove the instance registration and meta variables.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.DeleteInstance(client, defaults.RegPath, args[0], args[1])
+	},
+}
+
+func init() {
+	dashboardInstanceCmd.AddCommand(dashboardInstanceDeleteCmd)
+}
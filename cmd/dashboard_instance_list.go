@@ -0,0 +1,44 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/spf13/cobra"
+)
+
+// dashboardInstanceListCmd represents the dashboard instance list command
+var dashboardInstanceListCmd = &cobra.Command{
+	Use:   "list [template id]",
+	Short: "List dashboard instances",
+	Long:  `List dashboard template instances, for all templates or one template.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templateID := ""
+		if len(args) == 1 {
+			templateID = args[0]
+		}
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return dashboard.ListInstances(os.Stdout, defaults.RegPath, templateID, quiet)
+	},
+}
+
+func init() {
+	dashboardInstanceCmd.AddCommand(dashboardInstanceListCmd)
+
+	{
+		const (
+			shortOpt    = "q"
+			longOpt     = "quiet"
+			description = "no header lines"
+		)
+
+		dashboardInstanceListCmd.Flags().BoolP(longOpt, shortOpt, dashboard.QuietDefault, description)
+	}
+}
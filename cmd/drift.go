@@ -0,0 +1,52 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/drift"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect drift between COSI assets and registrations",
+	Long: `Drift fetches every asset referenced in the registration directory
+(checks, graphs, worksheets, dashboards and rulesets) and compares it,
+field by field, with the saved registration file.
+
+Exit status is 0 when all assets match their registrations, 2 when any
+asset has drifted or been deleted on the server and 1 on error.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		drifted, err := drift.Run(client, os.Stdout, defaults.RegPath, viper.GetString(drift.KeyFormat))
+		if err != nil {
+			return err
+		}
+		if drifted {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(driftCmd)
+
+	{
+		const (
+			key         = drift.KeyFormat
+			longOpt     = "format"
+			description = "Output format (text|json)"
+		)
+
+		driftCmd.Flags().String(longOpt, drift.DefaultFormat, description)
+		_ = viper.BindPFlag(key, driftCmd.Flags().Lookup(longOpt))
+	}
+}
@@ -6,33 +6,98 @@
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // pluginCmd represents the plugin command
 var pluginCmd = &cobra.Command{
 	Use:   "plugin",
-	Short: "Manage specific NAD plugins",
-	Long: `Intended for managing a subset of NAD plugins
+	Short: "Manage specific agent plugins",
+	Long: `Intended for managing a subset of agent plugins
 which require additional steps and/or local
-configuration.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("'plugin' has not been implemented at this time.", "It is on the schedule for implemenation in the near future.")
-	},
+configuration.
+
+Plugins are defined by manifests (json|toml|yaml) in
+the manifest directory. A manifest defines how to
+detect the service, the agent plugin configuration
+to write and the templates to register.`,
+}
+
+// pluginOptions returns the plugin options from the configuration
+func pluginOptions() *plugin.Options {
+	return &plugin.Options{
+		AgentPluginDir: viper.GetString(plugin.KeyAgentPluginDir),
+		RegDir:         defaults.RegPath,
+		Wait:           viper.GetDuration(plugin.KeyWait),
+	}
+}
+
+// loadPluginManifest loads a bundled plugin manifest for the plugin specific
+// (postgres|cassandra) commands, applying the --conf-file and --script-dir
+// overrides
+func loadPluginManifest(cmd *cobra.Command, name string) (*plugin.Manifest, error) {
+	m, err := plugin.LoadManifest(viper.GetString(plugin.KeyManifestDir), name)
+	if err != nil {
+		return nil, err
+	}
+	if f := cmd.Flags().Lookup("conf-file"); f != nil && f.Changed {
+		m.Agent.ConfFile = f.Value.String()
+	}
+	if f := cmd.Flags().Lookup("script-dir"); f != nil && f.Changed {
+		m.Agent.ScriptDir = f.Value.String()
+	}
+	return m, nil
+}
+
+// pluginFlagVars maps the plugin specific command flags to manifest
+// variables, only flags set on the command line are returned so the
+// manifest environment variables and defaults still apply
+func pluginFlagVars(cmd *cobra.Command, names ...string) map[string]string {
+	set := map[string]string{}
+	for _, name := range names {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			set[name] = f.Value.String()
+		}
+	}
+	return set
 }
 
 func init() {
 	RootCmd.AddCommand(pluginCmd)
 
-	// Here you will define your flags and configuration settings.
+	{
+		const (
+			key         = plugin.KeyManifestDir
+			longOpt     = "manifest-dir"
+			description = "Plugin manifest directory"
+		)
+
+		pluginCmd.PersistentFlags().String(longOpt, defaults.PluginPath, description)
+		_ = viper.BindPFlag(key, pluginCmd.PersistentFlags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = plugin.KeyAgentPluginDir
+			longOpt     = "agent-plugin-dir"
+			description = "Circonus agent plugin directory"
+		)
+
+		pluginCmd.PersistentFlags().String(longOpt, plugin.DefaultAgentPluginDir, description)
+		_ = viper.BindPFlag(key, pluginCmd.PersistentFlags().Lookup(longOpt))
+	}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// pluginCmd.PersistentFlags().String("foo", "", "A help for foo")
+	{
+		const (
+			key         = plugin.KeyWait
+			longOpt     = "wait"
+			description = "Maximum time to wait for the agent to expose plugin metrics"
+		)
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// pluginCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		pluginCmd.PersistentFlags().Duration(longOpt, plugin.DefaultWait, description)
+		_ = viper.BindPFlag(key, pluginCmd.PersistentFlags().Lookup(longOpt))
+	}
 }
@@ -0,0 +1,86 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginCassandraCmd represents the cassandra plugin command
+var pluginCassandraCmd = &cobra.Command{
+	Use:   "cassandra",
+	Short: "Manage the Cassandra plugin",
+	Long: `Enable or disable the circonus-agent Cassandra plugin.
+
+Equivalent to 'cosi plugin enable|disable cassandra', the connection
+flags are passed to the cassandra manifest as plugin variables
+(e.g. --host=node1 is --set host=node1).`,
+}
+
+// pluginCassandraEnableCmd represents the cassandra plugin enable command
+var pluginCassandraEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable the Cassandra plugin",
+	Long:  `Configure the Cassandra plugin and create the cassandra graphs and dashboard.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadPluginManifest(cmd, "cassandra")
+		if err != nil {
+			return err
+		}
+		return enablePlugin(m, pluginFlagVars(cmd, "host", "port", "user", "pass", "nodetool"), "")
+	},
+}
+
+// pluginCassandraDisableCmd represents the cassandra plugin disable command
+var pluginCassandraDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable the Cassandra plugin",
+	Long:  `Remove the Cassandra plugin configuration and the cassandra graphs and dashboard.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadPluginManifest(cmd, "cassandra")
+		if err != nil {
+			return err
+		}
+		return plugin.Disable(client, m, pluginOptions())
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginCassandraCmd)
+	pluginCassandraCmd.AddCommand(pluginCassandraEnableCmd)
+	pluginCassandraCmd.AddCommand(pluginCassandraDisableCmd)
+
+	{
+		const (
+			longOpt     = "conf-file"
+			description = "Plugin configuration file used by the plugin scripts (default from manifest)"
+		)
+
+		pluginCassandraCmd.PersistentFlags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "script-dir"
+			description = "Directory containing the plugin scripts (default from manifest)"
+		)
+
+		pluginCassandraCmd.PersistentFlags().String(longOpt, "", description)
+	}
+
+	desc := func(desc, env string) string {
+		return fmt.Sprintf("[ENV: %s] %s", env, desc)
+	}
+
+	pluginCassandraEnableCmd.Flags().String("host", "", desc("Cassandra node JMX host", "COSI_CASSANDRA_HOST"))
+	pluginCassandraEnableCmd.Flags().String("port", "", desc("Cassandra node JMX port", "COSI_CASSANDRA_PORT"))
+	pluginCassandraEnableCmd.Flags().String("user", "", desc("JMX user (if JMX authentication is enabled)", "COSI_CASSANDRA_USER"))
+	pluginCassandraEnableCmd.Flags().String("pass", "", desc("JMX user password", "COSI_CASSANDRA_PASS"))
+	pluginCassandraEnableCmd.Flags().String("nodetool", "", "nodetool command used to probe the node")
+}
@@ -0,0 +1,34 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pluginDisableCmd represents the plugin disable command
+var pluginDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a plugin",
+	Long: `Disable a plugin defined by a manifest.
+
+Disables the plugin scripts, removes the agent plugin configuration
+and the graphs and dashboards created by enable.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := plugin.LoadManifest(viper.GetString(plugin.KeyManifestDir), args[0])
+		if err != nil {
+			return err
+		}
+		return plugin.Disable(client, m, pluginOptions())
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginDisableCmd)
+}
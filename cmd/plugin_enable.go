@@ -0,0 +1,79 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pluginEnableCmd represents the plugin enable command
+var pluginEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a plugin",
+	Long: `Enable a plugin defined by a manifest.
+
+Probes for the service, writes the agent plugin configuration,
+enables the plugin scripts, waits for the agent to expose the
+metrics and creates the plugin graphs and dashboards.
+
+Plugin variables (listed by 'cosi plugin status <name>') are set
+with --set name=value, the environment variable defined in the
+manifest or the manifest default.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := plugin.LoadManifest(viper.GetString(plugin.KeyManifestDir), args[0])
+		if err != nil {
+			return err
+		}
+		settings, _ := cmd.Flags().GetStringArray("set")
+		set, err := plugin.ParseSet(settings)
+		if err != nil {
+			return err
+		}
+		instance, _ := cmd.Flags().GetString("instance")
+		return enablePlugin(m, set, instance)
+	},
+}
+
+// enablePlugin enables the plugin defined by the manifest with the
+// variables set on the command line
+func enablePlugin(m *plugin.Manifest, set map[string]string, instance string) error {
+	agent, err := agentapi.New(viper.GetString(config.KeyAgentURL))
+	if err != nil {
+		return errors.Wrap(err, "creating agent API client")
+	}
+	o := pluginOptions()
+	o.Set = set
+	o.Instance = instance
+	return plugin.Enable(client, agent, m, o)
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginEnableCmd)
+
+	{
+		const (
+			longOpt     = "set"
+			description = "Set a plugin variable (name=value), may be repeated"
+		)
+
+		pluginEnableCmd.Flags().StringArray(longOpt, []string{}, description)
+	}
+
+	{
+		const (
+			longOpt     = "instance"
+			description = "Create the plugin dashboards as an instance (e.g. one per database), the manifest meta is saved as the instance meta"
+		)
+
+		pluginEnableCmd.Flags().String(longOpt, "", description)
+	}
+}
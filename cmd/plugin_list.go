@@ -0,0 +1,39 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pluginListCmd represents the plugin list command
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available plugins",
+	Long:  `List the plugins with manifests in the manifest directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		return plugin.List(os.Stdout, viper.GetString(plugin.KeyManifestDir), quiet)
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+
+	{
+		const (
+			shortOpt    = "q"
+			longOpt     = "quiet"
+			description = "no header lines, plugin names only"
+		)
+
+		pluginListCmd.Flags().BoolP(longOpt, shortOpt, false, description)
+	}
+}
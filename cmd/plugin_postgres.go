@@ -0,0 +1,87 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginPostgresCmd represents the postgres plugin command
+var pluginPostgresCmd = &cobra.Command{
+	Use:   "postgres",
+	Short: "Manage the PostgreSQL plugin",
+	Long: `Enable or disable the circonus-agent PostgreSQL plugin.
+
+Equivalent to 'cosi plugin enable|disable postgres', the connection
+flags are passed to the postgres manifest as plugin variables
+(e.g. --host=db1 is --set host=db1).`,
+}
+
+// pluginPostgresEnableCmd represents the postgres plugin enable command
+var pluginPostgresEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable the PostgreSQL plugin",
+	Long:  `Configure the PostgreSQL plugin and create the postgres graphs and dashboard.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadPluginManifest(cmd, "postgres")
+		if err != nil {
+			return err
+		}
+		return enablePlugin(m, pluginFlagVars(cmd, "host", "port", "user", "pass", "database", "psql"), "")
+	},
+}
+
+// pluginPostgresDisableCmd represents the postgres plugin disable command
+var pluginPostgresDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable the PostgreSQL plugin",
+	Long:  `Remove the PostgreSQL plugin configuration and the postgres graphs and dashboard.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadPluginManifest(cmd, "postgres")
+		if err != nil {
+			return err
+		}
+		return plugin.Disable(client, m, pluginOptions())
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginPostgresCmd)
+	pluginPostgresCmd.AddCommand(pluginPostgresEnableCmd)
+	pluginPostgresCmd.AddCommand(pluginPostgresDisableCmd)
+
+	{
+		const (
+			longOpt     = "conf-file"
+			description = "Plugin configuration file used by the plugin scripts (default from manifest)"
+		)
+
+		pluginPostgresCmd.PersistentFlags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "script-dir"
+			description = "Directory containing the plugin scripts (default from manifest)"
+		)
+
+		pluginPostgresCmd.PersistentFlags().String(longOpt, "", description)
+	}
+
+	desc := func(desc, env string) string {
+		return fmt.Sprintf("[ENV: %s] %s", env, desc)
+	}
+
+	pluginPostgresEnableCmd.Flags().String("host", "", desc("PostgreSQL server host", "PGHOST"))
+	pluginPostgresEnableCmd.Flags().String("port", "", desc("PostgreSQL server port", "PGPORT"))
+	pluginPostgresEnableCmd.Flags().String("user", "", desc("PostgreSQL user", "PGUSER"))
+	pluginPostgresEnableCmd.Flags().String("pass", "", desc("PostgreSQL user password", "PGPASSWORD"))
+	pluginPostgresEnableCmd.Flags().String("database", "", desc("PostgreSQL database", "PGDATABASE"))
+	pluginPostgresEnableCmd.Flags().String("psql", "", "psql command used to probe the server")
+}
@@ -0,0 +1,48 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/plugin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pluginStatusCmd represents the plugin status command
+var pluginStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show plugin status",
+	Long: `Show the status of a plugin defined by a manifest.
+
+Shows the plugin scripts enabled in the agent, whether the agent
+plugin configuration exists, the number of plugin metrics available
+from the agent, the registered plugin assets and the plugin variables.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := plugin.LoadManifest(viper.GetString(plugin.KeyManifestDir), args[0])
+		if err != nil {
+			return err
+		}
+		var agent plugin.AgentAPI
+		if a, err := agentapi.New(viper.GetString(config.KeyAgentURL)); err == nil {
+			agent = a
+		}
+		s, err := plugin.GetStatus(agent, m, pluginOptions())
+		if err != nil {
+			return err
+		}
+		plugin.ShowStatus(os.Stdout, m, s)
+		return nil
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginStatusCmd)
+}
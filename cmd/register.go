@@ -25,6 +25,9 @@ Create graphs for known plugins.
 Create system worksheet.
 Create system dashboard.
 Create rulesets for system check.
+
+Use --dry-run to render the assets without creating them.
+Use --update to update existing assets which differ from the current templates.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		r, err := registration.New(client)
@@ -53,6 +56,57 @@ func init() {
 		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
 	}
 
+	{
+		const (
+			key         = registration.KeyBrokerSelect
+			longOpt     = "broker-select"
+			description = "Broker selection method when more than one broker is viable (random|latency) [default: random]"
+		)
+		registerCmd.Flags().String(longOpt, "", description)
+		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = registration.KeyBrokerMaxResponseTime
+			longOpt     = "broker-max-response-time"
+			description = "Maximum time to wait for a broker connection (e.g. 500ms) [default: 500ms]"
+		)
+		registerCmd.Flags().String(longOpt, "", description)
+		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = registration.KeyDryRun
+			longOpt     = "dry-run"
+			description = "Render assets without creating them, write API payloads to directory (or '-' for stdout)"
+		)
+		registerCmd.Flags().String(longOpt, "", description)
+		registerCmd.Flags().Lookup(longOpt).NoOptDefVal = "-"
+		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = registration.KeyUpdate
+			longOpt     = "update"
+			description = "Update existing graphs, worksheets and dashboards which differ from the current templates (assets modified since registration are not changed)"
+		)
+		registerCmd.Flags().Bool(longOpt, false, description)
+		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = registration.KeyRollbackOnError
+			longOpt     = "rollback-on-error"
+			description = "Delete assets created during this run if registration fails"
+		)
+		registerCmd.Flags().Bool(longOpt, false, description)
+		_ = viper.BindPFlag(key, registerCmd.Flags().Lookup(longOpt))
+	}
+
 	{
 		const (
 			key         = registration.KeyShowConfig
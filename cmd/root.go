@@ -21,6 +21,7 @@ import (
 	"github.com/circonus-labs/cosi-tool/internal/config"
 	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
 	"github.com/circonus-labs/cosi-tool/internal/release"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
 	"github.com/circonus-labs/go-apiclient"
 	"github.com/fatih/color"
 	"github.com/gofrs/uuid"
@@ -101,6 +102,43 @@ func init() {
 		_ = viper.BindEnv(key, envVar)
 	}
 
+	// custom templates directory
+	{
+		const (
+			key         = config.KeyTemplateDir
+			longOpt     = "template-dir"
+			envVar      = release.ENVPREFIX + "_TEMPLATE_DIR"
+			description = "Custom templates directory (templates here override cached and cosi-server templates)"
+		)
+		RootCmd.PersistentFlags().String(longOpt, defaults.TemplatePath, desc(description, envVar))
+		_ = viper.BindPFlag(key, RootCmd.PersistentFlags().Lookup(longOpt))
+		_ = viper.BindEnv(key, envVar)
+		viper.SetDefault(key, defaults.TemplatePath)
+	}
+	{
+		const (
+			key         = templates.KeyCacheTTL
+			longOpt     = "template-cache-ttl"
+			envVar      = release.ENVPREFIX + "_TEMPLATE_CACHE_TTL"
+			description = "Maximum age of cached templates, expired templates are re-fetched from cosi-server (e.g. 168h, 0 = never expire)"
+		)
+		RootCmd.PersistentFlags().Duration(longOpt, templates.DefaultCacheTTL, desc(description, envVar))
+		_ = viper.BindPFlag(key, RootCmd.PersistentFlags().Lookup(longOpt))
+		_ = viper.BindEnv(key, envVar)
+		viper.SetDefault(key, templates.DefaultCacheTTL)
+	}
+	{
+		const (
+			key         = config.KeyOffline
+			longOpt     = "offline"
+			envVar      = release.ENVPREFIX + "_OFFLINE"
+			description = "Offline mode, never contact cosi-server (templates must be in the custom templates directory or imported with 'cosi template import')"
+		)
+		RootCmd.PersistentFlags().Bool(longOpt, false, desc(description, envVar))
+		_ = viper.BindPFlag(key, RootCmd.PersistentFlags().Lookup(longOpt))
+		_ = viper.BindEnv(key, envVar)
+	}
+
 	//
 	// Circonus API
 	//
@@ -246,7 +284,7 @@ func init() {
 			key         = config.KeyHostBrokerType
 			longOpt     = "broker-type"
 			envVar      = release.ENVPREFIX + "_BROKER_TYPE"
-			description = "Limit automatic broker selection to a specific type of broker"
+			description = "Limit automatic broker selection to a specific type of broker (any|enterprise|public)"
 		)
 
 		RootCmd.PersistentFlags().String(longOpt, defaults.HostBrokerType, desc(description, envVar))
@@ -0,0 +1,102 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateDiffCmd represents the diff command
+var templateDiffCmd = &cobra.Command{
+	Use:   "diff [id...]",
+	Short: "Compare local templates with cosi-server",
+	Long: `Compare local templates with the templates cosi-server currently serves
+for this system, to review upstream changes before refreshing the cache
+(cosi template refresh) and re-rendering visuals.
+
+Each template is compared using the file it is loaded from (custom
+templates directory or cache). Differences are shown per section, the
+top level template settings and each configs.<name> section (template
+body, datapoints, widgets and filters). With no ids, all local templates
+are compared.
+
+Exit status is 0 when all templates match cosi-server, 2 when any
+template differs and 1 on error.`,
+	// the Circonus API is not used, only logging needs to be initialized
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initLogging()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool(config.KeyOffline) {
+			return errors.New("diff requires cosi-server, not available in offline mode")
+		}
+
+		local, err := templates.FindLocal(viper.GetString(config.KeyTemplateDir), defaults.RegPath)
+		if err != nil {
+			return err
+		}
+		list := local
+		if len(args) > 0 {
+			byID := make(map[string]*templates.LocalTemplate, len(local))
+			for _, lt := range local {
+				byID[lt.ID] = lt
+			}
+			list = make([]*templates.LocalTemplate, 0, len(args))
+			for _, id := range args {
+				lt, ok := byID[id]
+				if !ok {
+					return errors.Errorf("template (%s) not found locally", id)
+				}
+				list = append(list, lt)
+			}
+		}
+		if len(list) == 0 {
+			return errors.New("no local templates to compare")
+		}
+
+		client, err := api.New(&api.Config{
+			OSType:    viper.GetString(config.KeySystemOSType),
+			OSDistro:  viper.GetString(config.KeySystemOSDistro),
+			OSVersion: viper.GetString(config.KeySystemOSVersion),
+			SysArch:   viper.GetString(config.KeySystemArch),
+			CosiURL:   viper.GetString(config.KeyCosiURL),
+		})
+		if err != nil {
+			return errors.Wrap(err, "creating cosi-server client")
+		}
+		tc, err := templates.New(client)
+		if err != nil {
+			return err
+		}
+
+		diffs := make([]*templates.TemplateDiff, 0, len(list))
+		for _, lt := range list {
+			d, err := tc.Diff(lt.ID, lt.File)
+			if err != nil {
+				return err
+			}
+			d.Source = lt.Source
+			diffs = append(diffs, d)
+		}
+
+		if changed := templates.ShowDiff(os.Stdout, diffs); changed > 0 {
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+func init() {
+	templateCmd.AddCommand(templateDiffCmd)
+}
@@ -0,0 +1,146 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateExportCmd represents the export command
+var templateExportCmd = &cobra.Command{
+	Use:   "export [id...]",
+	Short: "Export a template bundle for offline systems",
+	Long: `Fetch templates from cosi-server and save them in a bundle (tar.gz)
+which can be imported (cosi template import) on systems which cannot
+reach cosi-server. The bundle contains a manifest with the checksum of
+each template and is optionally signed with a shared key (--key).
+
+Templates are fetched for the --os-type, --os-distro, --os-version and
+--sys-arch settings. With no ids, the default templates and all cached
+templates are exported.
+
+    cosi template export --os-type linux --os-distro ubuntu --os-version 18.04 \
+        --sys-arch x86_64 --out ubuntu-18.04.tar.gz
+`,
+	// the Circonus API is not used, only logging needs to be initialized
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initLogging()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool(config.KeyOffline) {
+			return errors.New("export requires cosi-server, not available in offline mode")
+		}
+		out, _ := cmd.Flags().GetString("out")
+		if out == "" {
+			return errors.New("invalid output file (empty)")
+		}
+		keyFile, _ := cmd.Flags().GetString("key")
+		key, err := readBundleKey(keyFile)
+		if err != nil {
+			return err
+		}
+
+		platform := templates.Platform{
+			OSType:    viper.GetString(config.KeySystemOSType),
+			OSDistro:  viper.GetString(config.KeySystemOSDistro),
+			OSVersion: viper.GetString(config.KeySystemOSVersion),
+			SysArch:   viper.GetString(config.KeySystemArch),
+		}
+		client, err := api.New(&api.Config{
+			OSType:    platform.OSType,
+			OSDistro:  platform.OSDistro,
+			OSVersion: platform.OSVersion,
+			SysArch:   platform.SysArch,
+			CosiURL:   viper.GetString(config.KeyCosiURL),
+		})
+		if err != nil {
+			return errors.Wrap(err, "creating cosi-server client")
+		}
+		tc, err := templates.New(client)
+		if err != nil {
+			return err
+		}
+
+		ids := args
+		if len(ids) == 0 {
+			ids = append(ids, templates.IDListDefault...)
+			local, err := templates.FindLocal("", defaults.RegPath)
+			if err != nil {
+				return err
+			}
+			for _, lt := range local {
+				ids = append(ids, lt.ID)
+			}
+		}
+
+		f, err := os.Create(out)
+		if err != nil {
+			return errors.Wrap(err, "creating bundle")
+		}
+		m, err := tc.Export(f, platform, ids, key)
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = errors.Wrap(cerr, "saving bundle")
+		}
+		if err != nil {
+			os.Remove(out)
+			return err
+		}
+
+		fmt.Printf("exported %d template(s) for %s to %s\n", len(m.Templates), m.Platform, out)
+		fmt.Println(strings.Join(m.IDs(), "\n"))
+		return nil
+	},
+}
+
+// readBundleKey reads the template bundle signing key, if a key file is set
+func readBundleKey(fn string) ([]byte, error) {
+	if fn == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading bundle key")
+	}
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) == 0 {
+		return nil, errors.Errorf("invalid bundle key (%s) empty", fn)
+	}
+	return key, nil
+}
+
+func init() {
+	templateCmd.AddCommand(templateExportCmd)
+
+	{
+		const (
+			longOpt     = "out"
+			shortOpt    = "o"
+			description = "Bundle output file (e.g. templates.tar.gz)"
+		)
+
+		templateExportCmd.Flags().StringP(longOpt, shortOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "key"
+			description = "File containing a shared key used to sign the bundle"
+		)
+
+		templateExportCmd.Flags().String(longOpt, "", description)
+	}
+}
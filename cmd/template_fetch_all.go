@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
-	"github.com/circonus-labs/cosi-server/api"
 	"github.com/circonus-labs/cosi-tool/internal/config"
 	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
 	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
@@ -46,6 +45,10 @@ Fetch default templates:
 
 Fetch templates listed:
     cosi template fetch all --list=check-system,graph-cpu
+
+Templates are fetched concurrently (--workers), each fetch is limited by
+--timeout. Templates not available (e.g. no template for a plugin) are
+reported separately from failures, any failure exits non-zero.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		list := viper.GetStringSlice(templates.KeyIDList)
@@ -77,24 +80,9 @@ Fetch templates listed:
 			return nil
 		}
 
-		osType := viper.GetString(config.KeySystemOSType)
-		osDist := viper.GetString(config.KeySystemOSDistro)
-		osVers := viper.GetString(config.KeySystemOSVersion)
-		sysArch := viper.GetString(config.KeySystemArch)
-		cosiURL := viper.GetString(config.KeyCosiURL)
-
-		client, err := api.New(&api.Config{
-			OSType:    osType,
-			OSDistro:  osDist,
-			OSVersion: osVers,
-			SysArch:   sysArch,
-			CosiURL:   cosiURL,
-		})
-		if err != nil {
-			return errors.Wrap(err, "creating cosi-server client")
-		}
-
-		tc, err := templates.New(client)
+		// the templates cosi-server client is created from the configuration,
+		// it supports cancelling a fetch when --timeout is exceeded
+		tc, err := templates.New(nil)
 		if err != nil {
 			return err
 		}
@@ -103,16 +91,30 @@ Fetch templates listed:
 		if err != nil {
 			return err
 		}
+		fetched, notFound, failed := 0, 0, 0
 		for _, t := range *tt {
+			if t.NotFound {
+				notFound++
+				log.Debug().Str("template", list[t.IDX]).Msg("no template available")
+				continue
+			}
 			if t.Err != nil {
+				failed++
 				log.Warn().Str("template", list[t.IDX]).Err(t.Err).Msg("fetching template")
 				continue
 			}
 			tfile := path.Join(defaults.RegPath, strings.Join([]string{"template", t.Template.Type, t.Template.Name}, "-")+".json")
 			if err := regfiles.Save(tfile, t.Template, force); err != nil {
-				log.Warn().Str("template", list[t.IDX]).Err(t.Err).Msg("saving template")
+				failed++
+				log.Warn().Str("template", list[t.IDX]).Err(err).Msg("saving template")
 				continue
 			}
+			fetched++
+		}
+
+		fmt.Printf("%d template(s) fetched, %d not available (no template for plugin), %d failed\n", fetched, notFound, failed)
+		if failed > 0 {
+			return errors.Errorf("%d of %d template(s) failed", failed, len(list))
 		}
 
 		return nil
@@ -144,6 +146,28 @@ func init() {
 		_ = viper.BindPFlag(key, templateFetchAllCmd.Flags().Lookup(longOpt))
 	}
 
+	{
+		const (
+			key         = templates.KeyFetchWorkers
+			longOpt     = "workers"
+			description = "Number of templates to fetch concurrently"
+		)
+
+		templateFetchAllCmd.Flags().Int(longOpt, templates.DefaultFetchWorkers, description)
+		_ = viper.BindPFlag(key, templateFetchAllCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = templates.KeyFetchTimeout
+			longOpt     = "timeout"
+			description = "Timeout for fetching each template"
+		)
+
+		templateFetchAllCmd.Flags().Duration(longOpt, templates.DefaultFetchTimeout, description)
+		_ = viper.BindPFlag(key, templateFetchAllCmd.Flags().Lookup(longOpt))
+	}
+
 	{
 		const (
 			key         = templates.KeyShow
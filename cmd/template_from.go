@@ -0,0 +1,263 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/circonus-labs/cosi-tool/internal/graph"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/circonus-labs/cosi-tool/internal/worksheet"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateFromCmd represents the from command
+var templateFromCmd = &cobra.Command{
+	Use:   "from graph|dashboard|worksheet <cid>",
+	Short: "Create a template from an existing asset",
+	Long: `Fetch an existing graph, dashboard or worksheet and create a template
+from it, e.g. after tuning a graph in the UI, so it can be registered
+on every host.
+
+Host specific values are replaced with template variables:
+  host name         {{.HostName}}   (--host, default is the check target or host name)
+  system check id   {{.CheckID}}    (--check-id, default from the system check registration)
+  system check uuid {{.CheckUUID}}  (--check-uuid, default from the system check registration)
+  graph uuids       {{.GraphUUID}}  (dashboard widgets, graph_name from the graph registrations or --graph)
+  metric item       {{.Item}}       (--item, the graph becomes a variable graph)
+
+The template is saved as template-<type>-<name>.toml in the custom
+templates directory (--template-dir) or --out (- for stdout).
+
+    cosi template from graph 1234 --name cpu-tuned
+    cosi template from graph 1235 --name disk-tuned --item sda
+    cosi template from dashboard 56 --name system-tuned
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind, cid := args[0], args[1]
+
+		opts, err := templateFromOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		var tmpl *cosiapi.Template
+		switch kind {
+		case "graph":
+			g, err := graph.FetchByID(client, cid)
+			if err != nil {
+				return err
+			}
+			tmpl, err = templates.FromGraph(g, opts)
+			if err != nil {
+				return err
+			}
+		case "dashboard":
+			d, err := dashboard.FetchByID(client, cid)
+			if err != nil {
+				return err
+			}
+			tmpl, err = templates.FromDashboard(d, opts)
+			if err != nil {
+				return err
+			}
+		case "worksheet":
+			w, err := worksheet.FetchByID(client, cid)
+			if err != nil {
+				return err
+			}
+			tmpl, err = templates.FromWorksheet(w, opts)
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("invalid asset type (%s) - must be graph, dashboard or worksheet", kind)
+		}
+
+		data, err := templates.Format(tmpl)
+		if err != nil {
+			return err
+		}
+
+		out, _ := cmd.Flags().GetString("out")
+		if out == "-" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if out == "" {
+			out = templates.TemplateFile(viper.GetString(config.KeyTemplateDir), tmpl.Type+"-"+tmpl.Name)
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if _, err := os.Stat(out); err == nil && !force {
+			return errors.Errorf("template file (%s) exists, use --force to overwrite", out)
+		}
+		if err := ioutil.WriteFile(out, data, 0644); err != nil {
+			return errors.Wrap(err, "saving template")
+		}
+
+		fmt.Printf("created template %s-%s from %s %s: %s\n", tmpl.Type, tmpl.Name, kind, cid, out)
+		return nil
+	},
+}
+
+// templateFromOptions gathers the template settings and the host specific
+// values to replace from the flags, configuration and registrations
+func templateFromOptions(cmd *cobra.Command) (*templates.FromOptions, error) {
+	opts := &templates.FromOptions{}
+	opts.Name, _ = cmd.Flags().GetString("name")
+	if opts.Name == "" {
+		return nil, errors.New("invalid template name (empty), --name is required")
+	}
+	opts.Config, _ = cmd.Flags().GetString("config")
+	opts.Description, _ = cmd.Flags().GetString("description")
+	opts.Item, _ = cmd.Flags().GetString("item")
+	opts.CheckID, _ = cmd.Flags().GetUint("check-id")
+	opts.CheckUUID, _ = cmd.Flags().GetString("check-uuid")
+
+	opts.HostName, _ = cmd.Flags().GetString("host")
+	if opts.HostName == "" {
+		opts.HostName = viper.GetString(config.KeyHostTarget)
+	}
+	if opts.HostName == "" {
+		hn, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		opts.HostName = hn
+	}
+
+	// notes and tags added by cosi during registration
+	if cosiID := viper.GetString(config.KeyCosiID); cosiID != "" {
+		opts.Notes = "cosi:register,cosi_id:" + cosiID
+	}
+	opts.Tags = []string{
+		"cosi:install",
+		"distro:" + viper.GetString(config.KeySystemOSDistro) + "-" + viper.GetString(config.KeySystemOSVersion),
+		"arch:" + viper.GetString(config.KeySystemArch),
+		"os:" + viper.GetString(config.KeySystemOSType),
+	}
+
+	graphs, _ := cmd.Flags().GetStringSlice("graph")
+	opts.Graphs = make(map[string]string, len(graphs))
+	for _, g := range graphs {
+		parts := strings.SplitN(g, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid graph (%s) - must be <graph uuid>=<graph id>", g)
+		}
+		opts.Graphs[parts[0]] = parts[1]
+	}
+
+	if err := opts.LoadRegistrations(defaults.RegPath); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+func init() {
+	templateCmd.AddCommand(templateFromCmd)
+
+	{
+		const (
+			longOpt     = "name"
+			description = "Template name, the template id is <type>-<name> (required)"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "config"
+			description = "Template config name (configs.<name>), default is the template name"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "description"
+			description = "Template description"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "host"
+			description = "Host name to replace with {{.HostName}}"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "check-id"
+			description = "System check id to replace with {{.CheckID}}"
+		)
+
+		templateFromCmd.Flags().Uint(longOpt, 0, description)
+	}
+
+	{
+		const (
+			longOpt     = "check-uuid"
+			description = "System check uuid to replace with {{.CheckUUID}}"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "item"
+			description = "Metric name item to replace with {{.Item}} (graph, creates a variable graph)"
+		)
+
+		templateFromCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "graph"
+			description = "Dashboard widget graph, <graph uuid>=<graph id> (in addition to the graph registrations)"
+		)
+
+		templateFromCmd.Flags().StringSlice(longOpt, []string{}, description)
+	}
+
+	{
+		const (
+			longOpt     = "out"
+			shortOpt    = "o"
+			description = "Template output file (- for stdout), default is the custom templates directory"
+		)
+
+		templateFromCmd.Flags().StringP(longOpt, shortOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "force"
+			description = "Force save (overwrite output file)"
+		)
+
+		templateFromCmd.Flags().Bool(longOpt, false, description)
+	}
+}
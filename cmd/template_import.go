@@ -0,0 +1,100 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateImportCmd represents the import command
+var templateImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import a template bundle into the cache",
+	Long: `Verify a template bundle (created with cosi template export) and
+save the templates into the template cache, for use with --offline.
+
+The checksum of every template is verified. If the bundle is signed, the
+shared key used to sign it is required (--key). The bundle must have been
+created for this system (os type/distro/version/arch), use --force to
+import a bundle created for a different system.
+
+    cosi template import --key /opt/circonus/cosi/etc/bundle.key ubuntu-18.04.tar.gz
+`,
+	Args: cobra.ExactArgs(1),
+	// the Circonus API is not used, only logging needs to be initialized
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initLogging()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyFile, _ := cmd.Flags().GetString("key")
+		key, err := readBundleKey(keyFile)
+		if err != nil {
+			return err
+		}
+		force, _ := cmd.Flags().GetBool("force")
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrap(err, "opening bundle")
+		}
+		defer f.Close()
+
+		var system *templates.Platform
+		if !force {
+			system = &templates.Platform{
+				OSType:    viper.GetString(config.KeySystemOSType),
+				OSDistro:  viper.GetString(config.KeySystemOSDistro),
+				OSVersion: viper.GetString(config.KeySystemOSVersion),
+				SysArch:   viper.GetString(config.KeySystemArch),
+			}
+		}
+
+		viper.Set(config.KeyOffline, true) // importing never needs cosi-server
+		tc, err := templates.New(nil)
+		if err != nil {
+			return err
+		}
+		m, err := tc.Import(f, defaults.RegPath, key, system)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("imported %d template(s) for %s\n", len(m.Templates), m.Platform)
+		fmt.Println(strings.Join(m.IDs(), "\n"))
+		return nil
+	},
+}
+
+func init() {
+	templateCmd.AddCommand(templateImportCmd)
+
+	{
+		const (
+			longOpt     = "key"
+			description = "File containing the shared key used to sign the bundle"
+		)
+
+		templateImportCmd.Flags().String(longOpt, "", description)
+	}
+
+	{
+		const (
+			longOpt     = "force"
+			description = "Import a bundle created for a different system"
+		)
+
+		templateImportCmd.Flags().Bool(longOpt, false, description)
+	}
+}
@@ -6,36 +6,44 @@
 package cmd
 
 import (
-	"fmt"
+	"os"
 
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // templateListCmd represents the list command
 var templateListCmd = &cobra.Command{
 	Use:   "list",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("list called")
+	Short: "List local templates",
+	Long: `List the templates available locally and the source each is loaded from.
+
+Templates are loaded, in order of precedence, from:
+  custom      - the custom templates directory (--template-dir)
+  cache       - the registration directory (templates fetched from cosi-server)
+  cosi-server - fetched, and cached, when not found locally
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quiet := viper.GetBool(templates.KeyQuiet)
+		return templates.List(os.Stdout, viper.GetString(config.KeyTemplateDir), defaults.RegPath, quiet)
 	},
 }
 
 func init() {
 	templateCmd.AddCommand(templateListCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// templateListCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// templateListCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	{
+		const (
+			key         = templates.KeyQuiet
+			shortOpt    = "q"
+			longOpt     = "quiet"
+			description = "no header lines"
+		)
+
+		templateListCmd.Flags().BoolP(longOpt, shortOpt, templates.QuietDefault, description)
+		_ = viper.BindPFlag(key, templateListCmd.Flags().Lookup(longOpt))
+	}
 }
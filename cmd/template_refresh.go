@@ -0,0 +1,96 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/registration"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateRefreshCmd represents the refresh command
+var templateRefreshCmd = &cobra.Command{
+	Use:   "refresh [id...]",
+	Short: "Refresh cached templates from COSI API",
+	Long: `Re-fetch cached templates from the COSI API, update the cached
+templates which have changed and report what changed.
+
+Use --update to update the existing graphs, worksheets and dashboards
+created from the changed templates (see 'cosi register --update').
+
+Refresh all cached templates:
+    cosi template refresh --all
+
+Refresh specific templates and update the assets using them:
+    cosi template refresh --update graph-cpu dashboard-system
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all := viper.GetBool(templates.KeyRefreshAll)
+		if len(args) == 0 && !all {
+			return errors.New("template id(s) or --all required")
+		}
+		if len(args) > 0 && all {
+			return errors.New("template id(s) and --all are mutually exclusive")
+		}
+
+		tc, err := templates.New(nil)
+		if err != nil {
+			return err
+		}
+
+		results, err := tc.Refresh(defaults.RegPath, args)
+		if err != nil {
+			return err
+		}
+		templates.ShowRefresh(os.Stdout, results)
+
+		changed := templates.ChangedTemplates(results)
+		if !viper.GetBool(templates.KeyRefreshUpdate) || len(changed) == 0 {
+			return nil
+		}
+
+		log.Info().Strs("templates", changed).Msg("updating assets using changed templates")
+		viper.Set(registration.KeyUpdate, true)
+		viper.Set(registration.KeyTemplateList, changed)
+		r, err := registration.New(client)
+		if err != nil {
+			return err
+		}
+		return r.Register()
+	},
+}
+
+func init() {
+	templateCmd.AddCommand(templateRefreshCmd)
+
+	{
+		const (
+			key         = templates.KeyRefreshAll
+			longOpt     = "all"
+			description = "Refresh all cached templates"
+		)
+
+		templateRefreshCmd.Flags().Bool(longOpt, false, description)
+		_ = viper.BindPFlag(key, templateRefreshCmd.Flags().Lookup(longOpt))
+	}
+
+	{
+		const (
+			key         = templates.KeyRefreshUpdate
+			longOpt     = "update"
+			description = "Update the assets created from changed templates"
+		)
+
+		templateRefreshCmd.Flags().Bool(longOpt, false, description)
+		_ = viper.BindPFlag(key, templateRefreshCmd.Flags().Lookup(longOpt))
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration"
+	"github.com/spf13/cobra"
+)
+
+// templateRenderCmd represents the render command
+var templateRenderCmd = &cobra.Command{
+	Use:   "render <id>",
+	Short: "Render a template against the agent metrics",
+	Long: `Preview a template, expanding it against the metrics available from
+the local agent and writing the resulting check, graph, worksheet or
+dashboard API payloads to stdout.
+
+Variable graphs and datapoints are expanded for each matching metric.
+Nothing is created and the Circonus API is not used, the template is
+loaded from the custom templates directory, the cache or cosi-server.
+
+    cosi template render graph-disk
+`,
+	Args: cobra.ExactArgs(1),
+	// the Circonus API is not used, only logging needs to be initialized
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initLogging()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := registration.NewRender(args[0], os.Stdout)
+		if err != nil {
+			return err
+		}
+		return r.Render()
+	},
+}
+
+func init() {
+	templateCmd.AddCommand(templateRenderCmd)
+}
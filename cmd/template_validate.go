@@ -0,0 +1,119 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateValidateCmd represents the validate command
+var templateValidateCmd = &cobra.Command{
+	Use:   "validate [file|id...]",
+	Short: "Validate local templates",
+	Long: `Lint template files before they are used for registration.
+
+Checks the toml structure against a COSI template, compiles each
+metric_regex and filter, expands each config, datapoint and widget
+template with sample variables and verifies the result parses as the
+Circonus API object for the template type. Issues are reported as
+file:line: key: message.
+
+Arguments are template files or template ids (loaded from the custom
+templates directory or the cache). With no arguments all local
+templates are validated.
+
+Variables not provided during registration (e.g. dashboard meta
+variables) can be set with --var:
+    cosi template validate --var Port=5432 dashboard-postgres
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, _ := cmd.Flags().GetStringArray("var")
+		vars, err := templates.ParseVars(settings)
+		if err != nil {
+			return err
+		}
+
+		customDir := viper.GetString(config.KeyTemplateDir)
+		files := []string{}
+		if len(args) == 0 {
+			local, err := templates.FindLocal(customDir, defaults.RegPath)
+			if err != nil {
+				return err
+			}
+			for _, lt := range local {
+				files = append(files, lt.File)
+			}
+			if len(files) == 0 {
+				return errors.New("no local templates found")
+			}
+		}
+		for _, arg := range args {
+			fn, err := templateValidateFile(customDir, arg)
+			if err != nil {
+				return err
+			}
+			files = append(files, fn)
+		}
+
+		invalid := 0
+		for _, fn := range files {
+			issues, err := templates.ValidateFile(fn, vars)
+			if err != nil {
+				return errors.Wrap(err, fn)
+			}
+			if len(issues) == 0 {
+				fmt.Printf("%s: OK\n", fn)
+				continue
+			}
+			invalid++
+			templates.ShowIssues(os.Stdout, fn, issues)
+		}
+
+		if invalid > 0 {
+			return errors.Errorf("%d of %d template(s) invalid", invalid, len(files))
+		}
+		return nil
+	},
+}
+
+// templateValidateFile returns the file for a validate argument, either
+// an existing file or the local file for a template id
+func templateValidateFile(customDir, arg string) (string, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return arg, nil
+	}
+	for _, dir := range []string{customDir, defaults.RegPath} {
+		if dir == "" {
+			continue
+		}
+		fn := templates.TemplateFile(dir, arg)
+		if _, err := os.Stat(fn); err == nil {
+			return fn, nil
+		}
+	}
+	return "", errors.Errorf("template (%s) not found, not a file or a local template id", arg)
+}
+
+func init() {
+	templateCmd.AddCommand(templateValidateCmd)
+
+	{
+		const (
+			longOpt     = "var"
+			description = "Template variable (Name=value) used when expanding templates, may be repeated"
+		)
+
+		templateValidateCmd.Flags().StringArray(longOpt, []string{}, description)
+	}
+}
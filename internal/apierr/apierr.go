@@ -0,0 +1,22 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package apierr classifies errors returned by the Circonus and cosi-server
+// API clients
+package apierr
+
+import "strings"
+
+// IsNotFound returns true if the error is an API 404, the object does not
+// exist (e.g. an asset deleted in the UI or no template for a plugin).
+// The Circonus API client reports "API response code 404: ..." and the
+// cosi-server API client reports "404 Not Found - ...".
+func IsNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "API response code 404") || strings.Contains(msg, "404 Not Found")
+}
@@ -0,0 +1,39 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package apierr
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestIsNotFound(t *testing.T) {
+	t.Log("Testing IsNotFound")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"other error", errors.New("API response code 500: internal error"), false},
+		{"circonus api", errors.New(`API response code 404: {"code":"ObjectError.NotFound"}`), true},
+		{"circonus api (wrapped)", pkgerrors.Wrap(errors.New("API response code 404: not found"), "fetching graph"), true},
+		{"cosi-server api", errors.New("404 Not Found - https://setup.circonus.com/template/graph-foo - not found"), true},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsNotFound(tst.err); got != tst.expected {
+				t.Fatalf("expected %v got %v", tst.expected, got)
+			}
+		})
+	}
+}
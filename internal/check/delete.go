@@ -8,10 +8,10 @@ package check
 import (
 	"encoding/json"
 	"io/ioutil"
-	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 )
@@ -59,15 +59,14 @@ func Delete(client CircAPI, regDir, id, checkType, in string) error {
 			return errors.Errorf("invalid check type (%s)", checkType)
 		}
 
-		regFile := filepath.Join(regDir, "registration-check-"+checkType+".json")
-		data, err := ioutil.ReadFile(regFile)
+		regFile := regfiles.File(regDir, "check-"+checkType)
+		var c circapi.CheckBundle
+		found, err := regfiles.Load(regFile, &c)
 		if err != nil {
 			return errors.Wrap(err, "loading check type")
 		}
-
-		var c *circapi.CheckBundle
-		if err := json.Unmarshal(data, &c); err != nil {
-			return errors.Wrap(err, "parsing json")
+		if !found {
+			return errors.Errorf("loading check type: %s not found", regFile)
 		}
 
 		cid = c.CID
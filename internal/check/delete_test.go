@@ -55,7 +55,7 @@ func TestDelete(t *testing.T) {
 	t.Log("\tinvalid type (group - missing)")
 	if err := Delete(client, "testdata/", "", "group", ""); err == nil {
 		t.Fatal("expected error")
-	} else if err.Error() != "loading check type: open testdata/registration-check-group.json: no such file or directory" {
+	} else if err.Error() != "loading check type: testdata/registration-check-group.json not found" {
 		t.Fatalf("expected different error, got (%v)", err)
 	}
 
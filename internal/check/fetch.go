@@ -6,12 +6,10 @@
 package check
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 )
@@ -101,15 +99,14 @@ func FetchByType(client CircAPI, regDir, checkType string) (*circapi.CheckBundle
 		return nil, errors.Errorf("invalid check type (%s)", checkType)
 	}
 
-	regFile := filepath.Join(regDir, "registration-check-"+checkType+".json")
-	data, err := ioutil.ReadFile(regFile)
+	regFile := regfiles.File(regDir, "check-"+checkType)
+	var b circapi.CheckBundle
+	found, err := regfiles.Load(regFile, &b)
 	if err != nil {
 		return nil, errors.Wrap(err, "loading check type")
 	}
-
-	var b *circapi.CheckBundle
-	if err := json.Unmarshal(data, &b); err != nil {
-		return nil, errors.Wrap(err, "parsing json")
+	if !found {
+		return nil, errors.Errorf("loading check type: %s not found", regFile)
 	}
 
 	return FetchByID(client, b.CID)
@@ -65,8 +65,9 @@ func TestFetchByType(t *testing.T) {
 		{"invalid (empty dir)", client, "", "", "invalid registration directory (empty)"},
 		{"invalid (empty type)", client, regDir, "", "invalid check type (empty)"},
 		{"invalid (type)", client, regDir, "foo", "invalid check type (foo)"},
-		{"invalid (missing)", client, regDir, "group", "loading check type: open testdata/registration-check-group.json: no such file or directory"},
+		{"invalid (missing)", client, regDir, "group", "loading check type: testdata/registration-check-group.json not found"},
 		{"valid", client, regDir, "system", ""},
+		{"valid (yaml)", client, "testdata/yaml", "system", ""},
 	}
 
 	for _, test := range tests {
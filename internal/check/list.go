@@ -6,10 +6,8 @@
 package check
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path"
 	"strings"
 	"time"
@@ -74,18 +72,17 @@ func getDetail(client CircAPI, regDir, regFile, uiURL string, verifyCheck bool)
 
 	var b circapi.CheckBundle
 
-	data, err := ioutil.ReadFile(path.Join(regDir, regFile))
+	found, err := regfiles.Load(path.Join(regDir, regFile), &b)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading check registration file")
 	}
-
-	if err := json.Unmarshal(data, &b); err != nil {
-		return nil, errors.Wrap(err, "parsing check registration json")
+	if !found {
+		return nil, errors.Errorf("check registration file (%s) not found", regFile)
 	}
 
 	cosiType := strings.Replace(regFile, "registration-", "", 1)
 	cosiType = strings.Replace(cosiType, "check-", "", 1)
-	cosiType = strings.Replace(cosiType, ".json", "", 1)
+	cosiType = strings.TrimSuffix(cosiType, path.Ext(cosiType))
 
 	lm := time.Unix(int64(b.LastModified), 0)
 
@@ -68,7 +68,7 @@ func TestGetDetail(t *testing.T) {
 	t.Log("\tinvalid regfile (json parse)")
 	if _, err := getDetail(client, "testdata/", "bad.json", uiURL, false); err == nil {
 		t.Fatal("expected error")
-	} else if err.Error() != "parsing check registration json: unexpected end of JSON input" {
+	} else if err.Error() != "reading check registration file: parsing registration (testdata/bad.json): unexpected end of JSON input" {
 		t.Fatalf("unexpected error (%v)", err)
 	}
 
@@ -32,7 +32,7 @@ const (
 	// HostBrokerID is the id of a specific broker to use when creating checks (an id or 0 which means auto select)
 	HostBrokerID = 0
 	// HostBrokerType is a specific type of broker to limit to when selecting a broker automatically
-	// (any|enterprise)
+	// (any|enterprise|public)
 	HostBrokerType = "any"
 
 	// Debug is false by default
@@ -71,6 +71,14 @@ var (
 
 	// RegConf defines the registration options configuration file
 	RegConf = ""
+
+	// PluginPath defines the plugin manifests path
+	// (e.g. /opt/circonus/cosi/etc/plugins)
+	PluginPath = ""
+
+	// TemplatePath defines the custom (local) templates path
+	// (e.g. /opt/circonus/cosi/etc/templates)
+	TemplatePath = ""
 )
 
 func init() {
@@ -98,6 +106,10 @@ func init() {
 
 	RegConf = filepath.Join(EtcPath, "regconf")
 
+	PluginPath = filepath.Join(EtcPath, "plugins")
+
+	TemplatePath = filepath.Join(EtcPath, "templates")
+
 	hn, err := os.Hostname()
 	if err != nil {
 		log.Fatal().Err(err).Msg("obtaining hostname from OS")
@@ -130,7 +130,7 @@ const (
 	// KeyHostBrokerID defines the broker to use when creating a check
 	KeyHostBrokerID = "checks.broker.id"
 
-	// KeyHostBrokerType defines the 'type' of broker to use (any|enterprise)
+	// KeyHostBrokerType defines the 'type' of broker to use (any|enterprise|public)
 	KeyHostBrokerType = "checks.broker.type"
 
 	// KeyHostGroupID defines the group ID (if this system will be used
@@ -160,6 +160,14 @@ const (
 	// KeyRegConf defines the registration options configuration file
 	KeyRegConf = "register.config"
 
+	// KeyTemplateDir defines the custom (local) templates directory,
+	// templates found here take precedence over cached and cosi-server templates
+	KeyTemplateDir = "template_dir"
+
+	// KeyOffline never contacts cosi-server, templates must be available
+	// locally (custom templates directory or an imported template bundle)
+	KeyOffline = "offline"
+
 	//
 	// generic flags
 	//
@@ -0,0 +1,258 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Instance defines one copy of a dashboard template (e.g. one per database
+// for dashboard-postgres). The meta variables are used, in addition to the
+// template meta, when creating the dashboard. The dashboard registration is
+// registration-<template id>@<instance>.json.
+type Instance struct {
+	TemplateID string
+	Name       string
+	Meta       map[string]string
+}
+
+const (
+	// InstanceVar is the template variable set to the instance name
+	InstanceVar = "DashboardInstance"
+	// InstanceSeparator separates the template id and instance in ids and
+	// file names, it cannot occur in a template id, dashboard config name
+	// or instance name so an instance cannot collide with the dashboards
+	// of a template (e.g. dashboard-postgres@main vs dashboard-postgres-main)
+	InstanceSeparator = "@"
+
+	instanceMetaPrefix = "meta-"
+)
+
+var (
+	instanceValidator = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+)
+
+// ValidateInstance verifies an instance name
+func ValidateInstance(instance string) error {
+	if instance == "" {
+		return errors.New("invalid instance (empty)")
+	}
+	if !instanceValidator.MatchString(instance) {
+		return errors.Errorf("invalid instance (%s) - may only contain letters, digits, '_' and '.'", instance)
+	}
+	return nil
+}
+
+// InstanceMetaFile returns the meta data file for a dashboard instance
+// (meta-<template id>@<instance>.json)
+func InstanceMetaFile(regDir, templateID, instance string) string {
+	return path.Join(regDir, instanceMetaPrefix+InstanceID(templateID, instance)+".json")
+}
+
+// InstanceID returns the registration id for a dashboard instance
+func InstanceID(templateID, instance string) string {
+	return templateID + InstanceSeparator + instance
+}
+
+// FindInstances returns the dashboard instances defined in the registration
+// directory, for the template or all templates if templateID is empty
+func FindInstances(regDir, templateID string) ([]*Instance, error) {
+	if regDir == "" {
+		return nil, errors.New("invalid registration directory (empty)")
+	}
+
+	pattern := instanceMetaPrefix + "dashboard-*" + InstanceSeparator + "*.json"
+	if templateID != "" {
+		pattern = instanceMetaPrefix + templateID + InstanceSeparator + "*.json"
+	}
+	files, err := filepath.Glob(path.Join(regDir, pattern))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding dashboard instances")
+	}
+	sort.Strings(files)
+
+	instances := []*Instance{}
+	for _, file := range files {
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), instanceMetaPrefix), ".json")
+		sep := strings.LastIndex(id, InstanceSeparator)
+		inst := Instance{TemplateID: id[:sep], Name: id[sep+len(InstanceSeparator):]}
+		if ValidateInstance(inst.Name) != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading dashboard instance meta")
+		}
+		if err := json.Unmarshal(data, &inst.Meta); err != nil {
+			return nil, errors.Wrapf(err, "parsing dashboard instance meta (%s)", file)
+		}
+		instances = append(instances, &inst)
+	}
+
+	return instances, nil
+}
+
+// SaveInstance writes the meta data file for a dashboard instance, meta
+// keys must start with an uppercase character and match the template exactly
+func SaveInstance(regDir, templateID, instance string, meta map[string]string) error {
+	if regDir == "" {
+		return errors.New("invalid registration directory (empty)")
+	}
+	if !strings.HasPrefix(templateID, "dashboard-") || strings.Contains(templateID, InstanceSeparator) {
+		return errors.Errorf("invalid dashboard template id (%s)", templateID)
+	}
+	if err := ValidateInstance(instance); err != nil {
+		return err
+	}
+	for k := range meta {
+		if k == "" || k[0] < 'A' || k[0] > 'Z' {
+			return errors.Errorf("invalid meta key (%s) - must start with an uppercase character", k)
+		}
+	}
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	return regfiles.Save(InstanceMetaFile(regDir, templateID, instance), meta, true)
+}
+
+// ParseInstanceMeta parses instance meta variable settings (Key=value)
+func ParseInstanceMeta(settings []string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, setting := range settings {
+		kv := strings.SplitN(setting, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid meta setting (%s) - must be Key=value", setting)
+		}
+		meta[kv[0]] = kv[1]
+	}
+	return meta, nil
+}
+
+// InstanceRegistrations returns the registration files for a dashboard instance
+func InstanceRegistrations(regDir, templateID, instance string) ([]string, error) {
+	regs, err := regfiles.Find(regDir, "dashboard")
+	if err != nil {
+		return nil, errors.Wrap(err, "loading dashboard registrations")
+	}
+	sig := "registration-" + InstanceID(templateID, instance)
+	found := []string{}
+	for _, rf := range *regs {
+		id := strings.TrimSuffix(rf, path.Ext(rf))
+		if id == sig || strings.HasPrefix(id, sig+"-") {
+			found = append(found, rf)
+		}
+	}
+	return found, nil
+}
+
+// DeleteInstance deletes the dashboard(s) for an instance, removes the
+// registration files and the instance meta data file. Dashboards already
+// deleted are ignored.
+func DeleteInstance(client CircAPI, regDir, templateID, instance string) error {
+	if client == nil {
+		return errors.New("invalid state, nil client")
+	}
+	if err := ValidateInstance(instance); err != nil {
+		return err
+	}
+
+	logger := log.With().Str("cmd", "cosi dashboard instance delete").Logger()
+
+	metaFile := InstanceMetaFile(regDir, templateID, instance)
+	regs, err := InstanceRegistrations(regDir, templateID, instance)
+	if err != nil {
+		return err
+	}
+	if len(regs) == 0 {
+		if _, err := os.Stat(metaFile); os.IsNotExist(err) {
+			return errors.Errorf("unknown dashboard instance (%s %s)", templateID, instance)
+		}
+	}
+
+	for _, rf := range regs {
+		regFile := path.Join(regDir, rf)
+		var db circapi.Dashboard
+		if _, err := regfiles.Load(regFile, &db); err != nil {
+			return err
+		}
+		if db.CID != "" {
+			logger.Info().Str("cid", db.CID).Str("instance", instance).Msg("deleting dashboard")
+			cid := db.CID
+			if _, err := client.DeleteDashboardByCID(&cid); err != nil && !apierr.IsNotFound(err) {
+				return errors.Wrapf(err, "deleting dashboard (%s)", db.CID)
+			}
+		}
+		if err := os.Remove(regFile); err != nil {
+			return errors.Wrap(err, "removing registration")
+		}
+	}
+
+	if err := os.Remove(metaFile); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing dashboard instance meta")
+	}
+
+	return nil
+}
+
+// ListInstances displays the dashboard instances, for the template or all
+// templates if templateID is empty
+func ListInstances(w io.Writer, regDir, templateID string, quiet bool) error {
+	instances, err := FindInstances(regDir, templateID)
+	if err != nil {
+		return err
+	}
+
+	format := "%-25s %-20s %-20s %s\n"
+	if !quiet {
+		fmt.Fprintf(w, format, "Template", "Instance", "CID", "Meta")
+	}
+
+	for _, inst := range instances {
+		cids := []string{}
+		regs, err := InstanceRegistrations(regDir, inst.TemplateID, inst.Name)
+		if err != nil {
+			return err
+		}
+		for _, rf := range regs {
+			var db circapi.Dashboard
+			if _, err := regfiles.Load(path.Join(regDir, rf), &db); err != nil {
+				return err
+			}
+			cids = append(cids, db.CID)
+		}
+		cid := "not registered"
+		if len(cids) > 0 {
+			cid = strings.Join(cids, ",")
+		}
+		keys := make([]string, 0, len(inst.Meta))
+		for k := range inst.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		meta := make([]string, 0, len(keys))
+		for _, k := range keys {
+			meta = append(meta, k+"="+inst.Meta[k])
+		}
+		fmt.Fprintf(w, format, inst.TemplateID, inst.Name, cid, strings.Join(meta, " "))
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboard
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestValidateInstance(t *testing.T) {
+	t.Log("Testing ValidateInstance")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tests := []struct {
+		name        string
+		instance    string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (empty)", "", true, "invalid instance (empty)"},
+		{"invalid (dash)", "db-1", true, "invalid instance (db-1) - may only contain letters, digits, '_' and '.'"},
+		{"valid", "db_1.main", false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			err := ValidateInstance(tst.instance)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+		})
+	}
+}
+
+func TestInstances(t *testing.T) {
+	t.Log("Testing SaveInstance/FindInstances/ListInstances/DeleteInstance")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-dashboard-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Log("invalid (template id)")
+	{
+		err := SaveInstance(dir, "graph-foo", "db1", nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid dashboard template id (graph-foo)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("invalid (meta key)")
+	{
+		err := SaveInstance(dir, "dashboard-postgres", "db1", map[string]string{"db": "foo"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid meta key (db) - must start with an uppercase character" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	instances := map[string]string{
+		"db1":  "/dashboard/123",
+		"db10": "",
+	}
+	for inst, cid := range instances {
+		if err := SaveInstance(dir, "dashboard-postgres", inst, map[string]string{"Database": inst}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if cid != "" {
+			if err := regfiles.Save(filepath.Join(dir, "registration-"+InstanceID("dashboard-postgres", inst)+".json"), &circapi.Dashboard{CID: cid}, true); err != nil {
+				t.Fatalf("saving registration (%s)", err)
+			}
+		}
+	}
+	if err := SaveInstance(dir, "dashboard-postgres-extra", "db1", nil); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	t.Log("FindInstances (template)")
+	{
+		found, err := FindInstances(dir, "dashboard-postgres")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(found) != 2 {
+			t.Fatalf("expected 2 instances, got %d", len(found))
+		}
+		if found[0].Name != "db1" || found[0].Meta["Database"] != "db1" {
+			t.Fatalf("unexpected instance (%#v)", found[0])
+		}
+	}
+
+	t.Log("FindInstances (all)")
+	{
+		found, err := FindInstances(dir, "")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(found) != 3 {
+			t.Fatalf("expected 3 instances, got %d", len(found))
+		}
+	}
+
+	t.Log("ListInstances")
+	{
+		var buf bytes.Buffer
+		if err := ListInstances(&buf, dir, "dashboard-postgres", true); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+		if !strings.Contains(lines[0], "/dashboard/123") || !strings.Contains(lines[1], "not registered") {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+	}
+
+	client := genMockClient()
+
+	t.Log("DeleteInstance (unknown)")
+	{
+		err := DeleteInstance(client, dir, "dashboard-postgres", "db2")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "unknown dashboard instance (dashboard-postgres db2)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("DeleteInstance")
+	{
+		if err := DeleteInstance(client, dir, "dashboard-postgres", "db1"); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		found, err := FindInstances(dir, "dashboard-postgres")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(found) != 1 || found[0].Name != "db10" {
+			t.Fatalf("expected only db10 instance to remain (%v)", found)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "registration-dashboard-postgres@db1.json")); !os.IsNotExist(err) {
+			t.Fatalf("expected registration to be removed (%v)", err)
+		}
+	}
+
+	t.Log("instance does not collide with template dashboard")
+	{
+		// dashboard-postgres template config "main" vs instance "main"
+		templateReg := filepath.Join(dir, "registration-dashboard-postgres-main.json")
+		if err := regfiles.Save(templateReg, &circapi.Dashboard{CID: "/dashboard/456"}, true); err != nil {
+			t.Fatalf("saving registration (%s)", err)
+		}
+		if err := SaveInstance(dir, "dashboard-postgres", "main", nil); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if InstanceID("dashboard-postgres", "main") == "dashboard-postgres-main" {
+			t.Fatal("instance id collides with template dashboard id")
+		}
+		regs, err := InstanceRegistrations(dir, "dashboard-postgres", "main")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(regs) != 0 {
+			t.Fatalf("expected no instance registrations (%v)", regs)
+		}
+		if err := DeleteInstance(client, dir, "dashboard-postgres", "main"); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if _, err := os.Stat(templateReg); err != nil {
+			t.Fatalf("expected template dashboard registration to remain (%v)", err)
+		}
+	}
+}
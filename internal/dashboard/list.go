@@ -6,10 +6,8 @@
 package dashboard
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path"
 	"strings"
 	"time"
@@ -72,18 +70,17 @@ func getDetail(client CircAPI, regDir, regFile, uiURL string, verifyCheck bool)
 
 	var db circapi.Dashboard
 
-	data, err := ioutil.ReadFile(path.Join(regDir, regFile))
+	found, err := regfiles.Load(path.Join(regDir, regFile), &db)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading dashboard registration file")
 	}
-
-	if err := json.Unmarshal(data, &db); err != nil {
-		return nil, errors.Wrap(err, "parsing dashboard registration json")
+	if !found {
+		return nil, errors.Errorf("dashboard registration file (%s) not found", regFile)
 	}
 
 	cosiType := strings.Replace(regFile, "registration-", "", 1)
 	cosiType = strings.Replace(cosiType, "dashboard-", "", 1)
-	cosiType = strings.Replace(cosiType, ".json", "", 1)
+	cosiType = strings.TrimSuffix(cosiType, path.Ext(cosiType))
 
 	lm := time.Unix(int64(db.LastModified), 0)
 
@@ -0,0 +1,19 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package drift
+
+//go:generate moq -out api_circ_test.go . CircAPI
+
+import circapi "github.com/circonus-labs/go-apiclient"
+
+// CircAPI interface abstraction of circonus api (for mocking)
+type CircAPI interface {
+	FetchCheckBundle(cid circapi.CIDType) (*circapi.CheckBundle, error)
+	FetchDashboard(cid circapi.CIDType) (*circapi.Dashboard, error)
+	FetchGraph(cid circapi.CIDType) (*circapi.Graph, error)
+	FetchRuleSet(cid circapi.CIDType) (*circapi.RuleSet, error)
+	FetchWorksheet(cid circapi.CIDType) (*circapi.Worksheet, error)
+}
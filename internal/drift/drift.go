@@ -0,0 +1,210 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package drift compares cosi created assets with their registrations
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	// KeyFormat output format (text|json)
+	KeyFormat = "drift.format"
+	// DefaultFormat is the default output format
+	DefaultFormat = "text"
+
+	// StatusOK asset matches registration
+	StatusOK = "OK"
+	// StatusDrift asset differs from registration
+	StatusDrift = "Drift"
+	// StatusDeleted asset no longer exists on the server
+	StatusDeleted = "Deleted"
+)
+
+// assetTypes in the order they are verified
+var assetTypes = []string{"check", "graph", "worksheet", "dashboard", "ruleset"}
+
+// Result of verifying a single asset against its registration
+type Result struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	File    string            `json:"file"`
+	CID     string            `json:"cid"`
+	Status  string            `json:"status"`
+	Changes []regfiles.Change `json:"changes,omitempty"`
+}
+
+// Run verifies all registered assets, writes the results to w in the
+// requested format and returns true if any asset has drifted or been deleted.
+func Run(client CircAPI, w io.Writer, regDir, format string) (bool, error) {
+	if format != "text" && format != "json" {
+		return false, errors.Errorf("invalid format (%s)", format)
+	}
+
+	results, err := Check(client, regDir)
+	if err != nil {
+		return false, err
+	}
+
+	drifted := false
+	for _, r := range results {
+		if r.Status != StatusOK {
+			drifted = true
+			break
+		}
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return false, errors.Wrap(err, "formatting results")
+		}
+		fmt.Fprintln(w, string(data))
+		return drifted, nil
+	}
+
+	show(w, results)
+	return drifted, nil
+}
+
+// Check fetches every asset referenced in the registration directory and
+// compares it with the saved registration.
+func Check(client CircAPI, regDir string) ([]*Result, error) {
+	if client == nil {
+		return nil, errors.New("invalid client (nil)")
+	}
+	if regDir == "" {
+		return nil, errors.New("invalid regdir (empty)")
+	}
+
+	results := []*Result{}
+	for _, assetType := range assetTypes {
+		assets, err := regfiles.Find(regDir, assetType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading '%s' registrations", assetType)
+		}
+		for _, asset := range *assets {
+			r, err := verify(client, assetType, filepath.Join(regDir, asset))
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+	}
+
+	return results, nil
+}
+
+// verify compares a single registration with the current asset
+func verify(client CircAPI, assetType, regFile string) (*Result, error) {
+	r := &Result{
+		Type:   assetType,
+		ID:     strings.TrimSuffix(strings.TrimPrefix(filepath.Base(regFile), "registration-"), filepath.Ext(regFile)),
+		File:   regFile,
+		Status: StatusOK,
+	}
+
+	var reg, current interface{}
+	var err error
+
+	switch assetType {
+	case "check":
+		var v circapi.CheckBundle
+		if _, err := regfiles.Load(regFile, &v); err != nil {
+			return nil, err
+		}
+		r.CID = v.CID
+		reg = &v
+		var b *circapi.CheckBundle
+		b, err = client.FetchCheckBundle(circapi.CIDType(&v.CID))
+		if err == nil && b.Status == "deleted" {
+			r.Status = StatusDeleted
+			return r, nil
+		}
+		current = b
+	case "graph":
+		var v circapi.Graph
+		if _, err := regfiles.Load(regFile, &v); err != nil {
+			return nil, err
+		}
+		r.CID = v.CID
+		reg = &v
+		current, err = client.FetchGraph(circapi.CIDType(&v.CID))
+	case "worksheet":
+		var v circapi.Worksheet
+		if _, err := regfiles.Load(regFile, &v); err != nil {
+			return nil, err
+		}
+		r.CID = v.CID
+		reg = &v
+		current, err = client.FetchWorksheet(circapi.CIDType(&v.CID))
+	case "dashboard":
+		var v circapi.Dashboard
+		if _, err := regfiles.Load(regFile, &v); err != nil {
+			return nil, err
+		}
+		r.CID = v.CID
+		reg = &v
+		current, err = client.FetchDashboard(circapi.CIDType(&v.CID))
+	case "ruleset":
+		var v circapi.RuleSet
+		if _, err := regfiles.Load(regFile, &v); err != nil {
+			return nil, err
+		}
+		r.CID = v.CID
+		reg = &v
+		current, err = client.FetchRuleSet(circapi.CIDType(&v.CID))
+	default:
+		return nil, errors.Errorf("invalid asset type (%s)", assetType)
+	}
+
+	if err != nil {
+		if apierr.IsNotFound(err) {
+			r.Status = StatusDeleted
+			return r, nil
+		}
+		return nil, errors.Wrapf(err, "fetching %s (%s)", r.ID, r.CID)
+	}
+
+	changes, err := regfiles.Diff(reg, current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "comparing %s", r.ID)
+	}
+	if len(changes) > 0 {
+		r.Status = StatusDrift
+		r.Changes = changes
+	}
+
+	return r, nil
+}
+
+func show(w io.Writer, results []*Result) {
+	format := "%-10s %-40s %-40s %-8s\n"
+	fmt.Fprintf(w, format, "Type", "ID", "CID", "Status")
+	numDrift, numDeleted := 0, 0
+	for _, r := range results {
+		fmt.Fprintf(w, format, r.Type, r.ID, r.CID, r.Status)
+		for _, c := range r.Changes {
+			fmt.Fprintf(w, "    %s\n", c.String())
+		}
+		switch r.Status {
+		case StatusDrift:
+			numDrift++
+		case StatusDeleted:
+			numDeleted++
+		}
+	}
+	fmt.Fprintf(w, "\n%d asset(s) verified, %d drifted, %d deleted\n", len(results), numDrift, numDeleted)
+}
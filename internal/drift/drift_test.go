@@ -0,0 +1,232 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package drift
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func genMockClient() *CircAPIMock {
+	return &CircAPIMock{
+		FetchCheckBundleFunc: func(cid circapi.CIDType) (*circapi.CheckBundle, error) {
+			if *cid == "/check_bundle/deleted" {
+				return &circapi.CheckBundle{CID: *cid, Status: "deleted"}, nil
+			}
+			return &circapi.CheckBundle{CID: *cid, DisplayName: "foo", Status: "active"}, nil
+		},
+		FetchDashboardFunc: func(cid circapi.CIDType) (*circapi.Dashboard, error) {
+			return nil, errors.New("API response code 404: Not Found")
+		},
+		FetchGraphFunc: func(cid circapi.CIDType) (*circapi.Graph, error) {
+			if *cid == "/graph/error" {
+				return nil, errors.New("forced mock api error")
+			}
+			return &circapi.Graph{CID: *cid, Title: "edited"}, nil
+		},
+		FetchRuleSetFunc: func(cid circapi.CIDType) (*circapi.RuleSet, error) {
+			return &circapi.RuleSet{CID: *cid, MetricName: "foo"}, nil
+		},
+		FetchWorksheetFunc: func(cid circapi.CIDType) (*circapi.Worksheet, error) {
+			return &circapi.Worksheet{CID: *cid, Title: "foo"}, nil
+		},
+	}
+}
+
+func saveReg(t *testing.T, dir, id string, v interface{}) {
+	if err := regfiles.Save(filepath.Join(dir, "registration-"+id+".json"), v, true); err != nil {
+		t.Fatalf("saving registration (%s)", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Log("Testing Check")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-drift-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := genMockClient()
+
+	t.Log("invalid (nil client)")
+	{
+		_, err := Check(nil, dir)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid client (nil)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("invalid (empty regdir)")
+	{
+		_, err := Check(client, "")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid regdir (empty)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("no registrations")
+	{
+		results, err := Check(client, dir)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(results) != 0 {
+			t.Fatalf("expected 0 results, got %d", len(results))
+		}
+	}
+
+	saveReg(t, dir, "check-system", &circapi.CheckBundle{CID: "/check_bundle/123", DisplayName: "foo", Status: "active"})
+	saveReg(t, dir, "check-group", &circapi.CheckBundle{CID: "/check_bundle/deleted", DisplayName: "bar"})
+	saveReg(t, dir, "graph-cpu", &circapi.Graph{CID: "/graph/123", Title: "cpu"})
+	saveReg(t, dir, "worksheet-system", &circapi.Worksheet{CID: "/worksheet/123", Title: "foo"})
+	saveReg(t, dir, "dashboard-system", &circapi.Dashboard{CID: "/dashboard/123", Title: "foo"})
+	saveReg(t, dir, "ruleset-cpu", &circapi.RuleSet{CID: "/rule_set/123", MetricName: "foo"})
+
+	t.Log("valid")
+	{
+		results, err := Check(client, dir)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+
+		expect := map[string]string{
+			"check-system":     StatusOK,
+			"check-group":      StatusDeleted,
+			"graph-cpu":        StatusDrift,
+			"worksheet-system": StatusOK,
+			"dashboard-system": StatusDeleted,
+			"ruleset-cpu":      StatusOK,
+		}
+		if len(results) != len(expect) {
+			t.Fatalf("expected %d results, got %d", len(expect), len(results))
+		}
+		for _, r := range results {
+			if r.Status != expect[r.ID] {
+				t.Fatalf("expected %s status %s, got %s", r.ID, expect[r.ID], r.Status)
+			}
+			if r.ID == "graph-cpu" {
+				if len(r.Changes) != 1 {
+					t.Fatalf("expected 1 change, got %d", len(r.Changes))
+				}
+				if r.Changes[0].String() != `title: "cpu" -> "edited"` {
+					t.Fatalf("unexpected change (%s)", r.Changes[0].String())
+				}
+			}
+		}
+	}
+
+	saveReg(t, dir, "graph-error", &circapi.Graph{CID: "/graph/error"})
+
+	t.Log("api error")
+	{
+		_, err := Check(client, dir)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "fetching graph-error (/graph/error): forced mock api error" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Log("Testing Run")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-drift-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := genMockClient()
+
+	t.Log("invalid format")
+	{
+		_, err := Run(client, ioutil.Discard, dir, "xml")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid format (xml)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	saveReg(t, dir, "worksheet-system", &circapi.Worksheet{CID: "/worksheet/123", Title: "foo"})
+
+	t.Log("no drift")
+	{
+		var buf bytes.Buffer
+		drifted, err := Run(client, &buf, dir, "text")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if drifted {
+			t.Fatal("expected no drift")
+		}
+		if !strings.Contains(buf.String(), "1 asset(s) verified, 0 drifted, 0 deleted") {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+	}
+
+	saveReg(t, dir, "graph-cpu", &circapi.Graph{CID: "/graph/123", Title: "cpu"})
+
+	t.Log("drift (text)")
+	{
+		var buf bytes.Buffer
+		drifted, err := Run(client, &buf, dir, "text")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !drifted {
+			t.Fatal("expected drift")
+		}
+		if !strings.Contains(buf.String(), `    title: "cpu" -> "edited"`) {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+	}
+
+	t.Log("drift (json)")
+	{
+		var buf bytes.Buffer
+		drifted, err := Run(client, &buf, dir, "json")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !drifted {
+			t.Fatal("expected drift")
+		}
+		var results []Result
+		if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+		if results[0].ID != "graph-cpu" || results[0].Status != StatusDrift {
+			t.Fatalf("unexpected result (%#v)", results[0])
+		}
+	}
+}
@@ -50,7 +50,7 @@ func FetchByID(client CircAPI, id string) (*circapi.Graph, error) {
 	if !strings.HasPrefix(cid, "/graph/") {
 		cid = "/graph/" + id
 	}
-	if ok, err := regexp.MatchString(`^/graph/[0-9]+`, cid); err != nil {
+	if ok, err := regexp.MatchString(`^/graph/([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`, cid); err != nil {
 		return nil, errors.Wrap(err, "compile graph id regexp")
 	} else if !ok {
 		return nil, errors.Errorf("invalid graph id (%s)", id)
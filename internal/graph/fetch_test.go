@@ -28,6 +28,7 @@ func TestFetchByID(t *testing.T) {
 		{"invalid (foo)", client, "foo", "invalid graph id (foo)"},
 		{"invalid (apierror)", client, "000", "fetch api: forced mock api call error"},
 		{"valid", client, "123", ""},
+		{"valid (uuid)", client, "/graph/abcdef01-2345-6789-abcd-ef0123456789", ""},
 	}
 
 	for _, test := range tests {
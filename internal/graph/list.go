@@ -6,10 +6,8 @@
 package graph
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path"
 	"strings"
 
@@ -68,13 +66,12 @@ func getDetail(regDir, regFile, uiURL string) (*detail, error) {
 
 	var g circapi.Graph
 
-	data, err := ioutil.ReadFile(path.Join(regDir, regFile))
+	found, err := regfiles.Load(path.Join(regDir, regFile), &g)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading graph registration file")
 	}
-
-	if err := json.Unmarshal(data, &g); err != nil {
-		return nil, errors.Wrap(err, "parsing graph registration json")
+	if !found {
+		return nil, errors.Errorf("graph registration file (%s) not found", regFile)
 	}
 
 	d := detail{
@@ -0,0 +1,15 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+//go:generate moq -out api_agent_test.go . AgentAPI
+
+import agentapi "github.com/circonus-labs/circonus-agent/api"
+
+// AgentAPI interface abstraction of circonus-agent api (for mocking)
+type AgentAPI interface {
+	Metrics(pluginID string) (*agentapi.Metrics, error)
+}
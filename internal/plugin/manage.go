@@ -0,0 +1,453 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/circonus-labs/cosi-tool/internal/registration"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// KeyManifestDir is the directory containing the plugin manifests
+	KeyManifestDir = "plugin.manifest_dir"
+
+	// StateEnabled all plugin scripts are enabled in the agent
+	StateEnabled = "enabled"
+	// StatePartial some plugin scripts are enabled in the agent
+	StatePartial = "partial"
+	// StateDisabled no plugin scripts are enabled in the agent
+	StateDisabled = "disabled"
+)
+
+// Options defines the local settings used to manage a plugin
+type Options struct {
+	AgentPluginDir string
+	RegDir         string
+	Wait           time.Duration
+	Set            map[string]string // plugin variable settings
+	Instance       string            // dashboard instance (e.g. one per database)
+}
+
+// Status describes the current state of a plugin
+type Status struct {
+	Name    string
+	State   string
+	Scripts []string // plugin IDs of the scripts enabled in the agent
+	Config  bool     // agent plugin config file present
+	Metrics int      // metrics available from the agent, -1 if unknown
+	Assets  []string // registered asset IDs
+}
+
+// Enable probes for the service, writes the agent plugin configuration,
+// enables the plugin scripts, waits for the agent to expose the plugin
+// metrics and then creates the graphs and dashboards for the plugin.
+func Enable(client registration.CircAPI, agent AgentAPI, m *Manifest, o *Options) error {
+	if client == nil {
+		return errors.New("invalid client (nil)")
+	}
+	if agent == nil {
+		return errors.New("invalid agent client (nil)")
+	}
+	if m == nil {
+		return errors.New("invalid manifest (nil)")
+	}
+	if o == nil {
+		return errors.New("invalid options (nil)")
+	}
+
+	if o.Instance != "" {
+		if err := dashboard.ValidateInstance(o.Instance); err != nil {
+			return err
+		}
+	}
+
+	logger := log.With().Str("cmd", "plugin."+m.Name).Logger()
+
+	vars, err := m.ResolveVars(o.Set)
+	if err != nil {
+		return err
+	}
+
+	version, err := m.probe(vars)
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("version", version).Msg("found " + m.Name)
+
+	if err := m.writeConfig(vars); err != nil {
+		return err
+	}
+
+	pluginIDs, err := LinkScripts(o.AgentPluginDir, m.Agent.ScriptDir, m.Agent.Scripts)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := WaitForMetrics(agent, pluginIDs, o.Wait)
+	if err != nil {
+		return err
+	}
+
+	if err := m.writeMeta(o.RegDir, o.Instance, vars); err != nil {
+		return err
+	}
+
+	return Register(client, TemplateList(metrics, pluginIDs, m.Templates...))
+}
+
+// Disable disables the plugin scripts, removes the agent plugin
+// configuration and the assets created for the plugin.
+func Disable(client CircAPI, m *Manifest, o *Options) error {
+	if client == nil {
+		return errors.New("invalid client (nil)")
+	}
+	if m == nil {
+		return errors.New("invalid manifest (nil)")
+	}
+	if o == nil {
+		return errors.New("invalid options (nil)")
+	}
+
+	pluginIDs, err := scriptIDs(m.Agent.ScriptDir, m.Agent.Scripts)
+	if err != nil {
+		return err
+	}
+
+	if err := UnlinkScripts(o.AgentPluginDir, m.Agent.ScriptDir, m.Agent.Scripts); err != nil {
+		return err
+	}
+
+	if err := RemoveConfig(m.Agent.ConfFile, m.Name); err != nil {
+		return err
+	}
+
+	if err := m.removeMeta(o.RegDir); err != nil {
+		return err
+	}
+
+	return RemoveAssets(client, o.RegDir, m.assetPrefixes(pluginIDs))
+}
+
+// ParseSet parses plugin variable settings (name=value)
+func ParseSet(settings []string) (map[string]string, error) {
+	set := make(map[string]string)
+	for _, setting := range settings {
+		kv := strings.SplitN(setting, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid plugin variable setting (%s) - must be name=value", setting)
+		}
+		set[kv[0]] = kv[1]
+	}
+	return set, nil
+}
+
+// GetStatus returns the current status of the plugin, agent may be nil
+// if the agent metrics should not be checked.
+func GetStatus(agent AgentAPI, m *Manifest, o *Options) (*Status, error) {
+	if m == nil {
+		return nil, errors.New("invalid manifest (nil)")
+	}
+	if o == nil {
+		return nil, errors.New("invalid options (nil)")
+	}
+
+	scripts, err := findScripts(m.Agent.ScriptDir, m.Agent.Scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Status{
+		Name:    m.Name,
+		Scripts: []string{},
+		Metrics: -1,
+		Assets:  []string{},
+	}
+
+	pluginIDs := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		name := filepath.Base(script)
+		id := strings.TrimSuffix(name, filepath.Ext(name))
+		pluginIDs = append(pluginIDs, id)
+		if fi, err := os.Lstat(filepath.Join(o.AgentPluginDir, name)); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			s.Scripts = append(s.Scripts, id)
+		}
+	}
+	switch {
+	case len(s.Scripts) == 0:
+		s.State = StateDisabled
+	case len(s.Scripts) == len(pluginIDs):
+		s.State = StateEnabled
+	default:
+		s.State = StatePartial
+	}
+
+	if m.Agent.ConfFile != "" {
+		if _, err := os.Stat(m.Agent.ConfFile); err == nil {
+			s.Config = true
+		}
+	}
+
+	if agent != nil {
+		if metrics, err := agent.Metrics(""); err == nil {
+			groups := make(map[string]bool)
+			for _, id := range pluginIDs {
+				groups[id] = true
+			}
+			s.Metrics = 0
+			for mname := range *metrics {
+				if groups[strings.Split(mname, "`")[0]] {
+					s.Metrics++
+				}
+			}
+		} else {
+			log.Warn().Err(err).Str("plugin", m.Name).Msg("fetching metrics from agent")
+		}
+	}
+
+	for _, prefix := range m.assetPrefixes(pluginIDs) {
+		assetType := strings.SplitN(prefix, "-", 2)[0]
+		assets, err := regfiles.Find(o.RegDir, assetType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading '%s' registrations", assetType)
+		}
+		for _, asset := range *assets {
+			if strings.HasPrefix(asset, "registration-"+prefix) {
+				s.Assets = append(s.Assets, strings.TrimSuffix(strings.TrimPrefix(asset, "registration-"), filepath.Ext(asset)))
+			}
+		}
+	}
+	sort.Strings(s.Assets)
+
+	return s, nil
+}
+
+// List displays the plugins with manifests in the manifest directory
+func List(w io.Writer, dir string, quiet bool) error {
+	manifests, err := LoadManifests(dir)
+	if err != nil {
+		return err
+	}
+
+	format := "%-15s %-45s %s\n"
+	if !quiet {
+		fmt.Fprintf(w, format, "Name", "Description", "Manifest")
+	}
+	for _, m := range manifests {
+		if quiet {
+			fmt.Fprintln(w, m.Name)
+			continue
+		}
+		fmt.Fprintf(w, format, m.Name, m.Description, m.File)
+	}
+
+	return nil
+}
+
+// ShowStatus displays the status of a plugin and the plugin variables
+func ShowStatus(w io.Writer, m *Manifest, s *Status) {
+	metrics := "n/a"
+	if s.Metrics >= 0 {
+		metrics = fmt.Sprintf("%d", s.Metrics)
+	}
+	format := "%-10s %s\n"
+	fmt.Fprintf(w, format, "Plugin", s.Name)
+	fmt.Fprintf(w, format, "State", s.State)
+	fmt.Fprintf(w, format, "Scripts", strings.Join(s.Scripts, ", "))
+	fmt.Fprintf(w, format, "Config", fmt.Sprintf("%t", s.Config))
+	fmt.Fprintf(w, format, "Metrics", metrics)
+	fmt.Fprintf(w, format, "Assets", fmt.Sprintf("%d", len(s.Assets)))
+	for _, a := range s.Assets {
+		fmt.Fprintf(w, "    %s\n", a)
+	}
+
+	if len(m.Vars) == 0 {
+		return
+	}
+	names := make([]string, 0, len(m.Vars))
+	for name := range m.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "Variables")
+	for _, name := range names {
+		v := m.Vars[name]
+		env := ""
+		if v.Env != "" {
+			env = "[ENV: " + v.Env + "] "
+		}
+		fmt.Fprintf(w, "    %-12s %s%s (default: '%s')\n", name, env, v.Description, v.Default)
+	}
+}
+
+// probe runs the manifest probe command to verify the service is reachable,
+// returns the version reported (if the manifest defines expect)
+func (m *Manifest) probe(vars map[string]string) (string, error) {
+	if m.Probe.Command == "" {
+		return "", nil
+	}
+
+	command, err := render(m.Name+" probe", m.Probe.Command, vars)
+	if err != nil {
+		return "", err
+	}
+
+	env := os.Environ()
+	for name, value := range m.Probe.Env {
+		v, err := render(m.Name+" probe env "+name, value, vars)
+		if err != nil {
+			return "", err
+		}
+		env = append(env, name+"="+v)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", command) //nolint:gosec
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "probing %s (%s)", m.Name, strings.TrimSpace(stderr.String()))
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	if m.Probe.Expect == "" {
+		return "", nil
+	}
+	matches := regexp.MustCompile(m.Probe.Expect).FindStringSubmatch(output)
+	if matches == nil {
+		return "", errors.Errorf("unexpected %s probe output (%s)", m.Name, output)
+	}
+	if len(matches) > 1 {
+		return strings.TrimSpace(matches[1]), nil
+	}
+	return strings.TrimSpace(matches[0]), nil
+}
+
+// writeConfig writes the agent plugin configuration sourced by the plugin scripts
+func (m *Manifest) writeConfig(vars map[string]string) error {
+	if len(m.Agent.Config) == 0 {
+		return nil
+	}
+	cfg := make([][2]string, 0, len(m.Agent.Config))
+	for _, cv := range m.Agent.Config {
+		v, err := render(m.Name+" config "+cv.Name, cv.Value, vars)
+		if err != nil {
+			return err
+		}
+		cfg = append(cfg, [2]string{cv.Name, v})
+	}
+	return WriteConfig(m.Agent.ConfFile, m.Name, cfg)
+}
+
+// writeMeta writes the meta data used when creating the plugin dashboards
+// (<reg dir>/<dashboard template id>.json), or the dashboard instance meta
+// if instance is set
+func (m *Manifest) writeMeta(regDir, instance string, vars map[string]string) error {
+	if len(m.Meta) == 0 && instance == "" {
+		return nil
+	}
+	meta := make(map[string]string)
+	for k, v := range m.Meta {
+		mv, err := render(m.Name+" meta "+k, v, vars)
+		if err != nil {
+			return err
+		}
+		meta[k] = mv
+	}
+	for _, id := range m.dashboardIDs() {
+		if instance != "" {
+			if err := dashboard.SaveInstance(regDir, id, instance, meta); err != nil {
+				return errors.Wrapf(err, "saving %s instance meta", id)
+			}
+			continue
+		}
+		if err := regfiles.Save(filepath.Join(regDir, id+".json"), meta, true); err != nil {
+			return errors.Wrapf(err, "saving %s meta", id)
+		}
+	}
+	return nil
+}
+
+// removeMeta removes the dashboard meta data and instance meta data files
+// written by enable
+func (m *Manifest) removeMeta(regDir string) error {
+	for _, id := range m.dashboardIDs() {
+		files := []string{}
+		if len(m.Meta) > 0 {
+			files = append(files, filepath.Join(regDir, id+".json"))
+		}
+		instances, err := dashboard.FindInstances(regDir, id)
+		if err != nil {
+			return err
+		}
+		for _, inst := range instances {
+			files = append(files, dashboard.InstanceMetaFile(regDir, id, inst.Name))
+		}
+		for _, file := range files {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "removing %s meta", id)
+			}
+		}
+	}
+	return nil
+}
+
+// dashboardIDs returns the dashboard template IDs in the manifest
+func (m *Manifest) dashboardIDs() []string {
+	ids := []string{}
+	for _, id := range m.Templates {
+		if strings.HasPrefix(id, "dashboard-") {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// assetPrefixes returns the registration ID prefixes of the assets created
+// from the plugin templates (graph-<plugin id>-, <template id>-) and of the
+// dashboard instances (<template id>@)
+func (m *Manifest) assetPrefixes(pluginIDs []string) []string {
+	prefixes := make([]string, 0, len(pluginIDs)+len(m.Templates))
+	for _, id := range pluginIDs {
+		prefixes = append(prefixes, "graph-"+id+"-")
+	}
+	for _, id := range m.Templates {
+		prefixes = append(prefixes, id+"-")
+	}
+	for _, id := range m.dashboardIDs() {
+		prefixes = append(prefixes, id+dashboard.InstanceSeparator)
+	}
+	return prefixes
+}
+
+// scriptIDs returns the plugin IDs (script names without extension) of the
+// plugin scripts in srcDir matching pattern
+func scriptIDs(srcDir, pattern string) ([]string, error) {
+	scripts, err := findScripts(srcDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		name := filepath.Base(script)
+		ids = append(ids, strings.TrimSuffix(name, filepath.Ext(name)))
+	}
+	return ids, nil
+}
@@ -0,0 +1,255 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func TestProbe(t *testing.T) {
+	t.Log("Testing Manifest.probe")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	vars := map[string]string{"pass": "secret"}
+
+	tests := []struct {
+		name        string
+		probe       Probe
+		version     string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"valid (no probe)", Probe{}, "", false, ""},
+		{"invalid (render)", Probe{Command: "echo {{.host}}"}, "", true, `rendering foo probe: template: foo probe:1:7: executing "foo probe" at <.host>: map has no entry for key "host"`},
+		{"invalid (exit)", Probe{Command: "echo 'connection refused' >&2; exit 2"}, "", true, "probing foo (connection refused): exit status 2"},
+		{"invalid (expect)", Probe{Command: "echo something else", Expect: "^Foo (.*)"}, "", true, "unexpected foo probe output (something else)"},
+		{"valid (no expect)", Probe{Command: "true"}, "", false, ""},
+		{"valid (expect)", Probe{Command: "echo Foo 1.2.3", Expect: "^Foo (.*)"}, "1.2.3", false, ""},
+		{"valid (env)", Probe{Command: `echo "Foo $FOO_PASS"`, Env: map[string]string{"FOO_PASS": "{{.pass}}"}, Expect: "^Foo (.*)"}, "secret", false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			m := &Manifest{Name: "foo", Probe: tst.probe}
+			version, err := m.probe(vars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if version != tst.version {
+				t.Fatalf("unexpected version (%s)", version)
+			}
+		})
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	t.Log("Testing ParseSet")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	t.Log("invalid")
+	{
+		_, err := ParseSet([]string{"foo"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid plugin variable setting (foo) - must be name=value" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid")
+	{
+		set, err := ParseSet([]string{"host=localhost", "pass=a=b,c"})
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if set["host"] != "localhost" || set["pass"] != "a=b,c" {
+			t.Fatalf("unexpected settings (%v)", set)
+		}
+	}
+}
+
+// testManifest returns a manifest and options using a temp dir
+func testManifest(t *testing.T, dir string) (*Manifest, *Options) {
+	srcDir := filepath.Join(dir, "src")
+	pluginDir := filepath.Join(dir, "plugins")
+	regDir := filepath.Join(dir, "registration")
+	for _, d := range []string{srcDir, pluginDir, regDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("creating dir (%s)", err)
+		}
+	}
+	for _, f := range []string{"foo_a.sh", "foo_b.sh"} {
+		if err := ioutil.WriteFile(filepath.Join(srcDir, f), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("writing script (%s)", err)
+		}
+	}
+
+	m := &Manifest{
+		Name: "foo",
+		Vars: map[string]Var{"host": {Default: "localhost", Description: "foo host"}},
+		Agent: Agent{
+			ScriptDir: srcDir,
+			Scripts:   "foo_*.sh",
+			ConfFile:  filepath.Join(dir, "foo-conf.sh"),
+			Config:    []ConfigVar{{Name: "FOO_HOST", Value: "{{.host}}"}},
+		},
+		Templates: []string{"dashboard-foo"},
+		Meta:      map[string]string{"Host": "{{.host}}"},
+	}
+	o := &Options{AgentPluginDir: pluginDir, RegDir: regDir}
+
+	return m, o
+}
+
+func TestDisable(t *testing.T) {
+	t.Log("Testing Disable")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-plugin-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, o := testManifest(t, dir)
+
+	vars, err := m.ResolveVars(nil)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := m.writeConfig(vars); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := m.writeMeta(o.RegDir, "", vars); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := m.writeMeta(o.RegDir, "db2", vars); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if _, err := LinkScripts(o.AgentPluginDir, m.Agent.ScriptDir, m.Agent.Scripts); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	t.Log("meta")
+	{
+		var meta map[string]string
+		found, err := regfiles.Load(filepath.Join(o.RegDir, "dashboard-foo.json"), &meta)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !found || meta["Host"] != "localhost" {
+			t.Fatalf("unexpected meta (%v)", meta)
+		}
+	}
+
+	regs := map[string]string{
+		"graph-foo_a-requests": "/graph/1",
+		"graph-foo_ab-errors":  "/graph/2",
+		"dashboard-foo-main":   "/dashboard/1",
+		"dashboard-foo@db2":    "/dashboard/2",
+	}
+	for id, cid := range regs {
+		if err := regfiles.Save(filepath.Join(o.RegDir, "registration-"+id+".json"), &circapi.Graph{CID: cid}, true); err != nil {
+			t.Fatalf("saving registration (%s)", err)
+		}
+	}
+
+	t.Log("status (enabled)")
+	{
+		agent := &AgentAPIMock{
+			MetricsFunc: func(pluginID string) (*agentapi.Metrics, error) {
+				return &agentapi.Metrics{"foo_a`requests": {}, "foo_b`errors": {}, "cpu`idle": {}}, nil
+			},
+		}
+		s, err := GetStatus(agent, m, o)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if s.State != StateEnabled || !s.Config || s.Metrics != 2 || len(s.Assets) != 3 {
+			t.Fatalf("unexpected status (%#v)", s)
+		}
+		var buf bytes.Buffer
+		ShowStatus(&buf, m, s)
+		if !strings.Contains(buf.String(), "host         foo host (default: 'localhost')") {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+	}
+
+	t.Log("disable")
+	{
+		client := &CircAPIMock{
+			DeleteDashboardByCIDFunc: func(cid circapi.CIDType) (bool, error) {
+				return true, nil
+			},
+			DeleteGraphByCIDFunc: func(cid circapi.CIDType) (bool, error) {
+				return true, nil
+			},
+		}
+		if err := Disable(client, m, o); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.DeleteGraphByCIDCalls()) != 1 || len(client.DeleteDashboardByCIDCalls()) != 2 {
+			t.Fatalf("unexpected deletes (%d graphs, %d dashboards)", len(client.DeleteGraphByCIDCalls()), len(client.DeleteDashboardByCIDCalls()))
+		}
+		dashCIDs := []string{}
+		for _, call := range client.DeleteDashboardByCIDCalls() {
+			dashCIDs = append(dashCIDs, *call.Cid)
+		}
+		sort.Strings(dashCIDs)
+		if !reflect.DeepEqual(dashCIDs, []string{"/dashboard/1", "/dashboard/2"}) {
+			t.Fatalf("unexpected dashboard deletes (%v)", dashCIDs)
+		}
+		files, err := ioutil.ReadDir(o.RegDir)
+		if err != nil {
+			t.Fatalf("reading reg dir (%s)", err)
+		}
+		if len(files) != 1 || files[0].Name() != "registration-graph-foo_ab-errors.json" {
+			t.Fatalf("expected only graph-foo_ab registration to remain (%v)", files)
+		}
+		if _, err := os.Stat(m.Agent.ConfFile); !os.IsNotExist(err) {
+			t.Fatalf("expected config to be removed (%v)", err)
+		}
+	}
+
+	t.Log("status (disabled)")
+	{
+		agent := &AgentAPIMock{
+			MetricsFunc: func(pluginID string) (*agentapi.Metrics, error) {
+				return nil, errors.New("forced mock api error")
+			},
+		}
+		s, err := GetStatus(agent, m, o)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if s.State != StateDisabled || s.Config || s.Metrics != -1 || len(s.Assets) != 0 {
+			t.Fatalf("unexpected status (%#v)", s)
+		}
+	}
+}
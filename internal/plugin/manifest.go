@@ -0,0 +1,228 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/pkg/errors"
+)
+
+// Manifest defines a plugin, how to detect the service it monitors, the
+// agent plugin configuration to write and the templates to register.
+// Manifests are loaded from the manifest directory (json|toml|yaml), the
+// plugin name defaults to the manifest file name (e.g. postgres.toml).
+//
+// String values in the probe, agent config and meta may reference plugin
+// variables using template syntax (e.g. `{{.host}}`, `{{quote .pass}}`).
+type Manifest struct {
+	Name        string            `json:"name" toml:"name" yaml:"name"`
+	Description string            `json:"description" toml:"description" yaml:"description"`
+	Vars        map[string]Var    `json:"vars" toml:"vars" yaml:"vars"`
+	Probe       Probe             `json:"probe" toml:"probe" yaml:"probe"`
+	Agent       Agent             `json:"agent" toml:"agent" yaml:"agent"`
+	Templates   []string          `json:"templates" toml:"templates" yaml:"templates"` // template IDs to register (e.g. dashboard-postgres)
+	Meta        map[string]string `json:"meta" toml:"meta" yaml:"meta"`                // dashboard template meta variables
+	File        string            `json:"-" toml:"-" yaml:"-"`                         // manifest file the plugin was loaded from
+}
+
+// Var defines a plugin variable, the value is set (in order of precedence)
+// with `--set name=value`, the environment variable or the default
+type Var struct {
+	Default     string `json:"default" toml:"default" yaml:"default"`
+	Env         string `json:"env" toml:"env" yaml:"env"`
+	Description string `json:"description" toml:"description" yaml:"description"`
+}
+
+// Probe defines the command used to detect the service, it is run with
+// `sh -c` and must exit zero. If expect is set, the output must match the
+// regular expression, the first capture group is reported as the version.
+type Probe struct {
+	Command string            `json:"command" toml:"command" yaml:"command"`
+	Env     map[string]string `json:"env" toml:"env" yaml:"env"`
+	Expect  string            `json:"expect" toml:"expect" yaml:"expect"`
+}
+
+// Agent defines the agent plugin scripts to enable and the configuration
+// file, sourced by the scripts, to write
+type Agent struct {
+	ScriptDir string      `json:"script_dir" toml:"script_dir" yaml:"script_dir"` // directory containing the plugin scripts
+	Scripts   string      `json:"scripts" toml:"scripts" yaml:"scripts"`          // pattern matching the scripts (e.g. pg_*.sh)
+	ConfFile  string      `json:"conf_file" toml:"conf_file" yaml:"conf_file"`
+	Config    []ConfigVar `json:"config" toml:"config" yaml:"config"`
+}
+
+// ConfigVar defines a variable written to the agent plugin configuration file
+type ConfigVar struct {
+	Name  string `json:"name" toml:"name" yaml:"name"`
+	Value string `json:"value" toml:"value" yaml:"value"`
+}
+
+var (
+	manifestExtensions = []string{".json", ".toml", ".yaml"}
+	configVarValidator = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// LoadManifests loads all of the plugin manifests in the directory
+func LoadManifests(dir string) ([]*Manifest, error) {
+	if dir == "" {
+		return nil, errors.New("invalid manifest dir (empty)")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Manifest{}, nil
+		}
+		return nil, errors.Wrap(err, "reading manifest directory")
+	}
+
+	manifests := []*Manifest{}
+	names := make(map[string]string)
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !isManifest(file.Name()) {
+			continue
+		}
+		m, err := loadManifest(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := names[m.Name]; ok {
+			return nil, errors.Errorf("duplicate plugin (%s) in %s and %s", m.Name, prev, m.File)
+		}
+		names[m.Name] = m.File
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+
+	return manifests, nil
+}
+
+// LoadManifest returns the manifest for the named plugin
+func LoadManifest(dir, name string) (*Manifest, error) {
+	if name == "" {
+		return nil, errors.New("invalid plugin name (empty)")
+	}
+	manifests, err := LoadManifests(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifests {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, errors.Errorf("unknown plugin (%s), no manifest found in %s", name, dir)
+}
+
+func isManifest(fileName string) bool {
+	for _, ext := range manifestExtensions {
+		if filepath.Ext(fileName) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// loadManifest loads and validates a manifest file
+func loadManifest(file string) (*Manifest, error) {
+	var m Manifest
+	if err := config.LoadConfigFile(file, &m); err != nil {
+		return nil, errors.Wrap(err, "loading plugin manifest")
+	}
+	m.File = file
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if err := m.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid plugin manifest (%s)", file)
+	}
+	return &m, nil
+}
+
+// validate verifies the required manifest settings
+func (m *Manifest) validate() error {
+	if m.Agent.ScriptDir == "" {
+		return errors.New("agent script_dir required")
+	}
+	if m.Agent.Scripts == "" {
+		return errors.New("agent scripts required")
+	}
+	if m.Probe.Expect != "" {
+		if _, err := regexp.Compile(m.Probe.Expect); err != nil {
+			return errors.Wrap(err, "probe expect")
+		}
+	}
+	if len(m.Agent.Config) > 0 && m.Agent.ConfFile == "" {
+		return errors.New("agent conf_file required with agent config")
+	}
+	for _, cv := range m.Agent.Config {
+		if !configVarValidator.MatchString(cv.Name) {
+			return errors.Errorf("invalid agent config variable name (%s)", cv.Name)
+		}
+	}
+	for _, id := range m.Templates {
+		if !strings.HasPrefix(id, "graph-") && !strings.HasPrefix(id, "dashboard-") {
+			return errors.Errorf("invalid template id (%s) - must be graph-* or dashboard-*", id)
+		}
+	}
+	for k := range m.Meta {
+		if k == "" || k[0] < 'A' || k[0] > 'Z' {
+			return errors.Errorf("invalid meta key (%s) - must start with an uppercase character", k)
+		}
+	}
+	return nil
+}
+
+// ResolveVars returns the plugin variable values, set overrides the
+// environment variable and default for a variable
+func (m *Manifest) ResolveVars(set map[string]string) (map[string]string, error) {
+	for name := range set {
+		if _, ok := m.Vars[name]; !ok {
+			return nil, errors.Errorf("unknown %s plugin variable (%s)", m.Name, name)
+		}
+	}
+
+	vars := make(map[string]string)
+	for name, v := range m.Vars {
+		val := v.Default
+		if v.Env != "" {
+			if ev, ok := os.LookupEnv(v.Env); ok {
+				val = ev
+			}
+		}
+		if sv, ok := set[name]; ok {
+			val = sv
+		}
+		vars[name] = val
+	}
+	return vars, nil
+}
+
+// render expands plugin variables in a manifest value
+func render(name, value string, vars map[string]string) (string, error) {
+	t, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"quote": shellQuote}).
+		Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing %s", name)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", errors.Wrapf(err, "rendering %s", name)
+	}
+	return buf.String(), nil
+}
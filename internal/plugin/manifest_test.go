@@ -0,0 +1,241 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLoadManifests(t *testing.T) {
+	t.Log("Testing LoadManifests")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	t.Log("invalid (empty dir)")
+	{
+		_, err := LoadManifests("")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid manifest dir (empty)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid (missing dir)")
+	{
+		manifests, err := LoadManifests(filepath.Join("testdata", "missing"))
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(manifests) != 0 {
+			t.Fatalf("expected no manifests (%v)", manifests)
+		}
+	}
+
+	t.Log("valid (shipped manifests)")
+	{
+		manifests, err := LoadManifests(filepath.Join("..", "..", "etc", "plugins"))
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(manifests) != 2 {
+			t.Fatalf("expected 2 manifests, got %d", len(manifests))
+		}
+		if manifests[0].Name != "cassandra" || manifests[1].Name != "postgres" {
+			t.Fatalf("unexpected manifests (%s, %s)", manifests[0].Name, manifests[1].Name)
+		}
+		pg := manifests[1]
+		if pg.Vars["host"].Env != "PGHOST" {
+			t.Fatalf("unexpected host var (%#v)", pg.Vars["host"])
+		}
+		if len(pg.Agent.Config) != 6 || pg.Agent.Config[0].Name != "PSQL_CMD" {
+			t.Fatalf("unexpected agent config (%#v)", pg.Agent.Config)
+		}
+		if len(pg.Templates) != 1 || pg.Templates[0] != "dashboard-postgres" {
+			t.Fatalf("unexpected templates (%v)", pg.Templates)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "cosi-plugin-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Log("invalid (duplicate)")
+	{
+		for _, f := range []string{"foo.json", "bar.json"} {
+			data := []byte(`{"name":"foo","agent":{"script_dir":"/tmp","scripts":"foo_*.sh"}}`)
+			if err := ioutil.WriteFile(filepath.Join(dir, f), data, 0644); err != nil {
+				t.Fatalf("writing manifest (%s)", err)
+			}
+		}
+		_, err := LoadManifests(dir)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expectedErr := "duplicate plugin (foo) in " + filepath.Join(dir, "bar.json") + " and " + filepath.Join(dir, "foo.json")
+		if err.Error() != expectedErr {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid (LoadManifest)")
+	{
+		if err := os.Remove(filepath.Join(dir, "bar.json")); err != nil {
+			t.Fatalf("removing manifest (%s)", err)
+		}
+		m, err := LoadManifest(dir, "foo")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if m.File != filepath.Join(dir, "foo.json") {
+			t.Fatalf("unexpected file (%s)", m.File)
+		}
+	}
+
+	t.Log("invalid (LoadManifest unknown)")
+	{
+		_, err := LoadManifest(dir, "bar")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "unknown plugin (bar), no manifest found in "+dir {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	t.Log("Testing Manifest.validate")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	agent := Agent{ScriptDir: "/tmp", Scripts: "foo_*.sh"}
+
+	tests := []struct {
+		name        string
+		m           Manifest
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (script dir)", Manifest{}, true, "agent script_dir required"},
+		{"invalid (scripts)", Manifest{Agent: Agent{ScriptDir: "/tmp"}}, true, "agent scripts required"},
+		{"invalid (expect)", Manifest{Agent: agent, Probe: Probe{Expect: "("}}, true, "probe expect: error parsing regexp: missing closing ): `(`"},
+		{"invalid (conf file)", Manifest{Agent: Agent{ScriptDir: "/tmp", Scripts: "foo_*.sh", Config: []ConfigVar{{Name: "FOO"}}}}, true, "agent conf_file required with agent config"},
+		{"invalid (config name)", Manifest{Agent: Agent{ScriptDir: "/tmp", Scripts: "foo_*.sh", ConfFile: "/tmp/foo.sh", Config: []ConfigVar{{Name: "FOO BAR"}}}}, true, "invalid agent config variable name (FOO BAR)"},
+		{"invalid (template)", Manifest{Agent: agent, Templates: []string{"check-foo"}}, true, "invalid template id (check-foo) - must be graph-* or dashboard-*"},
+		{"invalid (meta)", Manifest{Agent: agent, Meta: map[string]string{"foo": "bar"}}, true, "invalid meta key (foo) - must start with an uppercase character"},
+		{"valid", Manifest{Agent: agent, Templates: []string{"dashboard-foo"}, Meta: map[string]string{"Foo": "bar"}}, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			err := tst.m.validate()
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+		})
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	t.Log("Testing Manifest.ResolveVars")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	m := &Manifest{
+		Name: "foo",
+		Vars: map[string]Var{
+			"host": {Default: "localhost", Env: "COSI_TEST_FOO_HOST"},
+			"port": {Default: "1234", Env: "COSI_TEST_FOO_PORT"},
+			"user": {Default: "foo"},
+		},
+	}
+
+	os.Setenv("COSI_TEST_FOO_HOST", "example.com")
+	os.Setenv("COSI_TEST_FOO_PORT", "5678")
+	defer os.Unsetenv("COSI_TEST_FOO_HOST")
+	defer os.Unsetenv("COSI_TEST_FOO_PORT")
+
+	t.Log("invalid (unknown var)")
+	{
+		_, err := m.ResolveVars(map[string]string{"bar": "baz"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "unknown foo plugin variable (bar)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid")
+	{
+		vars, err := m.ResolveVars(map[string]string{"port": "9999"})
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		expected := map[string]string{"host": "example.com", "port": "9999", "user": "foo"}
+		for k, v := range expected {
+			if vars[k] != v {
+				t.Fatalf("unexpected %s (%s) expected (%s)", k, vars[k], v)
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Log("Testing render")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	vars := map[string]string{"host": "localhost", "pass": "it's"}
+
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (parse)", "{{.host", "", true, "parsing test: template: test:1: unclosed action"},
+		{"invalid (missing key)", "{{.port}}", "", true, `rendering test: template: test:1:2: executing "test" at <.port>: map has no entry for key "port"`},
+		{"valid", "-h {{.host}}", "-h localhost", false, ""},
+		{"valid (quote)", "-p {{quote .pass}}", `-p 'it'\''s'`, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			v, err := render("test", tst.value, vars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if v != tst.expected {
+				t.Fatalf("unexpected value (%s)", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package plugin provides common support for enabling and disabling
+// circonus-agent plugins which require local configuration
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/registration"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// KeyAgentPluginDir is the circonus-agent plugin directory
+	KeyAgentPluginDir = "plugin.agent_plugin_dir"
+	// DefaultAgentPluginDir is the default circonus-agent plugin directory
+	DefaultAgentPluginDir = "/opt/circonus/agent/plugins"
+
+	// KeyWait is the maximum time to wait for the agent to expose the
+	// metrics of an enabled plugin
+	KeyWait = "plugin.wait"
+	// DefaultWait is the default maximum time to wait for plugin metrics
+	DefaultWait = 60 * time.Second
+)
+
+// waitInterval is the time between polls of the agent for plugin metrics
+var waitInterval = 5 * time.Second
+
+// LinkScripts links the plugin scripts in srcDir matching pattern (e.g.
+// pg_*.sh) into the agent plugin directory. Returns the list of plugin IDs
+// (script names without extension) the agent will use for the metrics.
+func LinkScripts(pluginDir, srcDir, pattern string) ([]string, error) {
+	if pluginDir == "" {
+		return nil, errors.New("invalid agent plugin dir (empty)")
+	}
+	scripts, err := findScripts(srcDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.With().Str("cmd", "plugin").Logger()
+
+	pluginIDs := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		name := filepath.Base(script)
+		link := filepath.Join(pluginDir, name)
+		pluginIDs = append(pluginIDs, strings.TrimSuffix(name, filepath.Ext(name)))
+		if _, err := os.Lstat(link); err == nil {
+			logger.Debug().Str("link", link).Msg("already enabled")
+			continue
+		}
+		logger.Info().Str("script", script).Str("link", link).Msg("enabling agent plugin")
+		if err := os.Symlink(script, link); err != nil {
+			return nil, errors.Wrap(err, "enabling agent plugin")
+		}
+	}
+
+	return pluginIDs, nil
+}
+
+// UnlinkScripts removes the links to plugin scripts in srcDir matching pattern
+// from the agent plugin directory.
+func UnlinkScripts(pluginDir, srcDir, pattern string) error {
+	if pluginDir == "" {
+		return errors.New("invalid agent plugin dir (empty)")
+	}
+	scripts, err := findScripts(srcDir, pattern)
+	if err != nil {
+		return err
+	}
+
+	logger := log.With().Str("cmd", "plugin").Logger()
+
+	for _, script := range scripts {
+		link := filepath.Join(pluginDir, filepath.Base(script))
+		fi, err := os.Lstat(link)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrap(err, "disabling agent plugin")
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			logger.Warn().Str("file", link).Msg("not a link, leaving in place")
+			continue
+		}
+		logger.Info().Str("link", link).Msg("disabling agent plugin")
+		if err := os.Remove(link); err != nil {
+			return errors.Wrap(err, "disabling agent plugin")
+		}
+	}
+
+	return nil
+}
+
+// findScripts returns the plugin scripts in srcDir matching pattern
+func findScripts(srcDir, pattern string) ([]string, error) {
+	if srcDir == "" {
+		return nil, errors.New("invalid plugin script dir (empty)")
+	}
+	if pattern == "" {
+		return nil, errors.New("invalid plugin script pattern (empty)")
+	}
+	scripts, err := filepath.Glob(filepath.Join(srcDir, pattern))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding plugin scripts")
+	}
+	if len(scripts) == 0 {
+		return nil, errors.Errorf("no plugin scripts (%s) found in %s", pattern, srcDir)
+	}
+	sort.Strings(scripts)
+	return scripts, nil
+}
+
+// WriteConfig writes a shell configuration file, sourced by the plugin
+// scripts, exporting the variables. It may contain credentials so it is
+// only readable by the owner.
+func WriteConfig(confFile, pluginName string, vars [][2]string) error {
+	if confFile == "" {
+		return errors.Errorf("invalid %s plugin config file (empty)", pluginName)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "#!/bin/sh")
+	fmt.Fprintf(&buf, "# generated by cosi plugin %s\n", pluginName)
+	names := make([]string, 0, len(vars))
+	for _, v := range vars {
+		fmt.Fprintf(&buf, "%s=%s\n", v[0], shellQuote(v[1]))
+		names = append(names, v[0])
+	}
+	fmt.Fprintf(&buf, "export %s\n", strings.Join(names, " "))
+
+	if err := os.MkdirAll(filepath.Dir(confFile), 0755); err != nil {
+		return errors.Wrapf(err, "creating %s plugin config directory", pluginName)
+	}
+	if err := ioutil.WriteFile(confFile, buf.Bytes(), 0600); err != nil {
+		return errors.Wrapf(err, "writing %s plugin config", pluginName)
+	}
+
+	return nil
+}
+
+// RemoveConfig removes a plugin configuration file, if it exists
+func RemoveConfig(confFile, pluginName string) error {
+	if confFile == "" {
+		return nil
+	}
+	if err := os.Remove(confFile); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "removing %s plugin config", pluginName)
+	}
+	return nil
+}
+
+// shellQuote single quotes a value for use in a shell script
+func shellQuote(v string) string {
+	return "'" + strings.Replace(v, "'", `'\''`, -1) + "'"
+}
+
+// WaitForMetrics polls the agent until metrics are available for at least
+// one of the plugin IDs or the timeout is reached. Returns the metrics
+// available from the agent.
+func WaitForMetrics(client AgentAPI, pluginIDs []string, timeout time.Duration) (*agentapi.Metrics, error) {
+	if client == nil {
+		return nil, errors.New("invalid agent client (nil)")
+	}
+	if len(pluginIDs) == 0 {
+		return nil, errors.New("invalid plugin id list (empty)")
+	}
+
+	logger := log.With().Str("cmd", "plugin").Logger()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		metrics, err := client.Metrics("")
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching metrics from agent")
+		}
+		if len(metricGroups(metrics, pluginIDs)) > 0 {
+			return metrics, nil
+		}
+		if time.Now().Add(waitInterval).After(deadline) {
+			return nil, errors.Errorf("timed out waiting for agent to expose plugin metrics (%s) - verify the agent has loaded the plugins", strings.Join(pluginIDs, ","))
+		}
+		logger.Info().Strs("plugins", pluginIDs).Msg("waiting for agent to expose plugin metrics")
+		time.Sleep(waitInterval)
+	}
+}
+
+// TemplateList returns the graph template IDs (graph-<plugin id>) for the
+// plugins with metrics available from the agent, along with any additional
+// template IDs (e.g. the plugin dashboard).
+func TemplateList(metrics *agentapi.Metrics, pluginIDs []string, additional ...string) []string {
+	list := []string{}
+	for _, group := range metricGroups(metrics, pluginIDs) {
+		list = append(list, "graph-"+group)
+	}
+	return append(list, additional...)
+}
+
+// metricGroups returns the plugin IDs with metrics available from the agent
+func metricGroups(metrics *agentapi.Metrics, pluginIDs []string) []string {
+	if metrics == nil {
+		return []string{}
+	}
+	groups := make(map[string]bool)
+	for mname := range *metrics {
+		groups[strings.Split(mname, "`")[0]] = true
+	}
+	found := []string{}
+	for _, id := range pluginIDs {
+		if groups[id] {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// Register creates the assets (graphs, dashboards) for the plugin templates
+func Register(client registration.CircAPI, templateIDs []string) error {
+	r, err := registration.NewPlugin(client, templateIDs)
+	if err != nil {
+		return err
+	}
+	return r.RegisterPlugin()
+}
+
+// RemoveAssets deletes the graphs and dashboards with registration IDs
+// starting with one of the prefixes (e.g. graph-pg_, dashboard-postgres)
+// and removes the registration files. Assets already deleted are ignored.
+func RemoveAssets(client CircAPI, regDir string, prefixes []string) error {
+	if client == nil {
+		return errors.New("invalid client (nil)")
+	}
+	if regDir == "" {
+		return errors.New("invalid regdir (empty)")
+	}
+
+	logger := log.With().Str("cmd", "plugin").Logger()
+
+	for _, prefix := range prefixes {
+		var assetType string
+		var del func(cid string) (bool, error)
+		switch {
+		case strings.HasPrefix(prefix, "graph-"):
+			assetType = "graph"
+			del = func(cid string) (bool, error) { return client.DeleteGraphByCID(circapi.CIDType(&cid)) }
+		case strings.HasPrefix(prefix, "dashboard-"):
+			assetType = "dashboard"
+			del = func(cid string) (bool, error) { return client.DeleteDashboardByCID(circapi.CIDType(&cid)) }
+		default:
+			return errors.Errorf("invalid asset prefix (%s)", prefix)
+		}
+
+		assets, err := regfiles.Find(regDir, assetType)
+		if err != nil {
+			return errors.Wrapf(err, "loading '%s' registrations", assetType)
+		}
+		for _, asset := range *assets {
+			if !strings.HasPrefix(asset, "registration-"+prefix) {
+				continue
+			}
+			regFile := filepath.Join(regDir, asset)
+			var v struct {
+				CID string `json:"_cid"`
+			}
+			if _, err := regfiles.Load(regFile, &v); err != nil {
+				return err
+			}
+			if v.CID != "" {
+				logger.Info().Str("type", assetType).Str("cid", v.CID).Msg("removing")
+				if _, err := del(v.CID); err != nil && !apierr.IsNotFound(err) {
+					return errors.Wrapf(err, "deleting %s (%s)", assetType, v.CID)
+				}
+			}
+			if err := os.Remove(regFile); err != nil {
+				return errors.Wrap(err, "removing registration")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func TestLinkScripts(t *testing.T) {
+	t.Log("Testing LinkScripts/UnlinkScripts")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-plugin-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcDir := filepath.Join(dir, "src")
+	pluginDir := filepath.Join(dir, "plugins")
+	for _, d := range []string{srcDir, pluginDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("creating dir (%s)", err)
+		}
+	}
+	for _, f := range []string{"foo_a.sh", "foo_b.sh", "bar.sh"} {
+		if err := ioutil.WriteFile(filepath.Join(srcDir, f), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("writing script (%s)", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		pluginDir   string
+		srcDir      string
+		pattern     string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (plugin dir)", "", srcDir, "foo_*.sh", true, "invalid agent plugin dir (empty)"},
+		{"invalid (src dir)", pluginDir, "", "foo_*.sh", true, "invalid plugin script dir (empty)"},
+		{"invalid (pattern)", pluginDir, srcDir, "", true, "invalid plugin script pattern (empty)"},
+		{"no scripts", pluginDir, srcDir, "qux_*.sh", true, "no plugin scripts (qux_*.sh) found in " + srcDir},
+		{"valid", pluginDir, srcDir, "foo_*.sh", false, ""},
+		{"valid (already linked)", pluginDir, srcDir, "foo_*.sh", false, ""},
+	}
+
+	for _, tst := range tests {
+		t.Log(tst.name)
+		ids, err := LinkScripts(tst.pluginDir, tst.srcDir, tst.pattern)
+		if tst.shouldFail {
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != tst.expectedErr {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(ids) != 2 || ids[0] != "foo_a" || ids[1] != "foo_b" {
+			t.Fatalf("unexpected plugin ids (%v)", ids)
+		}
+		if _, err := os.Lstat(filepath.Join(pluginDir, "foo_a.sh")); err != nil {
+			t.Fatalf("expected link (%s)", err)
+		}
+	}
+
+	t.Log("unlink")
+	{
+		if err := UnlinkScripts(pluginDir, srcDir, "foo_*.sh"); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		files, err := ioutil.ReadDir(pluginDir)
+		if err != nil {
+			t.Fatalf("reading plugin dir (%s)", err)
+		}
+		if len(files) != 0 {
+			t.Fatalf("expected 0 files, found %d", len(files))
+		}
+	}
+}
+
+func TestWaitForMetrics(t *testing.T) {
+	t.Log("Testing WaitForMetrics")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	waitInterval = 10 * time.Millisecond
+
+	polls := 0
+	client := &AgentAPIMock{
+		MetricsFunc: func(pluginID string) (*agentapi.Metrics, error) {
+			polls++
+			if polls < 3 {
+				return &agentapi.Metrics{"cpu`idle": {}}, nil
+			}
+			return &agentapi.Metrics{"cpu`idle": {}, "foo_a`bar": {}}, nil
+		},
+	}
+
+	t.Log("invalid (nil client)")
+	{
+		_, err := WaitForMetrics(nil, []string{"foo_a"}, time.Second)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid agent client (nil)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid")
+	{
+		m, err := WaitForMetrics(client, []string{"foo_a", "foo_b"}, time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if polls != 3 {
+			t.Fatalf("expected 3 polls, got %d", polls)
+		}
+		list := TemplateList(m, []string{"foo_a", "foo_b"}, "dashboard-foo")
+		if len(list) != 2 || list[0] != "graph-foo_a" || list[1] != "dashboard-foo" {
+			t.Fatalf("unexpected template list (%v)", list)
+		}
+	}
+
+	t.Log("timeout")
+	{
+		_, err := WaitForMetrics(client, []string{"qux"}, 50*time.Millisecond)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "timed out waiting for agent to expose plugin metrics (qux) - verify the agent has loaded the plugins" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("api error")
+	{
+		client.MetricsFunc = func(pluginID string) (*agentapi.Metrics, error) {
+			return nil, errors.New("forced mock api error")
+		}
+		_, err := WaitForMetrics(client, []string{"foo_a"}, time.Second)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "fetching metrics from agent: forced mock api error" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
+
+func TestRemoveAssets(t *testing.T) {
+	t.Log("Testing RemoveAssets")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-plugin-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := &CircAPIMock{
+		DeleteDashboardByCIDFunc: func(cid circapi.CIDType) (bool, error) {
+			return true, nil
+		},
+		DeleteGraphByCIDFunc: func(cid circapi.CIDType) (bool, error) {
+			if *cid == "/graph/deleted" {
+				return false, errors.New("API response code 404: not found")
+			}
+			return true, nil
+		},
+	}
+
+	regs := map[string]string{
+		"graph-foo_a":        "/graph/1",
+		"graph-foo_b-0":      "/graph/deleted",
+		"graph-cpu":          "/graph/3",
+		"dashboard-foo-main": "/dashboard/1",
+	}
+	for id, cid := range regs {
+		if err := regfiles.Save(filepath.Join(dir, "registration-"+id+".json"), &circapi.Graph{CID: cid}, true); err != nil {
+			t.Fatalf("saving registration (%s)", err)
+		}
+	}
+
+	t.Log("invalid (prefix)")
+	{
+		err := RemoveAssets(client, dir, []string{"worksheet-foo"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid asset prefix (worksheet-foo)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid")
+	{
+		if err := RemoveAssets(client, dir, []string{"graph-foo_", "dashboard-foo"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.DeleteGraphByCIDCalls()) != 2 {
+			t.Fatalf("expected 2 graph deletes, got %d", len(client.DeleteGraphByCIDCalls()))
+		}
+		if len(client.DeleteDashboardByCIDCalls()) != 1 {
+			t.Fatalf("expected 1 dashboard delete, got %d", len(client.DeleteDashboardByCIDCalls()))
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("reading reg dir (%s)", err)
+		}
+		if len(files) != 1 || files[0].Name() != "registration-graph-cpu.json" {
+			t.Fatalf("expected only graph-cpu registration to remain (%v)", files)
+		}
+	}
+}
@@ -19,6 +19,7 @@ type CircAPI interface {
 	DeleteCheckBundleByCID(cid circapi.CIDType) (bool, error)
 	DeleteDashboardByCID(cid circapi.CIDType) (bool, error)
 	DeleteGraphByCID(cid circapi.CIDType) (bool, error)
+	DeleteRuleSetByCID(cid circapi.CIDType) (bool, error)
 	DeleteWorksheetByCID(cid circapi.CIDType) (bool, error)
 	FetchCheckBundle(cid circapi.CIDType) (*circapi.CheckBundle, error)
 	FetchBroker(cid circapi.CIDType) (*circapi.Broker, error)
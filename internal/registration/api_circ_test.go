@@ -18,6 +18,7 @@ var (
 	lockCircAPIMockDeleteCheckBundleByCID sync.RWMutex
 	lockCircAPIMockDeleteDashboardByCID   sync.RWMutex
 	lockCircAPIMockDeleteGraphByCID       sync.RWMutex
+	lockCircAPIMockDeleteRuleSetByCID     sync.RWMutex
 	lockCircAPIMockDeleteWorksheetByCID   sync.RWMutex
 	lockCircAPIMockFetchBroker            sync.RWMutex
 	lockCircAPIMockFetchBrokers           sync.RWMutex
@@ -65,6 +66,9 @@ var (
 //             DeleteGraphByCIDFunc: func(cid circapi.CIDType) (bool, error) {
 // 	               panic("TODO: mock out the DeleteGraphByCID method")
 //             },
+//             DeleteRuleSetByCIDFunc: func(cid circapi.CIDType) (bool, error) {
+// 	               panic("TODO: mock out the DeleteRuleSetByCID method")
+//             },
 //             DeleteWorksheetByCIDFunc: func(cid circapi.CIDType) (bool, error) {
 // 	               panic("TODO: mock out the DeleteWorksheetByCID method")
 //             },
@@ -141,6 +145,9 @@ type CircAPIMock struct {
 	// DeleteGraphByCIDFunc mocks the DeleteGraphByCID method.
 	DeleteGraphByCIDFunc func(cid circapi.CIDType) (bool, error)
 
+	// DeleteRuleSetByCIDFunc mocks the DeleteRuleSetByCID method.
+	DeleteRuleSetByCIDFunc func(cid circapi.CIDType) (bool, error)
+
 	// DeleteWorksheetByCIDFunc mocks the DeleteWorksheetByCID method.
 	DeleteWorksheetByCIDFunc func(cid circapi.CIDType) (bool, error)
 
@@ -228,6 +235,11 @@ type CircAPIMock struct {
 			// Cid is the cid argument value.
 			Cid circapi.CIDType
 		}
+		// DeleteRuleSetByCID holds details about calls to the DeleteRuleSetByCID method.
+		DeleteRuleSetByCID []struct {
+			// Cid is the cid argument value.
+			Cid circapi.CIDType
+		}
 		// DeleteWorksheetByCID holds details about calls to the DeleteWorksheetByCID method.
 		DeleteWorksheetByCID []struct {
 			// Cid is the cid argument value.
@@ -560,6 +572,37 @@ func (mock *CircAPIMock) DeleteGraphByCIDCalls() []struct {
 	return calls
 }
 
+// DeleteRuleSetByCID calls DeleteRuleSetByCIDFunc.
+func (mock *CircAPIMock) DeleteRuleSetByCID(cid circapi.CIDType) (bool, error) {
+	if mock.DeleteRuleSetByCIDFunc == nil {
+		panic("moq: CircAPIMock.DeleteRuleSetByCIDFunc is nil but CircAPI.DeleteRuleSetByCID was just called")
+	}
+	callInfo := struct {
+		Cid circapi.CIDType
+	}{
+		Cid: cid,
+	}
+	lockCircAPIMockDeleteRuleSetByCID.Lock()
+	mock.calls.DeleteRuleSetByCID = append(mock.calls.DeleteRuleSetByCID, callInfo)
+	lockCircAPIMockDeleteRuleSetByCID.Unlock()
+	return mock.DeleteRuleSetByCIDFunc(cid)
+}
+
+// DeleteRuleSetByCIDCalls gets all the calls that were made to DeleteRuleSetByCID.
+// Check the length with:
+//     len(mockedCircAPI.DeleteRuleSetByCIDCalls())
+func (mock *CircAPIMock) DeleteRuleSetByCIDCalls() []struct {
+	Cid circapi.CIDType
+} {
+	var calls []struct {
+		Cid circapi.CIDType
+	}
+	lockCircAPIMockDeleteRuleSetByCID.RLock()
+	calls = mock.calls.DeleteRuleSetByCID
+	lockCircAPIMockDeleteRuleSetByCID.RUnlock()
+	return calls
+}
+
 // DeleteWorksheetByCID calls DeleteWorksheetByCIDFunc.
 func (mock *CircAPIMock) DeleteWorksheetByCID(cid circapi.CIDType) (bool, error) {
 	if mock.DeleteWorksheetByCIDFunc == nil {
@@ -10,6 +10,7 @@ import (
 	"math/rand"
 	"net"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/circonus-labs/cosi-tool/internal/broker"
@@ -19,15 +20,34 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	brokerTypeAny        = "any"
+	brokerTypeEnterprise = "enterprise"
+	brokerTypePublic     = "public"
+
+	brokerSelectRandom  = "random"
+	brokerSelectLatency = "latency"
+
+	// brokerLatencySamples is the number of tcp connects used to
+	// measure the latency to a broker instance
+	brokerLatencySamples = 3
+
+	defaultBrokerPort = uint16(43191)
+)
+
 // selectBroker uses one of several methods to determine the broker to use when creating a check.
 // 1. explicit --broker on command line or  explicit broker set for check in config file
 // 2. explicit list of brokers to select from for check type in config file
 // 3. select from available enterprise brokers
-// NOTE: if there are any enterprise brokers #5 will not be used even
+// NOTE: if there are any enterprise brokers #4 will not be used even
 //       if none of the enterprise brokers is valid for the check type.
 //       to force use of a SaaS broker when enterprise brokers are available,
-//       use one of the excplicit methods #1 or #2 above.
+//       use --broker-type=public or one of the explicit methods #1 or #2 above.
 // 4. get broker from cosi-server for the check type
+// All methods respect --broker-type (any|enterprise|public), a broker
+// of the wrong type is rejected with the reason. When more than one broker
+// is viable (#2 w/default -1 and #3), --broker-select (random|latency)
+// determines which is used.
 func (r *Registration) selectBroker(checkType string) (string, error) {
 	logger := log.With().Str("cmd", "register.broker").Logger()
 
@@ -158,7 +178,11 @@ func (r *Registration) selectFromConfigList(checkType string, brokers *[]apiclie
 				return false, "", errors.New("invalid system check broker config in regconf (default out of list range)")
 			}
 		case cfg.System.Default == -1:
-			brokerID = cfg.System.List[rand.Intn(len(cfg.System.List))]
+			bid, err := r.selectFromList(checkType, cfg.System.List, brokers)
+			if err != nil {
+				return false, "", errors.Wrap(err, "invalid system check broker config in regconf")
+			}
+			brokerID = bid
 		default:
 			return false, "", errors.New("invalid system check broker config in regconf (default invalid)")
 		}
@@ -171,7 +195,11 @@ func (r *Registration) selectFromConfigList(checkType string, brokers *[]apiclie
 				return false, "", errors.New("invalid group check broker config in regconf (default out of list range)")
 			}
 		case cfg.Group.Default == -1:
-			brokerID = cfg.Group.List[rand.Intn(len(cfg.Group.List))]
+			bid, err := r.selectFromList(checkType, cfg.Group.List, brokers)
+			if err != nil {
+				return false, "", errors.Wrap(err, "invalid group check broker config in regconf")
+			}
+			brokerID = bid
 		default:
 			return false, "", errors.New("invalid group check broker config in regconf (default invalid)")
 		}
@@ -192,6 +220,24 @@ func (r *Registration) selectFromConfigList(checkType string, brokers *[]apiclie
 	return false, "", nil // fall-through to next selection method
 }
 
+// selectFromList selects a broker id from a regconf broker list, only
+// brokers matching the requested broker type are considered
+func (r *Registration) selectFromList(checkType string, list []string, brokers *[]apiclient.Broker) (string, error) {
+	candidates := []string{}
+	for _, brokerID := range list {
+		if err := r.checkBrokerType(brokerID, brokers); err != nil {
+			r.logger.Debug().Err(err).Msg("rejecting regconf broker")
+			continue
+		}
+		candidates = append(candidates, brokerID)
+	}
+	if len(candidates) == 0 {
+		return "", errors.Errorf("no brokers in list match broker type (%s)", r.brokerType)
+	}
+	return r.pickBroker(checkType, candidates, brokers)
+}
+
+// selectEnterprise selects from available enterprise brokers, if any
 func (r *Registration) selectEnterprise(checkType string, brokers *[]apiclient.Broker) (bool, string, error) {
 	logger := log.With().Str("cmd", "register.broker").Logger()
 
@@ -202,13 +248,19 @@ func (r *Registration) selectEnterprise(checkType string, brokers *[]apiclient.B
 		return false, "", errors.New("invalid broker list (nil)")
 	}
 
+	if r.brokerType == brokerTypePublic {
+		logger.Debug().Msg("broker type public requested, skipping enterprise brokers")
+		return false, "", nil
+	}
+
 	//
 	// create list of active enterprise brokers, if there are any
 	//
 	haveEnterpriseBrokers := false
 	enterpriseBrokerList := []string{}
+	rejected := []string{}
 	for _, broker := range *brokers {
-		if broker.Type != "enterprise" {
+		if broker.Type != brokerTypeEnterprise {
 			continue
 		}
 		// ensure there is at least ONE instance which is active
@@ -226,6 +278,7 @@ func (r *Registration) selectEnterprise(checkType string, brokers *[]apiclient.B
 		valid, bid, err := r.checkBroker(checkType, broker.CID, brokers)
 		if err != nil {
 			logger.Warn().Err(err).Msg("checking enterprise broker, skipping")
+			rejected = append(rejected, err.Error())
 		}
 		if valid {
 			enterpriseBrokerList = append(enterpriseBrokerList, bid)
@@ -236,16 +289,19 @@ func (r *Registration) selectEnterprise(checkType string, brokers *[]apiclient.B
 		//       this enforcement also makes cosi work for inside setups which are using
 		//       the public cosi-server. (an inside setup only has enterprise brokers...)
 		if len(enterpriseBrokerList) == 0 {
-			return false, "", errors.New("available enterprise brokers found, none valid")
+			return false, "", errors.Errorf("available enterprise brokers found, none valid - %s", strings.Join(rejected, " | "))
 		}
 		if len(enterpriseBrokerList) == 1 { // only one, return it
 			bid := enterpriseBrokerList[0]
 			logger.Debug().Str("check_type", checkType).Str("broker", bid).Msg("found enterprise broker")
 			return true, bid, nil
 		}
-		// otherwise, select a random one
-		bid := enterpriseBrokerList[rand.Intn(len(enterpriseBrokerList))]
-		logger.Debug().Str("check_type", checkType).Str("broker", bid).Msg("found more than one enterprise broker, using random one")
+		// otherwise, pick one using the configured selection method
+		bid, err := r.pickBroker(checkType, enterpriseBrokerList, brokers)
+		if err != nil {
+			return false, "", errors.Wrap(err, "selecting enterprise broker")
+		}
+		logger.Debug().Str("check_type", checkType).Str("broker", bid).Str("select", r.brokerSelect).Msg("found more than one enterprise broker")
 		return true, bid, nil
 	}
 
@@ -265,6 +321,11 @@ func (r *Registration) getCosiDefault(checkType string, brokers *[]apiclient.Bro
 		return false, "", errors.New("invalid cosi API client (nil)")
 	}
 
+	// the cosi default is always a public (SaaS) broker
+	if r.brokerType == brokerTypeEnterprise {
+		return false, "", errors.New("broker type (enterprise) requested, no enterprise brokers available")
+	}
+
 	brokerID, err := client.FetchBroker(checkType)
 	if err != nil {
 		return false, "", err
@@ -303,6 +364,9 @@ func (r *Registration) checkBroker(checkType, brokerID string, brokers *[]apicli
 		if broker.CID != brokerID {
 			continue
 		}
+		if !r.brokerTypeAllowed(broker.Type) {
+			return false, "", errors.Errorf("broker %s type (%s) does not match requested broker type (%s)", brokerID, broker.Type, r.brokerType)
+		}
 		// short-circuit for a broker that has been provisioned with no usable ip address
 		if len(broker.Details) == 1 {
 			if broker.Details[0].IP == nil && broker.Details[0].ExternalHost == nil {
@@ -318,25 +382,13 @@ func (r *Registration) checkBroker(checkType, brokerID string, brokers *[]apicli
 					continue
 				}
 				hasModule = true
-				ip := instance.ExternalHost
-				if ip == nil {
-					ip = instance.IP
-				}
-				if ip == nil {
+				ip, port, ok := instanceAddress(instance)
+				if !ok {
 					r.logger.Warn().Str("broker_id", brokerID).Str("instance_cn", instance.CN).Msg("'ipaddress' and 'external_host' both null, skipping instance")
 					continue // no external host or ip set - unreachable, active w/o ip...wtf!?
 				}
-				port := instance.ExternalPort
-				if port == 0 {
-					if instance.Port != nil {
-						port = *instance.Port
-					}
-				}
-				if port == 0 {
-					port = uint16(43191) // default broker port
-				}
 
-				ok, err := brokerConnectionTest(*ip, port, r.maxBrokerResponseTime)
+				ok, err := brokerConnectionTest(ip, port, r.maxBrokerResponseTime)
 				if err != nil {
 					// stack up the conn errors (may have tested 1-n broker instances, messy but complete...)
 					if connErr != nil {
@@ -363,6 +415,146 @@ func (r *Registration) checkBroker(checkType, brokerID string, brokers *[]apicli
 	return false, "", errors.Errorf("broker %s has no viable instance", brokerID)
 }
 
+// pickBroker selects one broker from a list of candidates using the
+// configured selection method (random|latency)
+func (r *Registration) pickBroker(checkType string, candidates []string, brokers *[]apiclient.Broker) (string, error) {
+	if len(candidates) == 0 {
+		return "", errors.New("invalid candidate list (empty)")
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	if r.brokerSelect != brokerSelectLatency {
+		return candidates[rand.Intn(len(candidates))], nil
+	}
+
+	var fastestID string
+	var fastest time.Duration
+	rejected := []string{}
+	for _, brokerID := range candidates {
+		latency, err := r.brokerLatency(checkType, brokerID, brokers)
+		if err != nil {
+			r.logger.Debug().Err(err).Str("broker", brokerID).Msg("measuring broker latency, skipping")
+			rejected = append(rejected, err.Error())
+			continue
+		}
+		r.logger.Debug().Str("broker", brokerID).Str("latency", latency.String()).Msg("broker latency")
+		if fastestID == "" || latency < fastest {
+			fastestID = brokerID
+			fastest = latency
+		}
+	}
+	if fastestID == "" {
+		return "", errors.Errorf("no broker with measurable latency - %s", strings.Join(rejected, " | "))
+	}
+
+	return fastestID, nil
+}
+
+// brokerLatency returns the lowest average tcp connect time of the active
+// instances of a broker which have the module for the check type
+func (r *Registration) brokerLatency(checkType, brokerID string, brokers *[]apiclient.Broker) (time.Duration, error) {
+	if !regexp.MustCompile(`^/broker/[0-9]+$`).MatchString(brokerID) {
+		brokerID = "/broker/" + brokerID
+	}
+
+	var best time.Duration
+	var lastErr error
+	found := false
+	for _, broker := range *brokers {
+		if broker.CID != brokerID {
+			continue
+		}
+		for _, instance := range broker.Details {
+			if instance.Status != "active" {
+				continue
+			}
+			hasModule := false
+			for _, module := range instance.Modules {
+				if module == checkType {
+					hasModule = true
+					break
+				}
+			}
+			if !hasModule {
+				continue
+			}
+			ip, port, ok := instanceAddress(instance)
+			if !ok {
+				continue
+			}
+			latency, err := connectLatency(ip, port, r.maxBrokerResponseTime, brokerLatencySamples)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if !found || latency < best {
+				best = latency
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		if lastErr != nil {
+			return 0, errors.Wrapf(lastErr, "broker %s", brokerID)
+		}
+		return 0, errors.Errorf("broker %s has no viable instance", brokerID)
+	}
+
+	return best, nil
+}
+
+// instanceAddress returns the address and port to use when connecting to a broker instance
+func instanceAddress(instance apiclient.BrokerDetail) (string, uint16, bool) {
+	ip := instance.ExternalHost
+	if ip == nil {
+		ip = instance.IP
+	}
+	if ip == nil {
+		return "", 0, false
+	}
+	port := instance.ExternalPort
+	if port == 0 {
+		if instance.Port != nil {
+			port = *instance.Port
+		}
+	}
+	if port == 0 {
+		port = defaultBrokerPort
+	}
+	return *ip, port, true
+}
+
+// checkBrokerType verifies a broker id in the broker list matches the requested broker type
+func (r *Registration) checkBrokerType(brokerID string, brokers *[]apiclient.Broker) error {
+	if !regexp.MustCompile(`^/broker/[0-9]+$`).MatchString(brokerID) {
+		brokerID = "/broker/" + brokerID
+	}
+	for _, broker := range *brokers {
+		if broker.CID != brokerID {
+			continue
+		}
+		if !r.brokerTypeAllowed(broker.Type) {
+			return errors.Errorf("broker %s type (%s) does not match requested broker type (%s)", brokerID, broker.Type, r.brokerType)
+		}
+		return nil
+	}
+	return errors.Errorf("broker %s not found", brokerID)
+}
+
+// brokerTypeAllowed verifies a broker type satisfies the requested broker type (any|enterprise|public)
+func (r *Registration) brokerTypeAllowed(brokerType string) bool {
+	switch r.brokerType {
+	case brokerTypeEnterprise:
+		return brokerType == brokerTypeEnterprise
+	case brokerTypePublic:
+		return brokerType != brokerTypeEnterprise
+	default:
+		return true
+	}
+}
+
 func brokerConnectionTest(ip string, port uint16, deadline time.Duration) (bool, error) {
 	if ip == "" {
 		return false, errors.New("invalid ip (empty)")
@@ -384,3 +576,21 @@ func brokerConnectionTest(ip string, port uint16, deadline time.Duration) (bool,
 
 	return true, nil
 }
+
+// connectLatency measures the average tcp connect time to an address over a number of samples
+func connectLatency(ip string, port uint16, deadline time.Duration, samples int) (time.Duration, error) {
+	if samples <= 0 {
+		return 0, errors.New("invalid samples (<=0)")
+	}
+
+	var total time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if _, err := brokerConnectionTest(ip, port, deadline); err != nil {
+			return 0, err
+		}
+		total += time.Since(start)
+	}
+
+	return total / time.Duration(samples), nil
+}
@@ -362,7 +362,7 @@ func TestSelectEnterprise(t *testing.T) {
 	}{
 		{"invalid check type (empty)", "", &emptyBrokers, false, false, true, "invalid check type (empty)"},
 		{"invalid broker list", "foo", nil, false, false, true, "invalid broker list (nil)"},
-		{"no valid enterprise", "json", &noValidEntBrokers, false, false, true, "available enterprise brokers found, none valid"},
+		{"no valid enterprise", "json", &noValidEntBrokers, false, false, true, "available enterprise brokers found, none valid - broker /broker/1 has no instance with module (json) loaded"},
 		// system check
 		{"sys no enterprise, pass-through", "json", &emptyBrokers, false, false, false, ""},
 		{"sys valid", "json", &validBrokers, true, true, false, ""},
@@ -672,3 +672,300 @@ func TestBrokerConnectionTest(t *testing.T) {
 		})
 	}
 }
+
+func TestBrokerTypeFilter(t *testing.T) {
+	t.Log("Testing broker type filtering")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "foo")
+	}))
+	defer broker.Close()
+	bu, err := url.Parse(broker.URL)
+	if err != nil {
+		t.Fatalf("error parsing broker url (%s)", err)
+	}
+	bip := bu.Hostname()
+	bport, err := strconv.ParseUint(bu.Port(), 10, 16)
+	if err != nil {
+		t.Fatalf("error parsing broker url port (%s)", err)
+	}
+
+	brokers := []apiclient.Broker{
+		{
+			CID:  "/broker/1",
+			Type: "circonus",
+			Details: []apiclient.BrokerDetail{
+				{
+					Status:       "active",
+					Modules:      []string{"json", "httptrap"},
+					ExternalHost: &bip,
+					ExternalPort: uint16(bport),
+				},
+			},
+		},
+		{
+			CID:  "/broker/2",
+			Type: "enterprise",
+			Details: []apiclient.BrokerDetail{
+				{
+					Status:       "active",
+					Modules:      []string{"json", "httptrap"},
+					ExternalHost: &bip,
+					ExternalPort: uint16(bport),
+				},
+			},
+		},
+	}
+
+	newReg := func(brokerType string) *Registration {
+		return &Registration{
+			cliCirc:               genMockCircAPI(),
+			cliCosi:               genMockCosiAPI(),
+			maxBrokerResponseTime: 500 * time.Millisecond,
+			brokerType:            brokerType,
+		}
+	}
+
+	t.Run("explicit, type mismatch", func(t *testing.T) {
+		reg := newReg(brokerTypePublic)
+		_, _, err := reg.getExplicit("json", &brokers, &options.Checks{System: options.SystemCheck{BrokerID: "2"}})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expectedErr := "invalid broker id specified (2): broker /broker/2 type (enterprise) does not match requested broker type (public)"
+		if err.Error() != expectedErr {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	})
+
+	t.Run("config list random, filtered", func(t *testing.T) {
+		reg := newReg(brokerTypeEnterprise)
+		cfg := &options.Brokers{System: options.SystemBrokers{List: validOptBrokerList, Default: -1}}
+		for i := 0; i < 10; i++ {
+			valid, bid, err := reg.selectFromConfigList("json", &brokers, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if !valid || bid != "/broker/2" {
+				t.Fatalf("expected valid /broker/2, got %v %s", valid, bid)
+			}
+		}
+	})
+
+	t.Run("config list random, none match", func(t *testing.T) {
+		reg := newReg(brokerTypeEnterprise)
+		cfg := &options.Brokers{Group: options.GroupBrokers{List: []string{"/broker/1"}, Default: -1}}
+		_, _, err := reg.selectFromConfigList("httptrap", &brokers, cfg)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid group check broker config in regconf: no brokers in list match broker type (enterprise)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	})
+
+	t.Run("enterprise, public requested", func(t *testing.T) {
+		reg := newReg(brokerTypePublic)
+		valid, bid, err := reg.selectEnterprise("json", &brokers)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if valid || bid != "" {
+			t.Fatalf("expected pass-through, got %v %s", valid, bid)
+		}
+	})
+
+	t.Run("enterprise, enterprise requested", func(t *testing.T) {
+		reg := newReg(brokerTypeEnterprise)
+		valid, bid, err := reg.selectEnterprise("json", &brokers)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !valid || bid != "/broker/2" {
+			t.Fatalf("expected valid /broker/2, got %v %s", valid, bid)
+		}
+	})
+
+	t.Run("cosi default, enterprise requested", func(t *testing.T) {
+		reg := newReg(brokerTypeEnterprise)
+		_, _, err := reg.getCosiDefault("json", &brokers, reg.cliCosi)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "broker type (enterprise) requested, no enterprise brokers available" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	})
+
+	t.Run("cosi default, public requested", func(t *testing.T) {
+		reg := newReg(brokerTypePublic)
+		valid, bid, err := reg.getCosiDefault("json", &brokers, reg.cliCosi)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !valid || !validBrokerRx.MatchString(bid) {
+			t.Fatalf("expected valid broker, got %v %s", valid, bid)
+		}
+	})
+}
+
+func TestPickBroker(t *testing.T) {
+	t.Log("Testing pickBroker")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "foo")
+	}))
+	defer broker.Close()
+	bu, err := url.Parse(broker.URL)
+	if err != nil {
+		t.Fatalf("error parsing broker url (%s)", err)
+	}
+	bip := bu.Hostname()
+	bport, err := strconv.ParseUint(bu.Port(), 10, 16)
+	if err != nil {
+		t.Fatalf("error parsing broker url port (%s)", err)
+	}
+
+	// a port with nothing listening, connections will be refused
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	cu, err := url.Parse(closed.URL)
+	if err != nil {
+		t.Fatalf("error parsing closed url (%s)", err)
+	}
+	closed.Close()
+	cport, err := strconv.ParseUint(cu.Port(), 10, 16)
+	if err != nil {
+		t.Fatalf("error parsing closed url port (%s)", err)
+	}
+
+	brokers := []apiclient.Broker{
+		{
+			CID: "/broker/1",
+			Details: []apiclient.BrokerDetail{
+				{
+					Status:       "active",
+					Modules:      []string{"json"},
+					ExternalHost: &bip,
+					ExternalPort: uint16(cport),
+				},
+			},
+		},
+		{
+			CID: "/broker/2",
+			Details: []apiclient.BrokerDetail{
+				{
+					Status:       "active",
+					Modules:      []string{"json"},
+					ExternalHost: &bip,
+					ExternalPort: uint16(bport),
+				},
+			},
+		},
+		{
+			CID: "/broker/3",
+			Details: []apiclient.BrokerDetail{
+				{
+					Status:       "active",
+					Modules:      []string{"httptrap"},
+					ExternalHost: &bip,
+					ExternalPort: uint16(bport),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		selectMode  string
+		candidates  []string
+		expectedBid string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid candidates (empty)", brokerSelectRandom, []string{}, "", true, "invalid candidate list (empty)"},
+		{"single candidate", brokerSelectLatency, []string{"/broker/1"}, "/broker/1", false, ""},
+		{"latency, skip unreachable", brokerSelectLatency, []string{"/broker/1", "/broker/2"}, "/broker/2", false, ""},
+		{"latency, none viable", brokerSelectLatency, []string{"/broker/3", "/broker/4"}, "", true, "no broker with measurable latency - broker /broker/3 has no viable instance | broker /broker/4 has no viable instance"},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			reg := &Registration{
+				cliCirc:               genMockCircAPI(),
+				maxBrokerResponseTime: 500 * time.Millisecond,
+				brokerSelect:          tst.selectMode,
+			}
+			bid, err := reg.pickBroker("json", tst.candidates, &brokers)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if bid != tst.expectedBid {
+				t.Fatalf("expected (%s) got (%s)", tst.expectedBid, bid)
+			}
+		})
+	}
+}
+
+func TestConnectLatency(t *testing.T) {
+	t.Log("Testing connectLatency")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "foo")
+	}))
+	defer broker.Close()
+	bu, err := url.Parse(broker.URL)
+	if err != nil {
+		t.Fatalf("error parsing broker url (%s)", err)
+	}
+	bip := bu.Hostname()
+	port, err := strconv.ParseUint(bu.Port(), 10, 16)
+	if err != nil {
+		t.Fatalf("error parsing broker url port (%s)", err)
+	}
+	bport := uint16(port)
+
+	tests := []struct {
+		name        string
+		samples     int
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid samples (0)", 0, true, "invalid samples (<=0)"},
+		{"valid", brokerLatencySamples, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			latency, err := connectLatency(bip, bport, 500*time.Millisecond, tst.samples)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if latency <= 0 {
+				t.Fatalf("expected latency > 0 got (%s)", latency)
+			}
+		})
+	}
+}
@@ -49,6 +49,18 @@ type CheckInfo struct {
 	SubmissionURL string // only applies to trap check (e.g. check-group)
 }
 
+// AddTemplateVars adds the check variables (CheckCID, CheckID, CheckUUID
+// and BundleCID) to a template variable context
+func (ci *CheckInfo) AddTemplateVars(tvars map[string]interface{}) {
+	if ci == nil || tvars == nil {
+		return
+	}
+	tvars["BundleCID"] = ci.BundleCID
+	tvars["CheckCID"] = ci.CheckCID
+	tvars["CheckID"] = ci.CheckID
+	tvars["CheckUUID"] = ci.CheckUUID
+}
+
 const (
 	statusActive = "active"
 )
@@ -322,6 +334,12 @@ func (c *Checks) UpdateSystemCheck(metrics *map[string]string) error {
 			return err
 		}
 		c.checkList["check-system"] = newCfg
+
+		// keep the registration in sync with the check, otherwise drift
+		// reports the activated metrics as changes
+		if err := regfiles.Save(regfiles.File(c.regDir, "check-system"), newCfg, true); err != nil {
+			return errors.Wrap(err, "saving check-system registration")
+		}
 	}
 
 	return nil
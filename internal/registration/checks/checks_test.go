@@ -7,9 +7,11 @@ package checks
 
 import (
 	"io/ioutil"
+	"os"
 	"testing"
 
 	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/drift"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
 	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
@@ -260,3 +262,79 @@ func TestGetCheckInfo(t *testing.T) {
 		}
 	}
 }
+
+// driftAPI returns the check bundle as it currently exists in the api
+type driftAPI struct {
+	drift.CircAPI
+	bundle *circapi.CheckBundle
+}
+
+func (d *driftAPI) FetchCheckBundle(cid circapi.CIDType) (*circapi.CheckBundle, error) {
+	return d.bundle, nil
+}
+
+func TestUpdateSystemCheck(t *testing.T) {
+	t.Log("Testing UpdateSystemCheck")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-checks-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	api := &driftAPI{}
+	client := &CircAPIMock{
+		CreateCheckBundleFunc: func(cfg *circapi.CheckBundle) (*circapi.CheckBundle, error) {
+			b := *cfg
+			b.Metrics = append([]circapi.CheckBundleMetric{}, cfg.Metrics...)
+			api.bundle = &b
+			return cfg, nil
+		},
+		UpdateCheckBundleFunc: func(cfg *circapi.CheckBundle) (*circapi.CheckBundle, error) {
+			b := *cfg
+			b.Metrics = append([]circapi.CheckBundleMetric{}, cfg.Metrics...)
+			b.LastModified++
+			api.bundle = &b
+			return &b, nil
+		},
+	}
+
+	c, err := New(&Options{
+		Client:    client,
+		Config:    &options.Options{},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+	})
+	if err != nil {
+		t.Fatalf("unable to create checks object (%s)", err)
+	}
+
+	b, err := c.createCheck("check-system", &circapi.CheckBundle{
+		CID:  "/check_bundle/123",
+		Type: "json:nad",
+		Metrics: []circapi.CheckBundleMetric{
+			{Name: "cosi_placeholder", Type: "numeric", Status: statusActive},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	c.checkList["check-system"] = b
+
+	metrics := map[string]string{"cpu`idle": "numeric"}
+	if err := c.UpdateSystemCheck(&metrics); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	results, err := drift.Check(api, dir)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != drift.StatusOK {
+		t.Fatalf("unexpected status (%s) changes (%#v)", results[0].Status, results[0].Changes)
+	}
+}
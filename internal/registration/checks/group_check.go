@@ -20,14 +20,7 @@ func (c *Checks) createGroupCheck() (*circapi.CheckBundle, error) {
 	checkID := cfgType + "-" + cfgName
 
 	// set up the template expansion data
-	type templateVars struct {
-		HostName string
-		GroupID  string
-	}
-	tvars := templateVars{
-		HostName: c.config.Host.Name,
-		GroupID:  c.config.Checks.Group.ID,
-	}
+	tvars := c.config.TemplateVars()
 
 	cfg, err := c.parseTemplateConfig(cfgType, cfgName, tvars)
 	if err != nil {
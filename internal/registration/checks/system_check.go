@@ -18,14 +18,7 @@ func (c *Checks) createSystemCheck() (*circapi.CheckBundle, error) {
 	checkID := cfgType + "-" + cfgName
 
 	// set up the template expansion data
-	type templateVars struct {
-		HostName   string
-		HostTarget string
-	}
-	tvars := templateVars{
-		HostName:   c.config.Host.Name,
-		HostTarget: c.config.Checks.System.Target,
-	}
+	tvars := c.config.TemplateVars()
 
 	cfg, err := c.parseTemplateConfig(cfgType, cfgName, tvars)
 	if err != nil {
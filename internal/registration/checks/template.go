@@ -6,12 +6,10 @@
 package checks
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 
+	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -47,25 +45,19 @@ func (c *Checks) parseTemplateConfig(cfgType, cfgName string, templateVars inter
 	}
 
 	// expand template w/data
-	tmpl, err := template.New(templateID).Parse(conf.Template)
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
+	data, err := templates.Expand(templateID, conf.Template, templateVars)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	if err := tmpl.Execute(bw, templateVars); err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", conf.Template, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create check bundle config
 	var cfg circapi.CheckBundle
-	if err := json.Unmarshal(b.Bytes(), &cfg); err != nil {
+	if err := json.Unmarshal(data, &cfg); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing template config")
 	}
@@ -8,9 +8,11 @@ package registration
 import (
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/agent"
 	"github.com/circonus-labs/cosi-tool/internal/config"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
 	"github.com/circonus-labs/cosi-tool/internal/templates"
@@ -39,22 +41,51 @@ func (r *Registration) configure() error {
 		os.Exit(0)
 	}
 
-	// Set brokers for checks if they are not already set
-	r.logger.Info().Msg("selecting system check broker")
-	if bid, err := r.selectBroker("json"); err == nil {
-		r.logger.Info().Str("broker", bid).Msg("system check broker")
-		r.config.Checks.System.BrokerID = bid
-	} else {
-		return errors.Wrap(err, "selecting system check broker")
+	switch bt := strings.ToLower(viper.GetString(config.KeyHostBrokerType)); bt {
+	case "", brokerTypeAny:
+		r.brokerType = brokerTypeAny
+	case brokerTypeEnterprise, brokerTypePublic:
+		r.brokerType = bt
+	default:
+		return errors.Errorf("invalid broker type (%s) - must be any|enterprise|public", bt)
 	}
 
-	if r.config.Checks.Group.Create {
-		r.logger.Info().Msg("selecting group check broker")
-		if bid, err := r.selectBroker("httptrap"); err == nil {
-			r.logger.Info().Str("broker", bid).Msg("group check broker")
-			r.config.Checks.System.BrokerID = bid
-		} else {
-			return errors.Wrap(err, "selecting group check broker")
+	brokerSelect := r.config.Brokers.Select
+	if viper.GetString(KeyBrokerSelect) != "" {
+		brokerSelect = viper.GetString(KeyBrokerSelect)
+	}
+	switch bs := strings.ToLower(brokerSelect); bs {
+	case "":
+		// use default
+	case brokerSelectRandom, brokerSelectLatency:
+		r.brokerSelect = bs
+	default:
+		return errors.Errorf("invalid broker select (%s) - must be random|latency", bs)
+	}
+
+	maxResponseTime := r.config.Brokers.MaxResponseTime
+	if viper.GetString(KeyBrokerMaxResponseTime) != "" {
+		maxResponseTime = viper.GetString(KeyBrokerMaxResponseTime)
+	}
+	if maxResponseTime != "" {
+		d, err := time.ParseDuration(maxResponseTime)
+		if err != nil {
+			return errors.Wrap(err, "parsing broker max response time")
+		}
+		if d <= 0 {
+			return errors.Errorf("invalid broker max response time (%s) - must be greater than zero", maxResponseTime)
+		}
+		r.maxBrokerResponseTime = d
+	}
+
+	switch {
+	case r.plugin:
+		// the system check must already exist, no broker needed
+	case r.dryRun:
+		r.setDryRunBrokers()
+	default:
+		if err := r.selectBrokers(); err != nil {
+			return err
 		}
 	}
 
@@ -65,6 +96,11 @@ func (r *Registration) configure() error {
 		return err
 	}
 
+	if r.plugin {
+		// the template list is supplied by the plugin
+		return nil
+	}
+
 	// set the templates to attempt to create
 	// NOTE: the system check will ALWAYS be created
 	r.logger.Info().Msg("setting template list")
@@ -105,6 +141,41 @@ func verifyRegDir(regDir string, logger zerolog.Logger) error {
 	return nil
 }
 
+// selectBrokers sets brokers for checks if they are not already set
+func (r *Registration) selectBrokers() error {
+	r.logger.Info().Msg("selecting system check broker")
+	if bid, err := r.selectBroker("json"); err == nil {
+		r.logger.Info().Str("broker", bid).Msg("system check broker")
+		r.config.Checks.System.BrokerID = bid
+	} else {
+		return errors.Wrap(err, "selecting system check broker")
+	}
+
+	if r.config.Checks.Group.Create {
+		r.logger.Info().Msg("selecting group check broker")
+		if bid, err := r.selectBroker("httptrap"); err == nil {
+			r.logger.Info().Str("broker", bid).Msg("group check broker")
+			r.config.Checks.Group.BrokerID = bid
+		} else {
+			return errors.Wrap(err, "selecting group check broker")
+		}
+	}
+
+	return nil
+}
+
+// setDryRunBrokers uses the explicitly configured brokers, or a placeholder,
+// broker selection requires the Circonus API
+func (r *Registration) setDryRunBrokers() {
+	r.logger.Info().Msg("dry run, skipping broker selection")
+	if r.config.Checks.System.BrokerID == "" {
+		r.config.Checks.System.BrokerID = dryRunBrokerCID
+	}
+	if r.config.Checks.Group.Create && r.config.Checks.Group.BrokerID == "" {
+		r.config.Checks.Group.BrokerID = dryRunBrokerCID
+	}
+}
+
 // Get available metrics from agent
 func getAvailableMetrics(agentURL string, logger zerolog.Logger) (*agentapi.Metrics, error) {
 	if agentURL == "" {
@@ -113,12 +184,12 @@ func getAvailableMetrics(agentURL string, logger zerolog.Logger) (*agentapi.Metr
 
 	logger.Info().Str("agent_url", agentURL).Msg("fetching available metrics from agent")
 
-	client, err := agentapi.New(agentURL)
+	client, err := agent.New(agentURL)
 	if err != nil {
 		return nil, errors.New("creating agent API client")
 	}
 
-	metrics, err := client.Metrics("")
+	metrics, err := client.AvailableMetrics("")
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching available metrics from agent")
 	}
@@ -15,11 +15,14 @@ import (
 
 	"github.com/circonus-labs/cosi-tool/internal/dashboard"
 	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
-func (d *Dashboards) create(id string) error {
+// create builds the dashboard(s) for a template, or for one instance of
+// the template if inst is not nil
+func (d *Dashboards) create(id string, inst *dashboard.Instance) error {
 	if id == "" {
 		return errors.Errorf("invalid id (empty)")
 	}
@@ -33,31 +36,36 @@ func (d *Dashboards) create(id string) error {
 		return errors.Errorf("%s invalid template (no configs)", id)
 	}
 
-	// TODO: will need to revisit and carve out the "dashboard_instance"
-	//       vars from the old cosi plugin cmd support.
-	//       the below meta data only adds partial support for the way the
-	//       plugin command does its job. (postgress and cassandra use
-	//       instances - meaning multiple copies of the same dashboard template)
-
 	// set up the template expansion data
-	// NOTE: all dashboards in a single template get the SAME set of basic
-	//       template vars. a combination of local system items as well as
-	//       any k:v data from a meta configuration file.
-	tvars := map[string]string{}
-
-	if m, err := d.loadMeta(id); err == nil {
-		for k, v := range m {
-			tvars[k] = v
-		}
-	} else {
+	// NOTE: all dashboards in a single template (or template instance) get
+	//       the SAME set of basic template vars. a combination of local
+	//       system items as well as any k:v data from a meta configuration
+	//       file and the instance meta data.
+	meta, err := d.loadMeta(id)
+	if err != nil {
 		d.logger.Warn().Err(err).Msg("loading dashobard meta data file")
 	}
-	// do system vars after to ensure they are not overwritten
-	tvars["HostName"] = d.config.Host.Name
-	tvars["CheckUUID"] = d.checkInfo.CheckUUID
+	var instMeta map[string]string
+	if inst != nil {
+		instMeta = inst.Meta
+	}
+	// system vars are set last to ensure they are not overwritten
+	tvars := d.config.TemplateVars(meta, instMeta)
+	d.checkInfo.AddTemplateVars(tvars)
+	if inst != nil {
+		tvars[dashboard.InstanceVar] = inst.Name
+	}
 
 	for dashName, cfg := range t.Configs {
 		dashID := id + "-" + dashName
+		if inst != nil {
+			// registration-<id>@<instance>.json, with the dashboard name
+			// appended if the template defines more than one dashboard
+			dashID = dashboard.InstanceID(id, inst.Name)
+			if len(t.Configs) > 1 {
+				dashID += "-" + dashName
+			}
+		}
 
 		d.logger.Info().Str("id", dashID).Msg("building dashboard")
 
@@ -66,6 +74,12 @@ func (d *Dashboards) create(id string) error {
 			return err
 		}
 
+		if id == systemDashboardID {
+			if err := d.applyOverrides(dcfg, tvars); err != nil {
+				return err
+			}
+		}
+
 		for widx, wcfg := range cfg.Widgets {
 			delete(tvars, "GraphUUID")
 			if wcfg.GraphName != "" {
@@ -91,6 +105,21 @@ func (d *Dashboards) create(id string) error {
 			}
 		}
 
+		if d.update {
+			regFile := regfiles.File(d.regDir, dashID)
+			var reg circapi.Dashboard
+			found, err := regfiles.Load(regFile, &reg)
+			if err != nil {
+				return errors.Wrapf(err, "loading %s", dashID)
+			}
+			if found {
+				if err := d.updateDashboard(dashID, &reg, dcfg); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
 		dash, err := dashboard.Create(d.client, dcfg)
 		if err != nil {
 			return err
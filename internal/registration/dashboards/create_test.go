@@ -49,7 +49,7 @@ func TestCreate(t *testing.T) {
 		tst := test
 		t.Run(tst.name, func(t *testing.T) {
 			t.Parallel()
-			err := d.create(tst.id)
+			err := d.create(tst.id, nil)
 			if tst.shouldFail {
 				if err == nil {
 					t.Fatal("expected error")
@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
 	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
 	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
@@ -21,18 +23,24 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	systemDashboardID = "dashboard-system"
+)
+
 // Dashboards defines the registration instance
 type Dashboards struct {
-	dashList  map[string]*circapi.Dashboard
-	client    CircAPI
-	config    *options.Options
-	regDir    string
-	templates *templates.Templates
-	checkInfo *checks.CheckInfo
-	graphInfo map[string]graphs.GraphInfo
-	metrics   *agentapi.Metrics
-	regFiles  *[]string
-	logger    zerolog.Logger
+	dashList   map[string]*circapi.Dashboard
+	client     CircAPI
+	config     *options.Options
+	regDir     string
+	templates  *templates.Templates
+	checkInfo  *checks.CheckInfo
+	graphInfo  map[string]graphs.GraphInfo
+	metrics    *agentapi.Metrics
+	regFiles   *[]string
+	onComplete func(id string)
+	update     bool
+	logger     zerolog.Logger
 }
 
 // Options defines the settings required to create a new instance
@@ -44,6 +52,11 @@ type Options struct {
 	CheckInfo *checks.CheckInfo
 	GraphInfo *map[string]graphs.GraphInfo
 	Metrics   *agentapi.Metrics
+	// OnComplete is optional, called with the template id once the
+	// dashboard for the template has been created
+	OnComplete func(id string)
+	// Update existing dashboards which differ from the current template
+	Update bool
 }
 
 // New creates a new Dashboards instance
@@ -82,16 +95,18 @@ func New(o *Options) (*Dashboards, error) {
 	}
 
 	d := Dashboards{
-		dashList:  make(map[string]*circapi.Dashboard),
-		client:    o.Client,
-		config:    o.Config,
-		regDir:    o.RegDir,
-		templates: o.Templates,
-		checkInfo: o.CheckInfo,
-		graphInfo: *o.GraphInfo,
-		metrics:   o.Metrics,
-		regFiles:  regs,
-		logger:    log.With().Str("cmd", "register.dashboards").Logger(),
+		dashList:   make(map[string]*circapi.Dashboard),
+		client:     o.Client,
+		config:     o.Config,
+		regDir:     o.RegDir,
+		templates:  o.Templates,
+		checkInfo:  o.CheckInfo,
+		graphInfo:  *o.GraphInfo,
+		metrics:    o.Metrics,
+		regFiles:   regs,
+		onComplete: o.OnComplete,
+		update:     o.Update,
+		logger:     log.With().Str("cmd", "register.dashboards").Logger(),
 	}
 
 	return &d, nil
@@ -120,16 +135,39 @@ func (d *Dashboards) Register(list map[string]bool) error {
 		if !create {
 			continue
 		}
-
-		if loaded, err := d.checkForRegistration(id); err != nil {
-			return err
-		} else if loaded {
+		if id == systemDashboardID && !d.config.Dashboards.System.Create {
+			d.logger.Info().Str("id", id).Msg("disabled in regconf (dashboards.system.create), skipping")
 			continue
 		}
 
-		if err := d.create(id); err != nil {
+		instances, err := dashboard.FindInstances(d.regDir, id)
+		if err != nil {
 			return err
 		}
+		if len(instances) == 0 {
+			instances = []*dashboard.Instance{nil}
+		}
+
+		for _, inst := range instances {
+			regID := id
+			if inst != nil {
+				regID = dashboard.InstanceID(id, inst.Name)
+			}
+			loaded, err := d.checkForRegistration(regID)
+			if err != nil {
+				return err
+			}
+			if loaded && !d.update {
+				continue
+			}
+
+			if err := d.create(id, inst); err != nil {
+				return err
+			}
+		}
+		if d.onComplete != nil {
+			d.onComplete(id)
+		}
 	}
 
 	return nil
@@ -149,8 +187,11 @@ func (d *Dashboards) GetMetricList() *map[string]string {
 }
 
 // checkForRegistration looks through existing registration files and
-// if the graphID is found, it is loaded. Returns a boolean indicating
-// if the registration was found+loaded successfully or an error
+// if the id is found, it is loaded and verified to still exist via the
+// API. Returns a boolean indicating if the registration was found+loaded
+// successfully or an error. A registration for a dashboard which no longer
+// exists is ignored so that the dashboard will be recreated. Widgets in an
+// existing dashboard referencing graphs which were recreated are re-linked.
 func (d *Dashboards) checkForRegistration(id string) (bool, error) {
 	if id == "" {
 		return false, errors.New("invalid dashboard id (empty)")
@@ -158,7 +199,11 @@ func (d *Dashboards) checkForRegistration(id string) (bool, error) {
 
 	regFileSig := "registration-" + id
 	for _, rf := range *d.regFiles {
-		if !strings.Contains(rf, regFileSig) {
+		rid := strings.TrimSuffix(rf, path.Ext(rf))
+		if rid != regFileSig && !strings.HasPrefix(rid, regFileSig+"-") {
+			continue
+		}
+		if d.isInstanceRegistration(id, rf) {
 			continue
 		}
 		var dash circapi.Dashboard
@@ -167,7 +212,24 @@ func (d *Dashboards) checkForRegistration(id string) (bool, error) {
 			return false, errors.Wrapf(err, "loading %s", regFileSig)
 		}
 		if found {
-			d.dashList[regFileSig] = &dash
+			current, err := d.verifyRegistration(id, &dash)
+			if err != nil {
+				return false, err
+			}
+			if current == nil {
+				return false, nil
+			}
+			relinked, err := d.relinkGraphs(id, current)
+			if err != nil {
+				return false, err
+			}
+			if relinked != nil {
+				if err := regfiles.Save(path.Join(d.regDir, rf), relinked, true); err != nil {
+					return false, errors.Wrapf(err, "saving %s registration", id)
+				}
+				current = relinked
+			}
+			d.dashList[regFileSig] = current
 			return found, nil
 		}
 		break // we already found it but there was an issue
@@ -175,3 +237,75 @@ func (d *Dashboards) checkForRegistration(id string) (bool, error) {
 
 	return false, nil
 }
+
+// isInstanceRegistration returns true if the registration file is for an
+// instance of the template id (registration-<id>@<instance>), rather
+// than the template itself
+func (d *Dashboards) isInstanceRegistration(id, regFile string) bool {
+	if !strings.HasPrefix(id, "dashboard-") {
+		return false
+	}
+	instances, err := dashboard.FindInstances(d.regDir, id)
+	if err != nil {
+		return false
+	}
+	for _, inst := range instances {
+		sig := "registration-" + dashboard.InstanceID(id, inst.Name)
+		rid := strings.TrimSuffix(regFile, path.Ext(regFile))
+		if rid == sig || strings.HasPrefix(rid, sig+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyRegistration confirms a registered dashboard still exists via the
+// API, returning the current dashboard or nil if it no longer exists
+func (d *Dashboards) verifyRegistration(id string, reg *circapi.Dashboard) (*circapi.Dashboard, error) {
+	if reg.CID == "" {
+		return nil, errors.Errorf("invalid registration for %s (empty cid)", id)
+	}
+	cid := reg.CID
+	dash, err := d.client.FetchDashboard(circapi.CIDType(&cid))
+	if err != nil {
+		if apierr.IsNotFound(err) {
+			d.logger.Warn().Str("id", id).Str("cid", cid).Msg("registered dashboard no longer exists, recreating")
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "verifying %s registration (%s)", id, cid)
+	}
+	return dash, nil
+}
+
+// relinkGraphs points widgets referencing graphs which have been recreated
+// at the new graphs. Returns the updated dashboard or nil if no widgets
+// needed to be re-linked.
+func (d *Dashboards) relinkGraphs(id string, dash *circapi.Dashboard) (*circapi.Dashboard, error) {
+	replaced := make(map[string]string)
+	for _, gi := range d.graphInfo {
+		if gi.PrevUUID != "" {
+			replaced[gi.PrevUUID] = gi.UUID
+		}
+	}
+	if len(replaced) == 0 {
+		return nil, nil
+	}
+
+	relinked := 0
+	for widx, widget := range dash.Widgets {
+		if newUUID, ok := replaced[widget.Settings.GraphUUID]; ok {
+			d.logger.Info().Str("id", id).Str("widget", widget.WidgetID).Str("old_graph", widget.Settings.GraphUUID).Str("new_graph", newUUID).Msg("re-linking widget to recreated graph")
+			dash.Widgets[widx].Settings.GraphUUID = newUUID
+			relinked++
+		}
+	}
+	if relinked == 0 {
+		return nil, nil
+	}
+
+	updated, err := d.client.UpdateDashboard(dash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "re-linking %s graphs", id)
+	}
+	return updated, nil
+}
@@ -6,12 +6,18 @@
 package dashboards
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
 	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
 	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/rs/zerolog"
@@ -26,7 +32,13 @@ func genMockCircAPI() CircAPI {
 			panic("TODO: mock out the DeleteDashboardByCID method")
 		},
 		FetchDashboardFunc: func(cid circapi.CIDType) (*circapi.Dashboard, error) {
-			panic("TODO: mock out the FetchDashboard method")
+			switch *cid {
+			case "/dashboard/deleted":
+				return nil, errors.New("API response code 404: {\"code\":404}")
+			case "/dashboard/fetch-error":
+				return nil, errors.New("forced mock api error")
+			}
+			return &circapi.Dashboard{CID: *cid}, nil
 		},
 		SearchDashboardsFunc: func(searchCriteria *circapi.SearchQueryType, filterCriteria *circapi.SearchFilterType) (*[]circapi.Dashboard, error) {
 			panic("TODO: mock out the SearchDashboards method")
@@ -52,6 +64,8 @@ func TestCheckForRegistration(t *testing.T) {
 		{"missing", "dashboard-missing", false, false, ""},
 		{"error", "dashboard-error", false, true, "loading registration-dashboard-error: parsing registration (testdata/registration-dashboard-error.json): unexpected end of JSON input"},
 		{"valid", "dashboard-valid", true, false, ""},
+		{"deleted (404)", "dashboard-deleted", false, false, ""},
+		{"verify error", "dashboard-fetch-error", false, true, "verifying dashboard-fetch-error registration (/dashboard/fetch-error): forced mock api error"},
 	}
 
 	d, err := New(&Options{
@@ -70,6 +84,8 @@ func TestCheckForRegistration(t *testing.T) {
 		"registration-dashboard-missing.json",
 		"registration-dashboard-error.json",
 		"registration-dashboard-valid.json",
+		"registration-dashboard-deleted.json",
+		"registration-dashboard-fetch-error.json",
 	}
 	d.dashList = make(map[string]*circapi.Dashboard)
 
@@ -101,3 +117,160 @@ func TestCheckForRegistration(t *testing.T) {
 		})
 	}
 }
+
+func TestRelinkGraphs(t *testing.T) {
+	t.Log("Testing relinkGraphs")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	client := genMockCircAPI().(*CircAPIMock)
+	client.UpdateDashboardFunc = func(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+		return cfg, nil
+	}
+
+	d, err := New(&Options{
+		Client:    client,
+		Config:    &options.Options{},
+		RegDir:    "testdata",
+		Templates: &templates.Templates{},
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		GraphInfo: &map[string]graphs.GraphInfo{
+			"graph-cpu": {CID: "/graph/new", UUID: "new", PrevUUID: "old"},
+			"graph-vm":  {CID: "/graph/vm", UUID: "vm"},
+		},
+		Metrics: &agentapi.Metrics{"test": {}},
+	})
+	if err != nil {
+		t.Fatalf("unable to create dashboards object (%s)", err)
+	}
+
+	t.Log("no recreated graphs referenced")
+	{
+		dash := &circapi.Dashboard{
+			CID:     "/dashboard/1",
+			Widgets: []circapi.DashboardWidget{{WidgetID: "w1", Settings: circapi.DashboardWidgetSettings{GraphUUID: "vm"}}},
+		}
+		updated, err := d.relinkGraphs("dashboard-test", dash)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if updated != nil {
+			t.Fatal("expected nil (no update)")
+		}
+		if len(client.UpdateDashboardCalls()) != 0 {
+			t.Fatal("expected no update calls")
+		}
+	}
+
+	t.Log("recreated graph referenced")
+	{
+		dash := &circapi.Dashboard{
+			CID: "/dashboard/1",
+			Widgets: []circapi.DashboardWidget{
+				{WidgetID: "w1", Settings: circapi.DashboardWidgetSettings{GraphUUID: "vm"}},
+				{WidgetID: "w2", Settings: circapi.DashboardWidgetSettings{GraphUUID: "old"}},
+			},
+		}
+		updated, err := d.relinkGraphs("dashboard-test", dash)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if updated == nil {
+			t.Fatal("expected updated dashboard")
+		}
+		if updated.Widgets[0].Settings.GraphUUID != "vm" {
+			t.Fatalf("expected vm got (%s)", updated.Widgets[0].Settings.GraphUUID)
+		}
+		if updated.Widgets[1].Settings.GraphUUID != "new" {
+			t.Fatalf("expected new got (%s)", updated.Widgets[1].Settings.GraphUUID)
+		}
+		if len(client.UpdateDashboardCalls()) != 1 {
+			t.Fatal("expected 1 update call")
+		}
+	}
+}
+
+func TestRegisterInstances(t *testing.T) {
+	t.Log("Testing Register (instances)")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-dashboards-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "template-dashboard-test.toml"))
+	if err != nil {
+		t.Fatalf("reading template (%s)", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "template-dashboard-test.toml"), data, 0644); err != nil {
+		t.Fatalf("writing template (%s)", err)
+	}
+	for _, inst := range []string{"db1", "db10"} {
+		if err := dashboard.SaveInstance(dir, "dashboard-test", inst, map[string]string{"Database": inst}); err != nil {
+			t.Fatalf("saving instance (%s)", err)
+		}
+	}
+
+	client := genMockCircAPI().(*CircAPIMock)
+	client.CreateDashboardFunc = func(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+		cfg.CID = "/dashboard/1"
+		return cfg, nil
+	}
+
+	opts := &Options{
+		Client: client,
+		Config: &options.Options{
+			Host: options.Host{Name: "foo"},
+		},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		GraphInfo: &map[string]graphs.GraphInfo{"graph-test": {CID: "/graphs/abcd-efgh-0123-4567", UUID: "abcd-efgh-0123-4567"}},
+		Metrics:   &agentapi.Metrics{"test": {}},
+	}
+
+	d, err := New(opts)
+	if err != nil {
+		t.Fatalf("unable to create dashboards object (%s)", err)
+	}
+	if err := d.Register(map[string]bool{"dashboard-test": true}); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	for _, inst := range []string{"db1", "db10"} {
+		var dash circapi.Dashboard
+		found, err := regfiles.Load(filepath.Join(dir, "registration-"+dashboard.InstanceID("dashboard-test", inst)+".json"), &dash)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !found {
+			t.Fatalf("expected %s registration", inst)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "registration-dashboard-test-ignore.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no template (non-instance) registration (%v)", err)
+	}
+
+	t.Log("checkForRegistration (instance)")
+	{
+		d, err := New(opts)
+		if err != nil {
+			t.Fatalf("unable to create dashboards object (%s)", err)
+		}
+		found, err := d.checkForRegistration(dashboard.InstanceID("dashboard-test", "db1"))
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !found {
+			t.Fatal("expected db1 registration to be found")
+		}
+		found, err = d.checkForRegistration("dashboard-test")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if found {
+			t.Fatal("expected instance registrations to be ignored for template")
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboards
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// applyOverrides applies the system dashboard title override from the
+// registration options config. The title is expanded as a template using
+// the same variables as the dashboard template (e.g. {{.HostName}}).
+func (d *Dashboards) applyOverrides(dash *circapi.Dashboard, templateVars interface{}) error {
+	if dash == nil {
+		return errors.New("invalid dashboard (nil)")
+	}
+
+	if d.config.Dashboards.System.Title != "" {
+		tmpl, err := templates.Parse("dashboards.system.title", d.config.Dashboards.System.Title)
+		if err != nil {
+			return errors.Wrap(err, "parsing regconf title override")
+		}
+		var b bytes.Buffer
+		bw := bufio.NewWriter(&b)
+		if err := tmpl.Execute(bw, templateVars); err != nil {
+			return errors.Wrap(err, "executing regconf title override")
+		}
+		bw.Flush()
+		dash.Title = b.String()
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboards
+
+import (
+	"testing"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	t.Log("Testing applyOverrides")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tvars := map[string]interface{}{"HostName": "foo"}
+
+	tests := []struct {
+		name        string
+		title       string
+		dash        *circapi.Dashboard
+		expected    string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid dashboard (nil)", "", nil, "", true, "invalid dashboard (nil)"},
+		{"no override", "", &circapi.Dashboard{Title: "orig"}, "orig", false, ""},
+		{"title", "{{.HostName}} overview", &circapi.Dashboard{Title: "orig"}, "foo overview", false, ""},
+		{"bad title var", "{{.BadName}}", &circapi.Dashboard{Title: "orig"}, "", true, `executing regconf title override: template: dashboards.system.title:1:2: executing "dashboards.system.title" at <.BadName>: map has no entry for key "BadName"`},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			d, err := New(&Options{
+				Client:    genMockCircAPI(),
+				Config:    &options.Options{Dashboards: options.Dashboards{System: options.SystemDashboard{Title: tst.title}}},
+				RegDir:    "testdata",
+				Templates: &templates.Templates{},
+				CheckInfo: &checks.CheckInfo{CheckID: 1234},
+				GraphInfo: &map[string]graphs.GraphInfo{},
+				Metrics:   &agentapi.Metrics{"test": {}},
+			})
+			if err != nil {
+				t.Fatalf("unable to create dashboards object (%s)", err)
+			}
+			err = d.applyOverrides(tst.dash, tvars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if tst.dash.Title != tst.expected {
+				t.Fatalf("expected title (%s) got (%s)", tst.expected, tst.dash.Title)
+			}
+		})
+	}
+}
@@ -6,12 +6,10 @@
 package dashboards
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"text/template"
 
+	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -28,28 +26,20 @@ func parseDashboardTemplate(dashID, templateCfg string, templateVars interface{}
 		return nil, errors.New("invalid template vars (nil)")
 	}
 
-	// parse template
-	tmpl, err := template.New(dashID).Parse(templateCfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	tmpl = tmpl.Option("missingkey=error")
 	// expand template w/data
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-	if err := tmpl.Execute(bw, templateVars); err != nil {
+	data, err := templates.Expand(dashID, templateCfg, templateVars)
+	if err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", templateCfg, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create config
 	var dash circapi.Dashboard
-	if err := json.Unmarshal(b.Bytes(), &dash); err != nil {
+	if err := json.Unmarshal(data, &dash); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing expanded template result")
 	}
@@ -68,28 +58,20 @@ func parseWidgetTemplate(dashID, templateCfg string, templateVars interface{}) (
 		return nil, errors.New("invalid template vars (nil)")
 	}
 
-	// parse template
-	tmpl, err := template.New(dashID).Parse(templateCfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	tmpl = tmpl.Option("missingkey=error")
 	// expand template w/data
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-	if err := tmpl.Execute(bw, templateVars); err != nil {
+	data, err := templates.Expand(dashID, templateCfg, templateVars)
+	if err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", templateCfg, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create config
 	var widget circapi.DashboardWidget
-	if err := json.Unmarshal(b.Bytes(), &widget); err != nil {
+	if err := json.Unmarshal(data, &widget); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing expanded template result")
 	}
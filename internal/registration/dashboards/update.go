@@ -0,0 +1,72 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboards
+
+import (
+	"path"
+
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// updateDashboard reconciles an existing dashboard with the configuration
+// rendered from the current template. Dashboards modified since registration
+// (last modified differs, e.g. edited in the UI) are left as-is to preserve
+// the user's changes.
+func (d *Dashboards) updateDashboard(id string, reg, cfg *circapi.Dashboard) error {
+	if id == "" {
+		return errors.New("invalid id (empty)")
+	}
+	if reg == nil {
+		return errors.New("invalid registration (nil)")
+	}
+	if cfg == nil {
+		return errors.New("invalid dashboard config (nil)")
+	}
+
+	current, err := dashboard.FetchByID(d.client, reg.CID)
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s", id)
+	}
+
+	if current.LastModified != reg.LastModified {
+		d.logger.Warn().Str("id", id).Uint("registered", reg.LastModified).Uint("current", current.LastModified).Msg("dashboard modified since registration, preserving user edits")
+		d.dashList[id] = current
+		return nil
+	}
+
+	changes, err := regfiles.DiffConfig(current, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "comparing %s template", id)
+	}
+	if len(changes) == 0 {
+		d.logger.Info().Str("id", id).Msg("dashboard up to date")
+		d.dashList[id] = current
+		return nil
+	}
+
+	d.logger.Info().Str("id", id).Interface("changes", changes).Msg("updating dashboard")
+
+	cfg.CID = reg.CID
+	cfgFile := path.Join(d.regDir, "config-"+id+".json")
+	if err := regfiles.Save(cfgFile, cfg, true); err != nil {
+		return errors.Wrapf(err, "saving config (%s)", cfgFile)
+	}
+	regFile := regfiles.File(d.regDir, id)
+	if err := dashboard.Update(d.client, cfgFile, regFile, true); err != nil {
+		return errors.Wrapf(err, "updating %s", id)
+	}
+
+	var updated circapi.Dashboard
+	if _, err := regfiles.Load(regFile, &updated); err != nil {
+		return errors.Wrapf(err, "loading %s", id)
+	}
+	d.dashList[id] = &updated
+
+	return nil
+}
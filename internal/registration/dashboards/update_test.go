@@ -0,0 +1,170 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestUpdateDashboard(t *testing.T) {
+	t.Log("Testing updateDashboard")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-dashboards-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var remote circapi.Dashboard
+	client := &CircAPIMock{
+		FetchDashboardFunc: func(c circapi.CIDType) (*circapi.Dashboard, error) {
+			db := remote
+			return &db, nil
+		},
+		UpdateDashboardFunc: func(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+			db := *cfg
+			db.LastModified = 2
+			return &db, nil
+		},
+	}
+
+	d, err := New(&Options{
+		Client:    client,
+		Config:    &options.Options{},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		GraphInfo: &map[string]graphs.GraphInfo{},
+		Metrics:   &agentapi.Metrics{"test": {}},
+		Update:    true,
+	})
+	if err != nil {
+		t.Fatalf("unable to create dashboards object (%s)", err)
+	}
+
+	{
+		t.Log("invalid (nil registration)")
+		err := d.updateDashboard("dashboard-test", nil, &circapi.Dashboard{})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid registration (nil)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	reg := circapi.Dashboard{CID: "/dashboard/123", LastModified: 1, Title: "foo"}
+
+	{
+		t.Log("up to date")
+		remote = reg
+		if err := d.updateDashboard("dashboard-test", &reg, &circapi.Dashboard{Title: "foo"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateDashboardCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("user edits preserved (last modified)")
+		remote = circapi.Dashboard{CID: "/dashboard/123", LastModified: 5, Title: "foo"}
+		if err := d.updateDashboard("dashboard-test", &reg, &circapi.Dashboard{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateDashboardCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("up to date (server defaults)")
+		served := circapi.Dashboard{
+			CID:          "/dashboard/123",
+			LastModified: 1,
+			Title:        "foo",
+			Active:       true,
+			CreatedBy:    "/user/1",
+			UUID:         "abcdef01-2345-6789-abcd-ef0123456789",
+			GridLayout:   circapi.DashboardGridLayout{Height: 4, Width: 4},
+			Options: circapi.DashboardOptions{
+				FullscreenHideTitle: false,
+				HideGrid:            false,
+				ScaleText:           true,
+				TextSize:            16,
+			},
+			Widgets: []circapi.DashboardWidget{{
+				Active:   true,
+				Height:   1,
+				Name:     "Graph",
+				Origin:   "a0",
+				Type:     "graph",
+				WidgetID: "w1",
+				Width:    1,
+				Settings: circapi.DashboardWidgetSettings{
+					GraphUUID: "01234567-89ab-cdef-0123-456789abcdef",
+					Label:     "cpu",
+					Period:    2000,
+				},
+			}},
+		}
+		remote = served
+		cfg := &circapi.Dashboard{
+			Title:      "foo",
+			GridLayout: circapi.DashboardGridLayout{Height: 4, Width: 4},
+			Widgets: []circapi.DashboardWidget{{
+				Active:   true,
+				Height:   1,
+				Name:     "Graph",
+				Origin:   "a0",
+				Type:     "graph",
+				WidgetID: "w1",
+				Width:    1,
+				Settings: circapi.DashboardWidgetSettings{
+					GraphUUID: "01234567-89ab-cdef-0123-456789abcdef",
+					Label:     "cpu",
+				},
+			}},
+		}
+		if err := d.updateDashboard("dashboard-test", &served, cfg); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateDashboardCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("template changed")
+		remote = reg
+		if err := d.updateDashboard("dashboard-test", &reg, &circapi.Dashboard{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateDashboardCalls()) != 1 {
+			t.Fatal("expected update")
+		}
+		var saved circapi.Dashboard
+		if _, err := regfiles.Load(filepath.Join(dir, "registration-dashboard-test.json"), &saved); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if saved.Title != "bar" || saved.LastModified != 2 {
+			t.Fatalf("unexpected registration (%#v)", saved)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	// dryRunStdout is the --dry-run destination to write payloads to stdout
+	dryRunStdout = "-"
+
+	// dryRunBrokerCID is used for checks when no broker is explicitly
+	// configured, broker selection requires the Circonus API
+	dryRunBrokerCID = "/broker/0"
+)
+
+// dryRunAPI is a no-op implementation of CircAPI. Create and update calls
+// write the would-be payload to a directory (or stdout) and return the
+// object with fake CIDs so that later registration steps (e.g. dashboards
+// referencing graph uuids) can still be rendered.
+type dryRunAPI struct {
+	dir string    // payload output directory
+	out io.Writer // payload output when not using a directory
+	seq uint
+	sync.Mutex
+}
+
+// newDryRun creates a no-op api for a dry run. The destination is either a
+// directory or "-" for stdout. Returns the api and the directory to use
+// as the registration directory.
+func newDryRun(dest string, out io.Writer) (*dryRunAPI, string, error) {
+	if dest == "" {
+		return nil, "", errors.New("invalid dry run destination (empty)")
+	}
+
+	if dest == dryRunStdout {
+		if out == nil {
+			return nil, "", errors.New("invalid dry run output (nil)")
+		}
+		regDir, err := ioutil.TempDir("", "cosi-dry-run")
+		if err != nil {
+			return nil, "", errors.Wrap(err, "creating dry run registration directory")
+		}
+		return &dryRunAPI{out: out}, regDir, nil
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, "", errors.Wrap(err, "creating dry run directory")
+	}
+	regs, err := filepath.Glob(filepath.Join(dest, "registration-*"))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "checking dry run directory")
+	}
+	if len(regs) > 0 {
+		return nil, "", errors.Errorf("dry run directory (%s) contains registration files, use an empty directory", dest)
+	}
+
+	return &dryRunAPI{dir: dest}, dest, nil
+}
+
+// write outputs the payload for an api call
+func (dr *dryRunAPI) write(action, kind, cid string, payload interface{}) error {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "dry run, encoding %s payload", kind)
+	}
+
+	if dr.dir == "" {
+		_, err = fmt.Fprintf(dr.out, "# %s %s %s\n%s\n", action, kind, cid, string(data))
+		return err
+	}
+
+	fn := filepath.Join(dr.dir, fmt.Sprintf("payload-%03d-%s-%s.json", dr.seq, action, kind))
+	if err := ioutil.WriteFile(fn, append(data, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "dry run, writing %s payload", kind)
+	}
+	return nil
+}
+
+// next returns the next sequence number, used to generate fake ids
+func (dr *dryRunAPI) next() uint {
+	dr.seq++
+	return dr.seq
+}
+
+func fakeUUID(seq uint) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", seq)
+}
+
+// CreateCheckBundle writes the check bundle payload and returns it with fake ids
+func (dr *dryRunAPI) CreateCheckBundle(cfg *circapi.CheckBundle) (*circapi.CheckBundle, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid check bundle config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	seq := dr.next()
+	if err := dr.write("create", "check_bundle", "", cfg); err != nil {
+		return nil, err
+	}
+	b := *cfg
+	b.CID = fmt.Sprintf("/check_bundle/%d", seq)
+	b.Checks = []string{fmt.Sprintf("/check/%d", seq)}
+	b.CheckUUIDs = []string{fakeUUID(seq)}
+	b.Status = "active"
+	return &b, nil
+}
+
+// CreateDashboard writes the dashboard payload and returns it with fake ids
+func (dr *dryRunAPI) CreateDashboard(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid dashboard config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	seq := dr.next()
+	if err := dr.write("create", "dashboard", "", cfg); err != nil {
+		return nil, err
+	}
+	d := *cfg
+	d.CID = fmt.Sprintf("/dashboard/%d", seq)
+	d.UUID = fakeUUID(seq)
+	return &d, nil
+}
+
+// CreateGraph writes the graph payload and returns it with fake ids
+func (dr *dryRunAPI) CreateGraph(cfg *circapi.Graph) (*circapi.Graph, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid graph config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	seq := dr.next()
+	if err := dr.write("create", "graph", "", cfg); err != nil {
+		return nil, err
+	}
+	g := *cfg
+	g.CID = "/graph/" + fakeUUID(seq)
+	return &g, nil
+}
+
+// CreateRuleSet writes the ruleset payload and returns it with fake ids
+func (dr *dryRunAPI) CreateRuleSet(cfg *circapi.RuleSet) (*circapi.RuleSet, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid rule set config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	seq := dr.next()
+	if err := dr.write("create", "rule_set", "", cfg); err != nil {
+		return nil, err
+	}
+	rs := *cfg
+	rs.CID = fmt.Sprintf("/rule_set/%d_%s", seq, cfg.MetricName)
+	return &rs, nil
+}
+
+// CreateWorksheet writes the worksheet payload and returns it with fake ids
+func (dr *dryRunAPI) CreateWorksheet(cfg *circapi.Worksheet) (*circapi.Worksheet, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid worksheet config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	seq := dr.next()
+	if err := dr.write("create", "worksheet", "", cfg); err != nil {
+		return nil, err
+	}
+	w := *cfg
+	w.CID = "/worksheet/" + fakeUUID(seq)
+	return &w, nil
+}
+
+// DeleteCheckBundleByCID is a no-op
+func (dr *dryRunAPI) DeleteCheckBundleByCID(cid circapi.CIDType) (bool, error) {
+	return true, nil
+}
+
+// DeleteDashboardByCID is a no-op
+func (dr *dryRunAPI) DeleteDashboardByCID(cid circapi.CIDType) (bool, error) {
+	return true, nil
+}
+
+// DeleteGraphByCID is a no-op
+func (dr *dryRunAPI) DeleteGraphByCID(cid circapi.CIDType) (bool, error) {
+	return true, nil
+}
+
+// DeleteRuleSetByCID is a no-op
+func (dr *dryRunAPI) DeleteRuleSetByCID(cid circapi.CIDType) (bool, error) {
+	return true, nil
+}
+
+// DeleteWorksheetByCID is a no-op
+func (dr *dryRunAPI) DeleteWorksheetByCID(cid circapi.CIDType) (bool, error) {
+	return true, nil
+}
+
+// FetchCheckBundle is not available in a dry run
+func (dr *dryRunAPI) FetchCheckBundle(cid circapi.CIDType) (*circapi.CheckBundle, error) {
+	return nil, dryRunFetchErr("check bundle", cid)
+}
+
+// FetchBroker is not available in a dry run
+func (dr *dryRunAPI) FetchBroker(cid circapi.CIDType) (*circapi.Broker, error) {
+	return nil, dryRunFetchErr("broker", cid)
+}
+
+// FetchBrokers returns an empty list in a dry run
+func (dr *dryRunAPI) FetchBrokers() (*[]circapi.Broker, error) {
+	return &[]circapi.Broker{}, nil
+}
+
+// FetchDashboard is not available in a dry run
+func (dr *dryRunAPI) FetchDashboard(cid circapi.CIDType) (*circapi.Dashboard, error) {
+	return nil, dryRunFetchErr("dashboard", cid)
+}
+
+// FetchGraph is not available in a dry run
+func (dr *dryRunAPI) FetchGraph(cid circapi.CIDType) (*circapi.Graph, error) {
+	return nil, dryRunFetchErr("graph", cid)
+}
+
+// FetchWorksheet is not available in a dry run
+func (dr *dryRunAPI) FetchWorksheet(cid circapi.CIDType) (*circapi.Worksheet, error) {
+	return nil, dryRunFetchErr("worksheet", cid)
+}
+
+// SearchCheckBundles returns an empty list in a dry run
+func (dr *dryRunAPI) SearchCheckBundles(searchCriteria *circapi.SearchQueryType, filterCriteria *circapi.SearchFilterType) (*[]circapi.CheckBundle, error) {
+	return &[]circapi.CheckBundle{}, nil
+}
+
+// SearchDashboards returns an empty list in a dry run
+func (dr *dryRunAPI) SearchDashboards(searchCriteria *circapi.SearchQueryType, filterCriteria *circapi.SearchFilterType) (*[]circapi.Dashboard, error) {
+	return &[]circapi.Dashboard{}, nil
+}
+
+// SearchGraphs returns an empty list in a dry run
+func (dr *dryRunAPI) SearchGraphs(searchCriteria *circapi.SearchQueryType, filterCriteria *circapi.SearchFilterType) (*[]circapi.Graph, error) {
+	return &[]circapi.Graph{}, nil
+}
+
+// SearchWorksheets returns an empty list in a dry run
+func (dr *dryRunAPI) SearchWorksheets(searchCriteria *circapi.SearchQueryType, filterCriteria *circapi.SearchFilterType) (*[]circapi.Worksheet, error) {
+	return &[]circapi.Worksheet{}, nil
+}
+
+// UpdateCheckBundle writes the check bundle payload and returns it
+func (dr *dryRunAPI) UpdateCheckBundle(cfg *circapi.CheckBundle) (*circapi.CheckBundle, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid check bundle config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	dr.next()
+	if err := dr.write("update", "check_bundle", cfg.CID, cfg); err != nil {
+		return nil, err
+	}
+	b := *cfg
+	return &b, nil
+}
+
+// UpdateDashboard writes the dashboard payload and returns it
+func (dr *dryRunAPI) UpdateDashboard(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid dashboard config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	dr.next()
+	if err := dr.write("update", "dashboard", cfg.CID, cfg); err != nil {
+		return nil, err
+	}
+	d := *cfg
+	return &d, nil
+}
+
+// UpdateGraph writes the graph payload and returns it
+func (dr *dryRunAPI) UpdateGraph(cfg *circapi.Graph) (*circapi.Graph, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid graph config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	dr.next()
+	if err := dr.write("update", "graph", cfg.CID, cfg); err != nil {
+		return nil, err
+	}
+	g := *cfg
+	return &g, nil
+}
+
+// UpdateWorksheet writes the worksheet payload and returns it
+func (dr *dryRunAPI) UpdateWorksheet(cfg *circapi.Worksheet) (*circapi.Worksheet, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid worksheet config (nil)")
+	}
+	dr.Lock()
+	defer dr.Unlock()
+	dr.next()
+	if err := dr.write("update", "worksheet", cfg.CID, cfg); err != nil {
+		return nil, err
+	}
+	w := *cfg
+	return &w, nil
+}
+
+func dryRunFetchErr(kind string, cid circapi.CIDType) error {
+	id := ""
+	if cid != nil {
+		id = *cid
+	}
+	return errors.Errorf("dry run, unable to fetch %s (%s) - no Circonus API access", kind, id)
+}
@@ -0,0 +1,161 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestNewDryRun(t *testing.T) {
+	t.Log("Testing newDryRun")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-dry-run-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	regDir := filepath.Join(dir, "existing")
+	if err := os.MkdirAll(regDir, 0755); err != nil {
+		t.Fatalf("creating dir (%s)", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(regDir, "registration-check-system.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("creating reg file (%s)", err)
+	}
+
+	tests := []struct {
+		name        string
+		dest        string
+		out         *bytes.Buffer
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid dest (empty)", "", nil, true, "invalid dry run destination (empty)"},
+		{"invalid stdout (nil)", "-", nil, true, "invalid dry run output (nil)"},
+		{"existing registrations", regDir, nil, true, "dry run directory (" + regDir + ") contains registration files, use an empty directory"},
+		{"valid stdout", "-", &bytes.Buffer{}, false, ""},
+		{"valid dir", filepath.Join(dir, "new"), nil, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			var dr *dryRunAPI
+			var rd string
+			var err error
+			if tst.out != nil {
+				dr, rd, err = newDryRun(tst.dest, tst.out)
+			} else {
+				dr, rd, err = newDryRun(tst.dest, nil)
+			}
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if dr == nil {
+				t.Fatal("expected dry run api")
+			}
+			if tst.dest == dryRunStdout {
+				defer os.RemoveAll(rd)
+			} else if rd != tst.dest {
+				t.Fatalf("expected reg dir (%s) got (%s)", tst.dest, rd)
+			}
+		})
+	}
+}
+
+func TestDryRunAPI(t *testing.T) {
+	t.Log("Testing dryRunAPI")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-dry-run-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dr, _, err := newDryRun(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	b, err := dr.CreateCheckBundle(&circapi.CheckBundle{DisplayName: "foo"})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if b.CID != "/check_bundle/1" || len(b.Checks) != 1 || len(b.CheckUUIDs) != 1 || b.Status != "active" {
+		t.Fatalf("unexpected check bundle (%#v)", b)
+	}
+
+	g, err := dr.CreateGraph(&circapi.Graph{Title: "foo"})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if g.CID != "/graph/"+fakeUUID(2) {
+		t.Fatalf("unexpected graph cid (%s)", g.CID)
+	}
+
+	if _, err := dr.UpdateCheckBundle(b); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if _, err := dr.CreateDashboard(nil); err == nil {
+		t.Fatal("expected error")
+	} else if err.Error() != "invalid dashboard config (nil)" {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	cid := "/graph/foo"
+	if _, err := dr.FetchGraph(circapi.CIDType(&cid)); err == nil {
+		t.Fatal("expected error")
+	} else if err.Error() != "dry run, unable to fetch graph (/graph/foo) - no Circonus API access" {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "payload-*"))
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	expected := []string{"payload-001-create-check_bundle.json", "payload-002-create-graph.json", "payload-003-update-check_bundle.json"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d payload files got %v", len(expected), files)
+	}
+	for i, f := range files {
+		if filepath.Base(f) != expected[i] {
+			t.Fatalf("expected (%s) got (%s)", expected[i], filepath.Base(f))
+		}
+	}
+
+	var out bytes.Buffer
+	drs, rd, err := newDryRun(dryRunStdout, &out)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	defer os.RemoveAll(rd)
+	if _, err := drs.CreateWorksheet(&circapi.Worksheet{Title: "foo"}); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if !strings.HasPrefix(out.String(), "# create worksheet") || !strings.Contains(out.String(), `"title": "foo"`) {
+		t.Fatalf("unexpected output (%s)", out.String())
+	}
+}
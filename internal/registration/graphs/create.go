@@ -9,6 +9,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
 	"github.com/circonus-labs/cosi-tool/internal/graph"
 	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	circapi "github.com/circonus-labs/go-apiclient"
@@ -63,17 +64,7 @@ func (g *Graphs) create(id string) error {
 }
 
 func (g *Graphs) createGraph(graphID string, cfg *circapi.Graph) error {
-	// map short metric names to full agent metric names with dynamic stream tags
-	for dpIdx, dp := range cfg.Datapoints {
-		if fullMetricName, ok := g.shortMetricNames[dp.MetricName]; ok {
-			// use the short metric name for display (otherwise the graph legend displays metric names with base64 encoded stream tags)
-			if dp.Name == "" && dp.MetricName != fullMetricName {
-				cfg.Datapoints[dpIdx].Name = dp.MetricName
-			}
-			g.logger.Debug().Str("graph_id", graphID).Str("graph_metric_name", dp.MetricName).Str("full_name", fullMetricName).Msg("metric mapped")
-			cfg.Datapoints[dpIdx].MetricName = fullMetricName
-		}
-	}
+	g.mapMetricNames(graphID, cfg)
 
 	if e := log.Debug(); e.Enabled() {
 		cfgFile := path.Join(g.regDir, "config-"+graphID+".json")
@@ -91,9 +82,25 @@ func (g *Graphs) createGraph(graphID string, cfg *circapi.Graph) error {
 	return regfiles.Save(path.Join(g.regDir, "registration-"+graphID+".json"), graph, true)
 }
 
+// mapMetricNames maps short metric names to full agent metric names with dynamic stream tags
+func (g *Graphs) mapMetricNames(graphID string, cfg *circapi.Graph) {
+	for dpIdx, dp := range cfg.Datapoints {
+		if fullMetricName, ok := g.shortMetricNames[dp.MetricName]; ok {
+			// use the short metric name for display (otherwise the graph legend displays metric names with base64 encoded stream tags)
+			if dp.Name == "" && dp.MetricName != fullMetricName {
+				cfg.Datapoints[dpIdx].Name = dp.MetricName
+			}
+			g.logger.Debug().Str("graph_id", graphID).Str("graph_metric_name", dp.MetricName).Str("full_name", fullMetricName).Msg("metric mapped")
+			cfg.Datapoints[dpIdx].MetricName = fullMetricName
+		}
+	}
+}
+
 // checkForRegistration looks through existing registration files and
-// if the graphID is found, it is loaded. Returns a boolean indicating
-// if the registration was found+loaded successfully or an error
+// if the graphID is found, it is loaded and verified to still exist via
+// the API. Returns a boolean indicating if the registration was
+// found+loaded successfully or an error. A registration for a graph which
+// no longer exists is ignored so that the graph will be recreated.
 func (g *Graphs) checkForRegistration(graphID string) (bool, error) {
 	// logger := log.With().Str("cmd", "register.graphs").Logger()
 	if graphID == "" {
@@ -111,6 +118,13 @@ func (g *Graphs) checkForRegistration(graphID string) (bool, error) {
 			return false, errors.Wrapf(err, "loading %s", regFileSig)
 		}
 		if found {
+			exists, err := g.verifyRegistration(graphID, &graph)
+			if err != nil {
+				return false, err
+			}
+			if !exists {
+				return false, nil
+			}
 			g.graphList[graphID] = graph
 			return found, nil
 		}
@@ -119,3 +133,22 @@ func (g *Graphs) checkForRegistration(graphID string) (bool, error) {
 
 	return false, nil
 }
+
+// verifyRegistration confirms a registered graph still exists via the API.
+// If it does not (e.g. deleted in the UI) the graph cid is recorded so that
+// dependents (dashboards) can be re-linked to the recreated graph.
+func (g *Graphs) verifyRegistration(graphID string, reg *circapi.Graph) (bool, error) {
+	if reg.CID == "" {
+		return false, errors.Errorf("invalid registration for %s (empty cid)", graphID)
+	}
+	cid := reg.CID
+	if _, err := g.client.FetchGraph(circapi.CIDType(&cid)); err != nil {
+		if apierr.IsNotFound(err) {
+			g.logger.Warn().Str("id", graphID).Str("cid", cid).Msg("registered graph no longer exists, recreating")
+			g.replaced[graphID] = cid
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "verifying %s registration (%s)", graphID, cid)
+	}
+	return true, nil
+}
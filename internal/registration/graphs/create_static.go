@@ -7,7 +7,6 @@ package graphs
 
 import (
 	"fmt"
-	"runtime"
 
 	cosiapi "github.com/circonus-labs/cosi-server/api"
 	"github.com/pkg/errors"
@@ -40,18 +39,12 @@ func (g *Graphs) createStaticGraph(templateID, graphName string, cfg *cosiapi.Te
 	}
 	if loaded {
 		g.logger.Info().Str("id", graphID).Msg("registration found and loaded")
-		return nil
+		if !g.update {
+			return nil
+		}
 	}
 
-	gtvars := struct {
-		HostName string
-		CheckID  uint
-		NumCPU   int
-	}{
-		g.config.Host.Name,
-		g.checkInfo.CheckID,
-		runtime.NumCPU(),
-	}
+	gtvars := g.templateVars()
 	// 2. build base graph config
 	graph, err := parseGraphTemplate(graphID, cfg.Template, gtvars)
 	if err != nil {
@@ -82,21 +75,10 @@ func (g *Graphs) createStaticGraph(templateID, graphName string, cfg *cosiapi.Te
 			if len(metrics) > 1 {
 				return errors.Errorf("invalid variable datapoint %s-%s:%d regex (matched>1 metrics)", graphID, graphName, dpIdx)
 			}
-			dtvars := struct {
-				HostName   string
-				CheckID    uint
-				NumCPU     int
-				Item       string
-				ItemIndex  int
-				MetricName string
-			}{
-				g.config.Host.Name,
-				g.checkInfo.CheckID,
-				runtime.NumCPU(),
-				item,
-				dpIdx,
-				metrics[0].metric,
-			}
+			dtvars := g.templateVars()
+			dtvars["Item"] = item
+			dtvars["ItemIndex"] = dpIdx
+			dtvars["MetricName"] = metrics[0].metric
 			dp, err := parseDatapointTemplate(fmt.Sprintf("%s-%d", graphID, dpIdx), dpConfig.Template, dtvars)
 			if err != nil {
 				return err
@@ -114,6 +96,14 @@ func (g *Graphs) createStaticGraph(templateID, graphName string, cfg *cosiapi.Te
 		graph.Tags = append(graph.Tags, g.config.Common.Tags...)
 	}
 
-	// 3. create graph
+	// 2b. apply any regconf overrides
+	if err := g.applyOverrides(templateID, graphName, graph, gtvars); err != nil {
+		return err
+	}
+
+	// 3. create graph (or update existing)
+	if loaded {
+		return g.updateGraph(graphID, graph)
+	}
 	return g.createGraph(graphID, graph)
 }
@@ -102,11 +102,11 @@ func TestCreateStaticGraph(t *testing.T) {
 		{"reg exists (parse err)", "graph-test", "error", &cosiapi.TemplateConfig{}, &globalFilters{}, true, "loading registration-graph-test-error: parsing registration (testdata/registration-graph-test-error.json): unexpected end of JSON input"},
 		{"reg exists", "graph-test", "valid", &cosiapi.TemplateConfig{}, &globalFilters{}, false, ""},
 		{"empty template", "graph-test", "bad", &cosiapi.TemplateConfig{}, &globalFilters{}, true, "parsing graph template: invalid template config (empty)"},
-		{"static template (bad template var)", "graph-test", "bad_dp_var", &badDPVar, &globalFilters{}, true, `executing template: template: graph-test-bad_dp_var-0:1:18: executing "graph-test-bad_dp_var-0" at <.BadName>: can't evaluate field BadName in type struct { HostName string; CheckID uint; NumCPU int }`},
+		{"static template (bad template var)", "graph-test", "bad_dp_var", &badDPVar, &globalFilters{}, true, `executing template: template: graph-test-bad_dp_var-0:1:18: executing "graph-test-bad_dp_var-0" at <.BadName>: map has no entry for key "BadName"`},
 		{"static template", "graph-ignore-static", "ok_static", &okStatic, &globalFilters{}, false, ""},
 		{"static template w/ST", "graph-ignore-static", "ok_static_st", &okStaticST, &globalFilters{}, false, ""},
 		{"variable template (bad dp config)", "graph-test", "bad_dp_rx", &badVDPRx, &globalFilters{}, true, `invalid variable datapoint graph-test-bad_dp_rx-bad_dp_rx:0 regex (empty)`},
-		{"variable template (bad dp var)", "graph-test", "bad_dp_rx", &badVDPVar, &globalFilters{}, true, `executing template: template: graph-test-bad_dp_rx-0:1:18: executing "graph-test-bad_dp_rx-0" at <.BadName>: can't evaluate field BadName in type struct { HostName string; CheckID uint; NumCPU int; Item string; ItemIndex int; MetricName string }`},
+		{"variable template (bad dp var)", "graph-test", "bad_dp_rx", &badVDPVar, &globalFilters{}, true, `executing template: template: graph-test-bad_dp_rx-0:1:18: executing "graph-test-bad_dp_rx-0" at <.BadName>: map has no entry for key "BadName"`},
 		{"variable template (multimetric)", "graph-test", "bad_multi_metric", &badVDPMulti, &globalFilters{}, true, `invalid variable datapoint graph-test-bad_multi_metric-bad_multi_metric:0 regex (matched>1 metrics)`},
 		{"variable template", "graph-ignore-static", "ok_variable", &okVariable, &globalFilters{}, false, ""},
 		{"variable template w/ST", "graph-ignore-static", "ok_variable_st", &okVariableST, &globalFilters{}, false, ""},
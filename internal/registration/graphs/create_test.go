@@ -119,6 +119,8 @@ func TestCheckForRegistration(t *testing.T) {
 		{"missing", "graph-missing", false, false, ""},
 		{"error (parsing)", "graph-error", false, true, "loading registration-graph-error: parsing registration (testdata/registration-graph-error.json): unexpected end of JSON input"},
 		{"valid", "graph-valid", true, false, ""},
+		{"deleted (404)", "graph-deleted", false, false, ""},
+		{"verify error", "graph-fetch-error", false, true, "verifying graph-fetch-error registration (/graph/fetch-error): forced mock api error"},
 	}
 
 	for _, test := range tests {
@@ -148,4 +150,8 @@ func TestCheckForRegistration(t *testing.T) {
 			}
 		})
 	}
+
+	if prev, ok := g.replaced["graph-deleted"]; !ok || prev != "/graph/deleted" {
+		t.Fatalf("expected graph-deleted to be recorded as replaced (%v)", g.replaced)
+	}
 }
@@ -7,7 +7,6 @@ package graphs
 
 import (
 	"fmt"
-	"runtime"
 	"strings"
 
 	cosiapi "github.com/circonus-labs/cosi-server/api"
@@ -53,19 +52,12 @@ func (g *Graphs) createVariableGraphs(templateID, graphName string, cfg *cosiapi
 		}
 		if loaded {
 			g.logger.Info().Str("id", graphID).Msg("registration found and loaded")
-			continue
-		}
-		gtvars := struct {
-			HostName string
-			CheckID  uint
-			NumCPU   int
-			Item     string
-		}{
-			g.config.Host.Name,
-			g.checkInfo.CheckID,
-			runtime.NumCPU(),
-			item,
+			if !g.update {
+				continue
+			}
 		}
+		gtvars := g.templateVars()
+		gtvars["Item"] = item
 		// 3. build base graph config (based on the "item")
 		graph, err := parseGraphTemplate(graphID, cfg.Template, gtvars)
 		if err != nil {
@@ -95,21 +87,10 @@ func (g *Graphs) createVariableGraphs(templateID, graphName string, cfg *cosiapi
 			if metricName == "" {
 				return errors.Errorf("unable to find correct metric idx:%d metrics:%#v", dpIdx, metrics)
 			}
-			dtvars := struct {
-				HostName   string
-				CheckID    uint
-				NumCPU     int
-				Item       string
-				ItemIndex  int
-				MetricName string
-			}{
-				g.config.Host.Name,
-				g.checkInfo.CheckID,
-				runtime.NumCPU(),
-				item,
-				dpIdx,
-				metricName,
-			}
+			dtvars := g.templateVars()
+			dtvars["Item"] = item
+			dtvars["ItemIndex"] = dpIdx
+			dtvars["MetricName"] = metricName
 			dp, err := parseDatapointTemplate(fmt.Sprintf("%s-%d", graphID, dpIdx), dpConfig.Template, dtvars)
 			if err != nil {
 				return err
@@ -126,7 +107,18 @@ func (g *Graphs) createVariableGraphs(templateID, graphName string, cfg *cosiapi
 			graph.Tags = append(graph.Tags, g.config.Common.Tags...)
 		}
 
-		// 4. create graph
+		// 3b. apply any regconf overrides
+		if err := g.applyOverrides(templateID, graphName, graph, gtvars); err != nil {
+			return err
+		}
+
+		// 4. create graph (or update existing)
+		if loaded {
+			if err := g.updateGraph(graphID, graph); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := g.createGraph(graphID, graph); err != nil {
 			return err
 		}
@@ -7,6 +7,7 @@
 package graphs
 
 import (
+	"path"
 	"strings"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
@@ -31,6 +32,9 @@ type Graphs struct {
 	metrics          *agentapi.Metrics
 	shortMetricNames map[string]string // metric names w/o stream tags - value is full metric name (can be used as key into Graphs.metrics)
 	regFiles         *[]string
+	replaced         map[string]string // graph id -> cid of a registered graph which no longer exists (recreated)
+	update           bool
+	onComplete       func(id string)
 	logger           zerolog.Logger
 }
 
@@ -42,12 +46,18 @@ type Options struct {
 	Metrics   *agentapi.Metrics
 	RegDir    string
 	Templates *templates.Templates
+	// OnComplete is optional, called with the template id once all
+	// graphs for the template have been created
+	OnComplete func(id string)
+	// Update existing graphs which differ from the current template
+	Update bool
 }
 
 // GraphInfo holds details needed for dashboards
 type GraphInfo struct {
-	CID  string
-	UUID string
+	CID      string
+	UUID     string
+	PrevUUID string // uuid of the graph this one replaced, if it was recreated (e.g. deleted in UI)
 }
 
 // New creates a new Graphs instance
@@ -92,6 +102,9 @@ func New(o *Options) (*Graphs, error) {
 		metrics:          o.Metrics,
 		shortMetricNames: make(map[string]string),
 		regFiles:         regs,
+		replaced:         make(map[string]string),
+		onComplete:       o.OnComplete,
+		update:           o.Update,
 		logger:           log.With().Str("cmd", "register.graphs").Logger(),
 	}
 
@@ -109,6 +122,14 @@ func New(o *Options) (*Graphs, error) {
 	return &g, nil
 }
 
+// templateVars returns the variables for graph and datapoint templates,
+// the shared variables plus the system check
+func (g *Graphs) templateVars() map[string]interface{} {
+	tvars := g.config.TemplateVars()
+	g.checkInfo.AddTemplateVars(tvars)
+	return tvars
+}
+
 // Register creates graphs using the Circonus API
 func (g *Graphs) Register(list map[string]bool) error {
 	if len(list) == 0 {
@@ -132,6 +153,9 @@ func (g *Graphs) Register(list map[string]bool) error {
 		return nil
 	}
 
+	// apply regconf graphs.include and graphs.exclude
+	g.filterList(graphList)
+
 	for id, create := range graphList {
 		if !create {
 			g.logger.Warn().Str("id", id).Msg("Skipping, graph disabled")
@@ -141,11 +165,33 @@ func (g *Graphs) Register(list map[string]bool) error {
 		if err != nil {
 			return err
 		}
+		if g.onComplete != nil {
+			g.onComplete(id)
+		}
 	}
 
 	return nil
 }
 
+// LoadRegistrations loads all existing graph registrations, used when
+// graph creation is skipped (e.g. resuming a registration) so that graph
+// info and the metric list are complete
+func (g *Graphs) LoadRegistrations() error {
+	for _, rf := range *g.regFiles {
+		var graph circapi.Graph
+		found, err := regfiles.Load(path.Join(g.regDir, rf), &graph)
+		if err != nil {
+			return errors.Wrapf(err, "loading %s", rf)
+		}
+		if !found {
+			continue
+		}
+		graphID := strings.TrimSuffix(strings.TrimPrefix(rf, "registration-"), path.Ext(rf))
+		g.graphList[graphID] = graph
+	}
+	return nil
+}
+
 // GetGraphInfo returns a list of graphs and details on each graph to be used by dashbaords
 func (g *Graphs) GetGraphInfo() (*map[string]GraphInfo, error) {
 	if len(g.graphList) == 0 {
@@ -154,10 +200,14 @@ func (g *Graphs) GetGraphInfo() (*map[string]GraphInfo, error) {
 	gi := make(map[string]GraphInfo)
 	for gid, v := range g.graphList {
 		g.logger.Debug().Str("id", gid).Str("cid", v.CID).Msg("adding graph to graph info")
-		gi[gid] = GraphInfo{
+		info := GraphInfo{
 			CID:  v.CID,
 			UUID: strings.Replace(v.CID, "/graph/", "", 1),
 		}
+		if prev, ok := g.replaced[gid]; ok {
+			info.PrevUUID = strings.Replace(prev, "/graph/", "", 1)
+		}
+		gi[gid] = info
 	}
 	return &gi, nil
 }
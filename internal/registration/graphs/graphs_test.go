@@ -7,6 +7,10 @@ package graphs
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
@@ -23,8 +27,20 @@ func genMockCircAPI() CircAPI {
 			if cfg.CID == "error" {
 				return nil, errors.New("forced mock api error")
 			}
+			if cfg.CID == "" {
+				cfg.CID = "/graph/" + cfg.Title
+			}
 			return cfg, nil
 		},
+		FetchGraphFunc: func(cid circapi.CIDType) (*circapi.Graph, error) {
+			switch *cid {
+			case "/graph/deleted":
+				return nil, errors.New("API response code 404: {\"code\":404}")
+			case "/graph/fetch-error":
+				return nil, errors.New("forced mock api error")
+			}
+			return &circapi.Graph{CID: *cid}, nil
+		},
 	}
 }
 
@@ -88,3 +104,63 @@ func TestRegister(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadRegistrations(t *testing.T) {
+	t.Log("Testing LoadRegistrations")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-graphs-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "registration-graph-cpu.json"), []byte(`{"_cid": "/graph/abc"}`), 0644); err != nil {
+		t.Fatalf("writing registration (%s)", err)
+	}
+
+	g, err := New(&Options{
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		Client:    genMockCircAPI(),
+		Config:    &options.Options{},
+		Metrics:   &agentapi.Metrics{"test": {}},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+	})
+	if err != nil {
+		t.Fatalf("unable to create graphs object (%s)", err)
+	}
+
+	{
+		t.Log("valid")
+		if err := g.LoadRegistrations(); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		gi, err := g.GetGraphInfo()
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		info, ok := (*gi)["graph-cpu"]
+		if !ok {
+			t.Fatalf("expected graph-cpu in graph info (%v)", *gi)
+		}
+		if info.UUID != "abc" {
+			t.Fatalf("expected uuid abc got (%s)", info.UUID)
+		}
+	}
+
+	{
+		t.Log("invalid registration")
+		*g.regFiles = append(*g.regFiles, "registration-graph-bad.json")
+		if err := ioutil.WriteFile(filepath.Join(dir, "registration-graph-bad.json"), []byte(`{`), 0644); err != nil {
+			t.Fatalf("writing registration (%s)", err)
+		}
+		err := g.LoadRegistrations()
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.HasPrefix(err.Error(), "loading registration-graph-bad.json") {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package graphs
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// templateIDFromOption normalizes an include/exclude entry from the
+// registration options config. Entries may be either the template id
+// (e.g. graph-cpu) or the plugin name (e.g. cpu).
+func templateIDFromOption(entry string) string {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return ""
+	}
+	if strings.HasPrefix(entry, "graph-") {
+		return entry
+	}
+	return "graph-" + entry
+}
+
+// filterList applies the include and exclude lists from the registration
+// options config to the list of graph templates to create. If an include
+// list is configured, only templates in the include list are retained.
+// Any template in the exclude list is disabled.
+func (g *Graphs) filterList(graphList map[string]bool) {
+	if len(g.config.Graphs.Include) > 0 {
+		include := make(map[string]bool)
+		for _, entry := range g.config.Graphs.Include {
+			if id := templateIDFromOption(entry); id != "" {
+				include[id] = true
+			}
+		}
+		for id := range graphList {
+			if !include[id] {
+				g.logger.Info().Str("id", id).Msg("not in regconf graphs.include, disabling")
+				graphList[id] = false
+			}
+		}
+	}
+
+	for _, entry := range g.config.Graphs.Exclude {
+		id := templateIDFromOption(entry)
+		if id == "" {
+			continue
+		}
+		if _, ok := graphList[id]; ok {
+			g.logger.Info().Str("id", id).Msg("in regconf graphs.exclude, disabling")
+			graphList[id] = false
+		}
+	}
+}
+
+// applyOverrides applies any title and tag overrides from the registration
+// options config (graphs.configs.PLUGIN_NAME.CONFIG_NAME) to a graph. The
+// title override is expanded as a template using the same variables as the
+// graph template itself (e.g. to include {{.Item}} for variable graphs).
+func (g *Graphs) applyOverrides(templateID, graphName string, graph *circapi.Graph, templateVars interface{}) error {
+	if graph == nil {
+		return errors.New("invalid graph (nil)")
+	}
+	if len(g.config.Graphs.Configs) == 0 {
+		return nil
+	}
+
+	pluginName := strings.TrimPrefix(templateID, "graph-")
+	cfgs, ok := g.config.Graphs.Configs[pluginName]
+	if !ok {
+		return nil
+	}
+	override, ok := cfgs[graphName]
+	if !ok {
+		return nil
+	}
+
+	if override.Title != "" {
+		tmpl, err := templates.Parse(templateID+"-"+graphName+"-title", override.Title)
+		if err != nil {
+			return errors.Wrapf(err, "parsing regconf title override for %s.%s", pluginName, graphName)
+		}
+		var b bytes.Buffer
+		bw := bufio.NewWriter(&b)
+		if err := tmpl.Execute(bw, templateVars); err != nil {
+			return errors.Wrapf(err, "executing regconf title override for %s.%s", pluginName, graphName)
+		}
+		bw.Flush()
+		graph.Title = b.String()
+	}
+
+	if len(override.Tags) > 0 {
+		graph.Tags = append(graph.Tags, override.Tags...)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package graphs
+
+import (
+	"testing"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestFilterList(t *testing.T) {
+	t.Log("Testing filterList")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected map[string]bool
+	}{
+		{"no filters", nil, nil, map[string]bool{"graph-cpu": true, "graph-vm": true, "graph-disk": true}},
+		{"include (template id)", []string{"graph-cpu"}, nil, map[string]bool{"graph-cpu": true, "graph-vm": false, "graph-disk": false}},
+		{"include (plugin name)", []string{"cpu", "vm"}, nil, map[string]bool{"graph-cpu": true, "graph-vm": true, "graph-disk": false}},
+		{"exclude", nil, []string{"disk"}, map[string]bool{"graph-cpu": true, "graph-vm": true, "graph-disk": false}},
+		{"include+exclude", []string{"cpu", "graph-vm"}, []string{"graph-vm"}, map[string]bool{"graph-cpu": true, "graph-vm": false, "graph-disk": false}},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			g, err := New(&Options{
+				CheckInfo: &checks.CheckInfo{CheckID: 1234},
+				Client:    genMockCircAPI(),
+				Config: &options.Options{
+					Graphs: options.Graphs{Include: tst.include, Exclude: tst.exclude},
+				},
+				Metrics:   &agentapi.Metrics{"test": {}},
+				RegDir:    "testdata",
+				Templates: &templates.Templates{},
+			})
+			if err != nil {
+				t.Fatalf("unable to create graphs object (%s)", err)
+			}
+			list := map[string]bool{"graph-cpu": true, "graph-vm": true, "graph-disk": true}
+			g.filterList(list)
+			for id, create := range tst.expected {
+				if list[id] != create {
+					t.Fatalf("expected %s=%v, got %v", id, create, list[id])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	t.Log("Testing applyOverrides")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	g, err := New(&Options{
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		Client:    genMockCircAPI(),
+		Config: &options.Options{
+			Graphs: options.Graphs{
+				Configs: map[string]map[string]options.Graph{
+					"cpu": {
+						"utilization": {Title: "{{.HostName}} CPU", Tags: []string{"team:ops"}},
+						"bad_title":   {Title: "{{.BadName}}"},
+					},
+					"disk": {
+						"io": {Title: "{{.HostName}} {{.Item}} IO"},
+					},
+				},
+			},
+		},
+		Metrics:   &agentapi.Metrics{"test": {}},
+		RegDir:    "testdata",
+		Templates: &templates.Templates{},
+	})
+	if err != nil {
+		t.Fatalf("unable to create graphs object (%s)", err)
+	}
+
+	staticVars := struct{ HostName string }{"foo"}
+	itemVars := struct{ HostName, Item string }{"foo", "sda"}
+
+	tests := []struct {
+		name          string
+		templateID    string
+		graphName     string
+		graph         *circapi.Graph
+		tvars         interface{}
+		expectedTitle string
+		expectedTags  int
+		shouldFail    bool
+		expectedErr   string
+	}{
+		{"invalid graph (nil)", "graph-cpu", "utilization", nil, staticVars, "", 0, true, "invalid graph (nil)"},
+		{"no override (plugin)", "graph-vm", "memory", &circapi.Graph{Title: "orig"}, staticVars, "orig", 0, false, ""},
+		{"no override (graph)", "graph-cpu", "other", &circapi.Graph{Title: "orig"}, staticVars, "orig", 0, false, ""},
+		{"title and tags", "graph-cpu", "utilization", &circapi.Graph{Title: "orig", Tags: []string{"cosi:install"}}, staticVars, "foo CPU", 2, false, ""},
+		{"title w/item", "graph-disk", "io", &circapi.Graph{Title: "orig"}, itemVars, "foo sda IO", 0, false, ""},
+		{"bad title var", "graph-cpu", "bad_title", &circapi.Graph{Title: "orig"}, staticVars, "", 0, true, `executing regconf title override for cpu.bad_title: template: graph-cpu-bad_title-title:1:2: executing "graph-cpu-bad_title-title" at <.BadName>: can't evaluate field BadName in type struct { HostName string }`},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			err := g.applyOverrides(tst.templateID, tst.graphName, tst.graph, tst.tvars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if tst.graph.Title != tst.expectedTitle {
+				t.Fatalf("expected title (%s) got (%s)", tst.expectedTitle, tst.graph.Title)
+			}
+			if len(tst.graph.Tags) != tst.expectedTags {
+				t.Fatalf("expected %d tags got %v", tst.expectedTags, tst.graph.Tags)
+			}
+		})
+	}
+}
@@ -6,12 +6,10 @@
 package graphs
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 
+	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -28,28 +26,20 @@ func parseGraphTemplate(graphID, templateCfg string, templateVars interface{}) (
 		return nil, errors.New("invalid template vars (nil)")
 	}
 
-	// parse template
-	tmpl, err := template.New(graphID).Parse(templateCfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	tmpl = tmpl.Option("missingkey=error")
 	// expand template w/data
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-	if err := tmpl.Execute(bw, templateVars); err != nil {
+	data, err := templates.Expand(graphID, templateCfg, templateVars)
+	if err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", templateCfg, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create graph config
 	var graph circapi.Graph
-	if err := json.Unmarshal(b.Bytes(), &graph); err != nil {
+	if err := json.Unmarshal(data, &graph); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing expanded template result")
 	}
@@ -68,28 +58,20 @@ func parseDatapointTemplate(graphID, templateCfg string, templateVars interface{
 		return nil, errors.New("invalid template vars (nil)")
 	}
 
-	// parse template
-	tmpl, err := template.New(graphID).Parse(templateCfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	tmpl = tmpl.Option("missingkey=error")
 	// expand template w/data
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-	if err := tmpl.Execute(bw, templateVars); err != nil {
+	data, err := templates.Expand(graphID, templateCfg, templateVars)
+	if err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", templateCfg, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create graph datapoint config
 	var dp circapi.GraphDatapoint
-	if err := json.Unmarshal(b.Bytes(), &dp); err != nil {
+	if err := json.Unmarshal(data, &dp); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing expanded template result")
 	}
@@ -0,0 +1,79 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package graphs
+
+import (
+	"path"
+
+	"github.com/circonus-labs/cosi-tool/internal/graph"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// updateGraph reconciles an existing graph with the configuration rendered
+// from the current template. Graphs modified since registration (e.g. edited
+// in the UI) are left as-is to preserve the user's changes.
+func (g *Graphs) updateGraph(graphID string, cfg *circapi.Graph) error {
+	if graphID == "" {
+		return errors.New("invalid graph id (empty)")
+	}
+	if cfg == nil {
+		return errors.New("invalid graph config (nil)")
+	}
+
+	reg, ok := g.graphList[graphID]
+	if !ok {
+		return errors.Errorf("no registration loaded for %s", graphID)
+	}
+
+	g.mapMetricNames(graphID, cfg)
+
+	current, err := graph.FetchByID(g.client, reg.CID)
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s", graphID)
+	}
+
+	// the api graph object does not carry a last modified time,
+	// compare the current graph to the registration to detect edits
+	edits, err := regfiles.Diff(&reg, current)
+	if err != nil {
+		return errors.Wrapf(err, "comparing %s registration", graphID)
+	}
+	if len(edits) > 0 {
+		g.logger.Warn().Str("id", graphID).Interface("changes", edits).Msg("graph modified since registration, preserving user edits")
+		return nil
+	}
+
+	changes, err := regfiles.DiffConfig(current, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "comparing %s template", graphID)
+	}
+	if len(changes) == 0 {
+		g.logger.Info().Str("id", graphID).Msg("graph up to date")
+		return nil
+	}
+
+	g.logger.Info().Str("id", graphID).Interface("changes", changes).Msg("updating graph")
+
+	cfg.CID = reg.CID
+	cfgFile := path.Join(g.regDir, "config-"+graphID+".json")
+	if err := regfiles.Save(cfgFile, cfg, true); err != nil {
+		return errors.Wrapf(err, "saving config (%s)", cfgFile)
+	}
+	regFile := regfiles.File(g.regDir, graphID)
+	if err := graph.Update(g.client, cfgFile, regFile, true); err != nil {
+		return errors.Wrapf(err, "updating %s", graphID)
+	}
+
+	var updated circapi.Graph
+	if _, err := regfiles.Load(regFile, &updated); err != nil {
+		return errors.Wrapf(err, "loading %s", graphID)
+	}
+	g.graphList[graphID] = updated
+
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package graphs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	agentapi "github.com/circonus-labs/circonus-agent/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestUpdateGraph(t *testing.T) {
+	t.Log("Testing updateGraph")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-graphs-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	const cid = "/graph/abcdef01-2345-6789-abcd-ef0123456789"
+
+	var remote circapi.Graph
+	client := &CircAPIMock{
+		FetchGraphFunc: func(c circapi.CIDType) (*circapi.Graph, error) {
+			g := remote
+			return &g, nil
+		},
+		UpdateGraphFunc: func(cfg *circapi.Graph) (*circapi.Graph, error) {
+			return cfg, nil
+		},
+	}
+
+	g, err := New(&Options{
+		CheckInfo: &checks.CheckInfo{CheckID: 1234},
+		Client:    client,
+		Config:    &options.Options{},
+		Metrics:   &agentapi.Metrics{"test": {}},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+		Update:    true,
+	})
+	if err != nil {
+		t.Fatalf("unable to create graphs object (%s)", err)
+	}
+
+	{
+		t.Log("invalid (no registration)")
+		err := g.updateGraph("graph-missing", &circapi.Graph{})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "no registration loaded for graph-missing" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	reg := circapi.Graph{CID: cid, Title: "foo"}
+	g.graphList["graph-test"] = reg
+
+	{
+		t.Log("up to date")
+		remote = reg
+		if err := g.updateGraph("graph-test", &circapi.Graph{Title: "foo"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateGraphCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("user edits preserved")
+		remote = circapi.Graph{CID: cid, Title: "edited in ui"}
+		if err := g.updateGraph("graph-test", &circapi.Graph{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateGraphCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("up to date (server defaults)")
+		style := "line"
+		lineStyle := "stepped"
+		color := "#4a00dc"
+		alpha := "0.3"
+		name := "idle"
+		served := circapi.Graph{
+			CID:       cid,
+			Title:     "foo",
+			Style:     &style,
+			LineStyle: &lineStyle,
+			Tags:      []string{},
+			Datapoints: []circapi.GraphDatapoint{{
+				Alpha:      &alpha,
+				Axis:       "l",
+				CheckID:    1234,
+				Color:      &color,
+				Derive:     "gauge",
+				MetricName: "cpu`idle",
+				MetricType: "numeric",
+				Name:       name,
+			}},
+		}
+		g.graphList["graph-test"] = served
+		remote = served
+		cfg := &circapi.Graph{
+			Title: "foo",
+			Style: &style,
+			Datapoints: []circapi.GraphDatapoint{{
+				Axis:       "l",
+				CheckID:    1234,
+				MetricName: "cpu`idle",
+				MetricType: "numeric",
+				Name:       name,
+			}},
+		}
+		if err := g.updateGraph("graph-test", cfg); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateGraphCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+		g.graphList["graph-test"] = reg
+	}
+
+	{
+		t.Log("template changed")
+		remote = reg
+		if err := g.updateGraph("graph-test", &circapi.Graph{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateGraphCalls()) != 1 {
+			t.Fatal("expected update")
+		}
+		if client.UpdateGraphCalls()[0].Cfg.CID != cid {
+			t.Fatalf("expected update of %s got (%s)", cid, client.UpdateGraphCalls()[0].Cfg.CID)
+		}
+		var saved circapi.Graph
+		if _, err := regfiles.Load(filepath.Join(dir, "registration-graph-test.json"), &saved); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if saved.Title != "bar" {
+			t.Fatalf("expected registration title bar got (%s)", saved.Title)
+		}
+		if g.graphList["graph-test"].Title != "bar" {
+			t.Fatalf("expected graph list title bar got (%s)", g.graphList["graph-test"].Title)
+		}
+	}
+}
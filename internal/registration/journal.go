@@ -0,0 +1,210 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	assetCheckBundle = "check_bundle"
+	assetDashboard   = "dashboard"
+	assetGraph       = "graph"
+	assetRuleSet     = "rule_set"
+	assetWorksheet   = "worksheet"
+)
+
+// journalEntry is an asset created during a registration run
+type journalEntry struct {
+	Type string
+	CID  string
+}
+
+// journal records every asset created during a registration run, and the
+// files which existed in the registration directory before the run, so
+// that a failed run can be rolled back
+type journal struct {
+	entries  []journalEntry
+	regDir   string
+	preExist map[string]bool
+	sync.Mutex
+}
+
+// newJournal creates a journal, taking a snapshot of the files currently
+// in the registration directory
+func newJournal(regDir string) (*journal, error) {
+	if regDir == "" {
+		return nil, errors.New("invalid registration directory (empty)")
+	}
+
+	j := &journal{
+		entries:  []journalEntry{},
+		regDir:   regDir,
+		preExist: make(map[string]bool),
+	}
+
+	files, err := ioutil.ReadDir(regDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return j, nil
+		}
+		return nil, errors.Wrap(err, "reading registration directory")
+	}
+	for _, f := range files {
+		j.preExist[f.Name()] = true
+	}
+
+	return j, nil
+}
+
+// add records a created asset
+func (j *journal) add(assetType, cid string) {
+	j.Lock()
+	j.entries = append(j.entries, journalEntry{Type: assetType, CID: cid})
+	j.Unlock()
+}
+
+// list returns a copy of the assets recorded, in creation order
+func (j *journal) list() []journalEntry {
+	j.Lock()
+	defer j.Unlock()
+	entries := make([]journalEntry, len(j.entries))
+	copy(entries, j.entries)
+	return entries
+}
+
+// newFiles returns the registration and config files created in the
+// registration directory since the journal was started
+func (j *journal) newFiles() ([]string, error) {
+	files, err := ioutil.ReadDir(j.regDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading registration directory")
+	}
+	created := []string{}
+	for _, f := range files {
+		if f.IsDir() || j.preExist[f.Name()] {
+			continue
+		}
+		if strings.HasPrefix(f.Name(), "registration-") || strings.HasPrefix(f.Name(), "config-") {
+			created = append(created, filepath.Join(j.regDir, f.Name()))
+		}
+	}
+	return created, nil
+}
+
+// journalAPI wraps a CircAPI recording each asset successfully created
+type journalAPI struct {
+	CircAPI
+	journal *journal
+}
+
+// CreateCheckBundle creates a check bundle and records it in the journal
+func (ja *journalAPI) CreateCheckBundle(cfg *circapi.CheckBundle) (*circapi.CheckBundle, error) {
+	b, err := ja.CircAPI.CreateCheckBundle(cfg)
+	if err == nil && b != nil {
+		ja.journal.add(assetCheckBundle, b.CID)
+	}
+	return b, err
+}
+
+// CreateDashboard creates a dashboard and records it in the journal
+func (ja *journalAPI) CreateDashboard(cfg *circapi.Dashboard) (*circapi.Dashboard, error) {
+	d, err := ja.CircAPI.CreateDashboard(cfg)
+	if err == nil && d != nil {
+		ja.journal.add(assetDashboard, d.CID)
+	}
+	return d, err
+}
+
+// CreateGraph creates a graph and records it in the journal
+func (ja *journalAPI) CreateGraph(cfg *circapi.Graph) (*circapi.Graph, error) {
+	g, err := ja.CircAPI.CreateGraph(cfg)
+	if err == nil && g != nil {
+		ja.journal.add(assetGraph, g.CID)
+	}
+	return g, err
+}
+
+// CreateRuleSet creates a ruleset and records it in the journal
+func (ja *journalAPI) CreateRuleSet(cfg *circapi.RuleSet) (*circapi.RuleSet, error) {
+	rs, err := ja.CircAPI.CreateRuleSet(cfg)
+	if err == nil && rs != nil {
+		ja.journal.add(assetRuleSet, rs.CID)
+	}
+	return rs, err
+}
+
+// CreateWorksheet creates a worksheet and records it in the journal
+func (ja *journalAPI) CreateWorksheet(cfg *circapi.Worksheet) (*circapi.Worksheet, error) {
+	w, err := ja.CircAPI.CreateWorksheet(cfg)
+	if err == nil && w != nil {
+		ja.journal.add(assetWorksheet, w.CID)
+	}
+	return w, err
+}
+
+// rollback deletes, in reverse creation order, the assets recorded in the
+// journal and removes any registration files created during the run
+func (r *Registration) rollback(j *journal) error {
+	if j == nil {
+		return errors.New("invalid journal (nil)")
+	}
+
+	failed := []string{}
+
+	entries := j.list()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		cid := entry.CID
+		var err error
+		switch entry.Type {
+		case assetCheckBundle:
+			_, err = r.cliCirc.DeleteCheckBundleByCID(circapi.CIDType(&cid))
+		case assetDashboard:
+			_, err = r.cliCirc.DeleteDashboardByCID(circapi.CIDType(&cid))
+		case assetGraph:
+			_, err = r.cliCirc.DeleteGraphByCID(circapi.CIDType(&cid))
+		case assetRuleSet:
+			_, err = r.cliCirc.DeleteRuleSetByCID(circapi.CIDType(&cid))
+		case assetWorksheet:
+			_, err = r.cliCirc.DeleteWorksheetByCID(circapi.CIDType(&cid))
+		default:
+			err = errors.Errorf("unknown asset type (%s)", entry.Type)
+		}
+		if err != nil {
+			r.logger.Error().Err(err).Str("type", entry.Type).Str("cid", cid).Msg("rollback, deleting asset")
+			failed = append(failed, entry.Type+" "+cid)
+			continue
+		}
+		r.logger.Info().Str("type", entry.Type).Str("cid", cid).Msg("rollback, deleted asset")
+	}
+
+	files, err := j.newFiles()
+	if err != nil {
+		return errors.Wrap(err, "rollback")
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			r.logger.Error().Err(err).Str("file", file).Msg("rollback, removing file")
+			failed = append(failed, file)
+			continue
+		}
+		r.logger.Info().Str("file", file).Msg("rollback, removed file")
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("rollback incomplete, unable to remove: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
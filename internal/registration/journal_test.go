@@ -0,0 +1,153 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func TestJournalAPI(t *testing.T) {
+	t.Log("Testing journalAPI")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-journal-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := newJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	client := genMockCircAPI()
+	client.CreateGraphFunc = func(cfg *apiclient.Graph) (*apiclient.Graph, error) {
+		if cfg.Title == "fail" {
+			return nil, errors.New("api error")
+		}
+		g := *cfg
+		g.CID = "/graph/abc"
+		return &g, nil
+	}
+	client.CreateWorksheetFunc = func(cfg *apiclient.Worksheet) (*apiclient.Worksheet, error) {
+		w := *cfg
+		w.CID = "/worksheet/def"
+		return &w, nil
+	}
+
+	ja := &journalAPI{CircAPI: client, journal: j}
+
+	if _, err := ja.CreateGraph(&apiclient.Graph{Title: "ok"}); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if _, err := ja.CreateGraph(&apiclient.Graph{Title: "fail"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := ja.CreateWorksheet(&apiclient.Worksheet{Title: "ok"}); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	entries := j.list()
+	expected := []journalEntry{{assetGraph, "/graph/abc"}, {assetWorksheet, "/worksheet/def"}}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, entries)
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Fatalf("expected %v got %v", expected[i], entries[i])
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	t.Log("Testing rollback")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-journal-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "registration-check-system.json")
+	if err := ioutil.WriteFile(existing, []byte("{}"), 0644); err != nil {
+		t.Fatalf("writing file (%s)", err)
+	}
+
+	j, err := newJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	created := filepath.Join(dir, "registration-graph-cpu-utilization.json")
+	if err := ioutil.WriteFile(created, []byte("{}"), 0644); err != nil {
+		t.Fatalf("writing file (%s)", err)
+	}
+	other := filepath.Join(dir, "other.json")
+	if err := ioutil.WriteFile(other, []byte("{}"), 0644); err != nil {
+		t.Fatalf("writing file (%s)", err)
+	}
+
+	j.add(assetGraph, "/graph/abc")
+	j.add(assetDashboard, "/dashboard/1")
+
+	deleted := []string{}
+	client := genMockCircAPI()
+	client.DeleteGraphByCIDFunc = func(cid apiclient.CIDType) (bool, error) {
+		deleted = append(deleted, *cid)
+		return true, nil
+	}
+	client.DeleteDashboardByCIDFunc = func(cid apiclient.CIDType) (bool, error) {
+		deleted = append(deleted, *cid)
+		return true, nil
+	}
+
+	r := &Registration{cliCirc: client}
+
+	if err := r.rollback(nil); err == nil {
+		t.Fatal("expected error")
+	} else if err.Error() != "invalid journal (nil)" {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if err := r.rollback(j); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	// reverse creation order
+	if len(deleted) != 2 || deleted[0] != "/dashboard/1" || deleted[1] != "/graph/abc" {
+		t.Fatalf("unexpected deletes %v", deleted)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed", created)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Fatalf("expected %s to be retained (%s)", existing, err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("expected %s to be retained (%s)", other, err)
+	}
+
+	t.Log("delete failure")
+	j.add(assetWorksheet, "/worksheet/def")
+	client.DeleteWorksheetByCIDFunc = func(cid apiclient.CIDType) (bool, error) {
+		return false, errors.New("api error")
+	}
+	j.entries = j.entries[2:]
+	if err := r.rollback(j); err == nil {
+		t.Fatal("expected error")
+	} else if err.Error() != "rollback incomplete, unable to remove: worksheet /worksheet/def" {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+}
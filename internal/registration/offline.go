@@ -0,0 +1,25 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/pkg/errors"
+)
+
+// offlineCosi is used in place of the cosi-server client in offline mode
+// (--offline), cosi-server is never contacted
+type offlineCosi struct{}
+
+// FetchBroker fails, the default broker is only available from cosi-server
+func (offlineCosi) FetchBroker(checkType string) (string, error) {
+	return "", errors.Errorf("default %s broker not available in offline mode, use --broker-id or an enterprise broker", checkType)
+}
+
+// FetchTemplate fails, templates must be available locally in offline mode
+func (offlineCosi) FetchTemplate(id string) (*cosiapi.Template, error) {
+	return nil, errors.Errorf("template (%s) not available in offline mode", id)
+}
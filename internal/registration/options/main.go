@@ -30,13 +30,16 @@ type Options struct {
 	Graphs     `json:"graphs" toml:"graphs" yaml:"graphs"`
 	Host       `json:"host" toml:"host" yaml:"host"`
 	Worksheets `json:"worksheets" toml:"worksheets" yaml:"worksheets"`
+	Vars       map[string]string            `json:"vars" toml:"vars" yaml:"vars"` // custom template variables
 	Common     `json:"-" toml:"-" yaml:"-"` // cannot be set in config, generated by cosi register
 }
 
 // Brokers defines settings for broker selection
 type Brokers struct {
-	Group  GroupBrokers  `json:"group" toml:"group" yaml:"group"`
-	System SystemBrokers `json:"system" toml:"system" yaml:"system"`
+	Group           GroupBrokers  `json:"group" toml:"group" yaml:"group"`
+	System          SystemBrokers `json:"system" toml:"system" yaml:"system"`
+	Select          string        `json:"select" toml:"select" yaml:"select"`                                  // random|latency, when more than one broker is viable
+	MaxResponseTime string        `json:"max_response_time" toml:"max_response_time" yaml:"max_response_time"` // e.g. 500ms
 }
 
 // SystemBrokers defines broker settings for system check
@@ -123,8 +126,13 @@ type SystemWorksheet struct {
 
 // Common are a set of non-configurable options which are dynamically generated
 type Common struct {
-	Notes string   `json:"-" toml:"-" yaml:"-"`
-	Tags  []string `json:"-" toml:"-" yaml:"-"`
+	Notes     string   `json:"-" toml:"-" yaml:"-"`
+	Tags      []string `json:"-" toml:"-" yaml:"-"`
+	CosiID    string   `json:"-" toml:"-" yaml:"-"`
+	OSType    string   `json:"-" toml:"-" yaml:"-"`
+	OSDistro  string   `json:"-" toml:"-" yaml:"-"`
+	OSVersion string   `json:"-" toml:"-" yaml:"-"`
+	SysArch   string   `json:"-" toml:"-" yaml:"-"`
 }
 
 // LoadConfigFile reads a custom options configuration file and returns an Options struct
@@ -137,7 +145,12 @@ func LoadConfigFile(fn string) (*Options, error) {
 		isReverse = true
 	}
 
-	cfg := Options{}
+	// defaults which differ from the zero value, the config file will
+	// only override settings which are explicitly present
+	cfg := Options{
+		Dashboards: Dashboards{System: SystemDashboard{Create: true}},
+		Worksheets: Worksheets{System: SystemWorksheet{Create: true}},
+	}
 	if fn == "" {
 		logger.Warn().Msg("no custom configuration provided, skipping")
 	} else {
@@ -228,6 +241,11 @@ func LoadConfigFile(fn string) (*Options, error) {
 		return nil, errors.New("cosi_id not set")
 	}
 
+	cfg.Common.CosiID = cosiID
+	cfg.Common.OSType = viper.GetString(config.KeySystemOSType)
+	cfg.Common.OSDistro = viper.GetString(config.KeySystemOSDistro)
+	cfg.Common.OSVersion = viper.GetString(config.KeySystemOSVersion)
+	cfg.Common.SysArch = viper.GetString(config.KeySystemArch)
 	cfg.Common.Notes = "cosi:register,cosi_id:" + cosiID
 	cfg.Common.Tags = []string{
 		"cosi:install",
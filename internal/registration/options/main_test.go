@@ -50,3 +50,40 @@ func TestLoadConfigFile(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigFileCreateDefaults(t *testing.T) {
+	t.Log("Testing LoadConfigFile create defaults")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	viper.Set(config.KeyCosiID, "abc123")
+	tests := []struct {
+		name          string
+		file          string
+		dashboardSys  bool
+		worksheetSys  bool
+		worksheetName string
+	}{
+		{"no config", "", true, true, ""},
+		{"system dashboard disabled", path.Join("testdata", "system-disabled"), false, true, "{{.HostName}} overview"},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := LoadConfigFile(tst.file)
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if cfg.Dashboards.System.Create != tst.dashboardSys {
+				t.Fatalf("expected dashboards.system.create=%v", tst.dashboardSys)
+			}
+			if cfg.Worksheets.System.Create != tst.worksheetSys {
+				t.Fatalf("expected worksheets.system.create=%v", tst.worksheetSys)
+			}
+			if cfg.Worksheets.System.Title != tst.worksheetName {
+				t.Fatalf("expected worksheets.system.title (%s) got (%s)", tst.worksheetName, cfg.Worksheets.System.Title)
+			}
+		})
+	}
+}
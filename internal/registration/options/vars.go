@@ -0,0 +1,38 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package options
+
+import "runtime"
+
+// TemplateVars returns the variable context shared by all check, graph,
+// worksheet and dashboard templates. The custom variables from the regconf
+// [vars] section come first, followed by each layer (e.g. dashboard meta),
+// the system variables are set last so they cannot be overridden.
+func (o *Options) TemplateVars(layers ...map[string]string) map[string]interface{} {
+	tvars := map[string]interface{}{}
+	for k, v := range o.Vars {
+		tvars[k] = v
+	}
+	for _, layer := range layers {
+		for k, v := range layer {
+			tvars[k] = v
+		}
+	}
+
+	tvars["HostName"] = o.Host.Name
+	tvars["HostIP"] = o.Host.IP
+	tvars["HostTarget"] = o.Checks.System.Target
+	tvars["OSType"] = o.Common.OSType
+	tvars["OSDistro"] = o.Common.OSDistro
+	tvars["OSVersion"] = o.Common.OSVersion
+	tvars["SysArch"] = o.Common.SysArch
+	tvars["CosiID"] = o.Common.CosiID
+	tvars["BrokerID"] = o.Checks.System.BrokerID
+	tvars["GroupID"] = o.Checks.Group.ID
+	tvars["NumCPU"] = runtime.NumCPU()
+
+	return tvars
+}
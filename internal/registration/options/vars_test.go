@@ -0,0 +1,58 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package options
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestTemplateVars(t *testing.T) {
+	t.Log("Testing TemplateVars")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	o := Options{
+		Host:   Host{Name: "foo", IP: "10.0.0.1"},
+		Checks: Checks{System: SystemCheck{Target: "foo.example.com", BrokerID: "/broker/1"}, Group: GroupCheck{ID: "grp"}},
+		Vars:   map[string]string{"Env": "prod", "HostName": "custom"},
+		Common: Common{CosiID: "abc", OSType: "linux", OSDistro: "ubuntu", OSVersion: "18.04", SysArch: "x86_64"},
+	}
+
+	tests := []struct {
+		name     string
+		layers   []map[string]string
+		key      string
+		expected interface{}
+	}{
+		{"system var", nil, "HostIP", "10.0.0.1"},
+		{"system var (not overridden by custom)", nil, "HostName", "foo"},
+		{"system var (not overridden by layer)", []map[string]string{{"CosiID": "xyz"}}, "CosiID", "abc"},
+		{"custom var", nil, "Env", "prod"},
+		{"custom var (overridden by layer)", []map[string]string{{"Env": "dev"}}, "Env", "dev"},
+		{"layer order", []map[string]string{{"Port": "1"}, {"Port": "2"}}, "Port", "2"},
+		{"target", nil, "HostTarget", "foo.example.com"},
+		{"broker", nil, "BrokerID", "/broker/1"},
+		{"group", nil, "GroupID", "grp"},
+		{"os", nil, "OSDistro", "ubuntu"},
+		{"num cpu", nil, "NumCPU", runtime.NumCPU()},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			tvars := o.TemplateVars(tst.layers...)
+			v, ok := tvars[tst.key]
+			if !ok {
+				t.Fatalf("expected %s", tst.key)
+			}
+			if v != tst.expected {
+				t.Fatalf("unexpected value (%v) expected (%v)", v, tst.expected)
+			}
+		})
+	}
+}
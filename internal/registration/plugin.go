@@ -0,0 +1,137 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"os"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/dashboards"
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// NewPlugin creates a registration client for creating the assets of a
+// plugin (e.g. postgres) on a system which has already been registered.
+// Only the templates in the list are used and broker selection is skipped.
+func NewPlugin(circClient CircAPI, templateIDs []string) (*Registration, error) {
+	if len(templateIDs) == 0 {
+		return nil, errors.New("invalid template list (empty)")
+	}
+
+	r, err := newRegistration(circClient)
+	if err != nil {
+		return nil, err
+	}
+
+	r.plugin = true
+	r.update = viper.GetBool(KeyUpdate)
+	for _, id := range templateIDs {
+		r.templateList[id] = true
+	}
+
+	if err := r.configure(); err != nil {
+		return nil, err
+	}
+
+	r.logger.Info().Strs("list", templateIDs).Msg("plugin templates")
+	return r, nil
+}
+
+// RegisterPlugin creates the graphs and dashboards for the plugin templates
+// and activates the metrics they use on the system check.
+func (r *Registration) RegisterPlugin() error {
+	if !r.plugin {
+		return errors.New("invalid state, not a plugin registration")
+	}
+	if err := verifySystemRegistration(r.regDir); err != nil {
+		return err
+	}
+
+	c, err := checks.New(&checks.Options{
+		Client:    r.cliCirc,
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+	})
+	if err != nil {
+		return err
+	}
+	// loads the existing check registrations
+	if err := c.Register(); err != nil {
+		return err
+	}
+	ci, err := c.GetCheckInfo("system")
+	if err != nil {
+		return errors.Wrap(err, "unable to get system check info")
+	}
+
+	g, err := graphs.New(&graphs.Options{
+		Client:    r.cliCirc,
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+		CheckInfo: ci,
+		Metrics:   r.availableMetrics,
+		Update:    r.update,
+	})
+	if err != nil {
+		return err
+	}
+	// existing graphs are needed for graph info
+	if err := g.LoadRegistrations(); err != nil {
+		return err
+	}
+	if err := g.Register(r.templateList); err != nil {
+		return err
+	}
+	if err := c.UpdateSystemCheck(g.GetMetricList()); err != nil {
+		return err
+	}
+	gi, err := g.GetGraphInfo()
+	if err != nil {
+		return err
+	}
+
+	d, err := dashboards.New(&dashboards.Options{
+		Client:    r.cliCirc,
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+		CheckInfo: ci,
+		GraphInfo: gi,
+		Metrics:   r.availableMetrics,
+		Update:    r.update,
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.Register(r.templateList); err != nil {
+		return err
+	}
+	if err := c.UpdateSystemCheck(d.GetMetricList()); err != nil {
+		return err
+	}
+
+	r.logger.Info().Msg("plugin registration complete")
+
+	return nil
+}
+
+// verifySystemRegistration ensures the system check has been registered,
+// plugin assets are added to an existing registration
+func verifySystemRegistration(regDir string) error {
+	regFile := regfiles.File(regDir, "check-system")
+	if _, err := os.Stat(regFile); err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("system not registered, run 'cosi register' first")
+		}
+		return errors.Wrap(err, "system check registration")
+	}
+	return nil
+}
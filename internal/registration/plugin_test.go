@@ -0,0 +1,76 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestVerifySystemRegistration(t *testing.T) {
+	t.Log("Testing verifySystemRegistration")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-registration-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Log("not registered")
+	{
+		err := verifySystemRegistration(dir)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "system not registered, run 'cosi register' first" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "registration-check-system.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("writing registration (%s)", err)
+	}
+
+	t.Log("registered")
+	{
+		if err := verifySystemRegistration(dir); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	t.Log("Testing RegisterPlugin")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	t.Log("invalid (not plugin)")
+	{
+		r := &Registration{}
+		err := r.RegisterPlugin()
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid state, not a plugin registration" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("invalid (empty template list)")
+	{
+		_, err := NewPlugin(&CircAPIMock{}, []string{})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid template list (empty)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package regfiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Change is a single field difference between two versions of an asset
+type Change struct {
+	Path string      `json:"path"` // e.g. datapoints[0].metric_name
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// String returns a human readable representation of the change
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, fmtValue(c.From), fmtValue(c.To))
+}
+
+// Diff returns the field level differences between two versions of an
+// asset (e.g. a registration and the current object from the API).
+// Fields managed by the API (names starting with '_', e.g. _cid or
+// _last_modified) are ignored, as are differences between a missing
+// field, null, and the zero value.
+func Diff(from, to interface{}) ([]Change, error) {
+	if from == nil || to == nil {
+		return nil, errors.New("invalid asset (nil)")
+	}
+
+	var a, b interface{}
+	if err := normalize(from, &a); err != nil {
+		return nil, errors.Wrap(err, "normalizing from")
+	}
+	if err := normalize(to, &b); err != nil {
+		return nil, errors.Wrap(err, "normalizing to")
+	}
+
+	changes := []Change{}
+	diffValue("", a, b, false, &changes)
+	return changes, nil
+}
+
+// DiffConfig returns the differences between an asset from the API and a
+// configuration rendered from a template. Only the fields set in the
+// configuration are compared, fields the configuration leaves empty are
+// filled in with defaults by the API and are not differences.
+func DiffConfig(current, cfg interface{}) ([]Change, error) {
+	if current == nil || cfg == nil {
+		return nil, errors.New("invalid asset (nil)")
+	}
+
+	var a, b interface{}
+	if err := normalize(current, &a); err != nil {
+		return nil, errors.Wrap(err, "normalizing current")
+	}
+	if err := normalize(cfg, &b); err != nil {
+		return nil, errors.Wrap(err, "normalizing config")
+	}
+
+	changes := []Change{}
+	diffValue("", a, b, true, &changes)
+	return changes, nil
+}
+
+// normalize converts an asset into generic json types for comparison
+func normalize(v interface{}, dest *interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// diffValue adds the differences between a and b to changes, when setOnly
+// is true object fields which are empty in b are skipped
+func diffValue(path string, a, b interface{}, setOnly bool, changes *[]Change) {
+	if isZero(a) && isZero(b) {
+		return
+	}
+
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := map[string]bool{}
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		names := make([]string, 0, len(keys))
+		for k := range keys {
+			if strings.HasPrefix(k, "_") {
+				continue
+			}
+			if setOnly && isZero(bm[k]) {
+				continue
+			}
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffValue(p, am[k], bm[k], setOnly, changes)
+		}
+		return
+	}
+
+	as, aIsSlice := a.([]interface{})
+	bs, bIsSlice := b.([]interface{})
+	if aIsSlice && bIsSlice {
+		n := len(as)
+		if len(bs) > n {
+			n = len(bs)
+		}
+		for i := 0; i < n; i++ {
+			var av, bv interface{}
+			if i < len(as) {
+				av = as[i]
+			}
+			if i < len(bs) {
+				bv = bs[i]
+			}
+			diffValue(fmt.Sprintf("%s[%d]", path, i), av, bv, setOnly, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, From: a, To: b})
+	}
+}
+
+// isZero returns true for null and zero values (incl. empty arrays and objects)
+func isZero(v interface{}) bool {
+	switch tv := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !tv
+	case float64:
+		return tv == 0
+	case string:
+		return tv == ""
+	case []interface{}:
+		return len(tv) == 0
+	case map[string]interface{}:
+		for k, mv := range tv {
+			if strings.HasPrefix(k, "_") {
+				continue
+			}
+			if !isZero(mv) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func fmtValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,115 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package regfiles
+
+import (
+	"testing"
+
+	circapi "github.com/circonus-labs/go-apiclient"
+)
+
+func TestDiff(t *testing.T) {
+	t.Log("Testing Diff")
+
+	title := "foo"
+	other := "bar"
+
+	tests := []struct {
+		name       string
+		from       interface{}
+		to         interface{}
+		expected   []string
+		shouldFail bool
+		errMsg     string
+	}{
+		{"invalid from (nil)", nil, &circapi.Graph{}, nil, true, "invalid asset (nil)"},
+		{"invalid to (nil)", &circapi.Graph{}, nil, nil, true, "invalid asset (nil)"},
+		{"identical", &circapi.Graph{Title: title}, &circapi.Graph{Title: title}, []string{}, false, ""},
+		{"api fields ignored", &circapi.Dashboard{CID: "/dashboard/1", LastModified: 1, Title: title}, &circapi.Dashboard{CID: "/dashboard/2", LastModified: 2, Title: title}, []string{}, false, ""},
+		{"null vs zero", &circapi.Graph{Description: ""}, &circapi.Graph{Tags: []string{}}, []string{}, false, ""},
+		{"field", &circapi.Graph{Title: title}, &circapi.Graph{Title: other}, []string{`title: "foo" -> "bar"`}, false, ""},
+		{"nested", &circapi.Graph{Datapoints: []circapi.GraphDatapoint{{MetricName: title}}}, &circapi.Graph{Datapoints: []circapi.GraphDatapoint{{MetricName: other}}}, []string{`datapoints[0].metric_name: "foo" -> "bar"`}, false, ""},
+		{"added element", &circapi.Graph{Tags: []string{title}}, &circapi.Graph{Tags: []string{title, other}}, []string{`tags[1]: null -> "bar"`}, false, ""},
+		{"removed element", &circapi.Graph{Tags: []string{title, other}}, &circapi.Graph{Tags: []string{title}}, []string{`tags[1]: "bar" -> null`}, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			changes, err := Diff(tst.from, tst.to)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.errMsg {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if len(changes) != len(tst.expected) {
+				t.Fatalf("expected %v got %v", tst.expected, changes)
+			}
+			for i, c := range changes {
+				if c.String() != tst.expected[i] {
+					t.Fatalf("expected (%s) got (%s)", tst.expected[i], c.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	t.Log("Testing DiffConfig")
+
+	style := "line"
+	color := "#4a00dc"
+	other := "area"
+
+	tests := []struct {
+		name       string
+		current    interface{}
+		cfg        interface{}
+		expected   []string
+		shouldFail bool
+		errMsg     string
+	}{
+		{"invalid current (nil)", nil, &circapi.Graph{}, nil, true, "invalid asset (nil)"},
+		{"invalid cfg (nil)", &circapi.Graph{}, nil, nil, true, "invalid asset (nil)"},
+		{"server defaults ignored", &circapi.Graph{Title: "foo", Style: &style, Datapoints: []circapi.GraphDatapoint{{MetricName: "foo", Color: &color}}}, &circapi.Graph{Title: "foo", Datapoints: []circapi.GraphDatapoint{{MetricName: "foo"}}}, []string{}, false, ""},
+		{"field", &circapi.Graph{Title: "foo", Style: &style}, &circapi.Graph{Title: "foo", Style: &other}, []string{`style: "line" -> "area"`}, false, ""},
+		{"removed element", &circapi.Graph{Datapoints: []circapi.GraphDatapoint{{MetricName: "foo"}, {MetricName: "bar"}}}, &circapi.Graph{Datapoints: []circapi.GraphDatapoint{{MetricName: "foo"}}}, []string{`datapoints[1]: {"data_formula":null,"hidden":false,"legend_formula":null,"metric_name":"bar","name":"","search":null,"stack":null} -> null`}, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			changes, err := DiffConfig(tst.current, tst.cfg)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.errMsg {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if len(changes) != len(tst.expected) {
+				t.Fatalf("expected %v got %v", tst.expected, changes)
+			}
+			for i, c := range changes {
+				if c.String() != tst.expected[i] {
+					t.Fatalf("expected (%s) got (%s)", tst.expected[i], c.String())
+				}
+			}
+		})
+	}
+}
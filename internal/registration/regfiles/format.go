@@ -0,0 +1,136 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package regfiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	toml "github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NOTE: the API objects only have json tags, toml and yaml registrations
+//       are converted to/from json so the keys (e.g. _cid) and values are
+//       the same in every format.
+
+// encode formats a registration for the format (file extension)
+func encode(format string, o interface{}) ([]byte, error) {
+	switch format {
+	case ".json":
+		return json.MarshalIndent(o, "", "  ")
+	case ".toml", ".yaml":
+	default:
+		return nil, errors.Errorf("unknown extension/format (%s)", format)
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	v = fromJSON(v)
+
+	if format == ".yaml" {
+		return yaml.Marshal(v)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("toml requires an object, not %T", o)
+	}
+	tree, err := toml.TreeFromMap(m)
+	if err != nil {
+		return nil, err
+	}
+	s, err := tree.ToTomlString()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// decode parses a registration in the format (file extension) into v
+func decode(format string, data []byte, v interface{}) error {
+	var generic interface{}
+	switch format {
+	case ".toml":
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return err
+		}
+		generic = tree.ToMap()
+	case ".yaml":
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		generic = fromYAML(generic)
+	default:
+		return json.Unmarshal(data, v)
+	}
+
+	data, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// fromJSON converts json numbers to int64|float64 and drops null values
+// (toml has no null, a missing key has the same result when parsed)
+func fromJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, val := range t {
+			if val == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = fromJSON(val)
+		}
+		return t
+	case []interface{}:
+		list := make([]interface{}, 0, len(t))
+		for _, val := range t {
+			if val != nil {
+				list = append(list, fromJSON(val))
+			}
+		}
+		return list
+	}
+	return v
+}
+
+// fromYAML converts yaml maps (map[interface{}]interface{}) to json
+// compatible maps
+func fromYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprintf("%v", k)] = fromYAML(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range t {
+			t[i] = fromYAML(val)
+		}
+		return t
+	}
+	return v
+}
@@ -7,7 +7,6 @@
 package regfiles
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -16,16 +15,52 @@ import (
 	"regexp"
 	"strings"
 
-	toml "github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	yaml "gopkg.in/yaml.v2"
 )
 
 var regTypeValidator = regexp.MustCompile(`^(check|graph|worksheet|dashboard|ruleset)$`)
 
+// Extensions are the registration file formats, in order of precedence
+// when a registration exists in more than one format
+var Extensions = []string{".json", ".toml", ".yaml"}
+
+// ID returns the id of a registration file
+// (e.g. registration-check-system.yaml -> check-system)
+func ID(regFile string) string {
+	base := filepath.Base(regFile)
+	return strings.TrimSuffix(strings.TrimPrefix(base, "registration-"), filepath.Ext(base))
+}
+
+// File returns the registration file for an id (e.g. check-system) in the
+// registration directory, the first format found (see Extensions) or the
+// json file if the registration does not exist
+func File(regDir, id string) string {
+	for _, ext := range Extensions {
+		regFile := filepath.Join(regDir, "registration-"+id+ext)
+		if _, err := os.Stat(regFile); err == nil {
+			return regFile
+		}
+	}
+	return filepath.Join(regDir, "registration-"+id+".json")
+}
+
+func isRegExt(ext string) bool {
+	return extRank(ext) != -1
+}
+
+func extRank(ext string) int {
+	for i, e := range Extensions {
+		if ext == e {
+			return i
+		}
+	}
+	return -1
+}
+
 // Save registration write file w/optional force overwrite or write
-// formatted JSON to stdout if no file name is provided.
+// formatted JSON to stdout if no file name is provided. The format is
+// based on the file extension (json, toml or yaml).
 func Save(file string, o interface{}, force bool) error {
 	if o == nil {
 		return errors.New("invalid configuration (nil)")
@@ -36,23 +71,9 @@ func Save(file string, o interface{}, force bool) error {
 		format = ".json"
 	}
 
-	var data []byte
-	{
-		var err error
-
-		switch format {
-		case ".json":
-			data, err = json.MarshalIndent(o, "", "  ")
-		case ".toml":
-			data, err = toml.Marshal(o)
-		case ".yaml":
-			data, err = yaml.Marshal(o)
-		default:
-			err = errors.Errorf("unknown extension/format (%s)", format)
-		}
-		if err != nil {
-			return errors.Wrap(err, "formatting configuration")
-		}
+	data, err := encode(format, o)
+	if err != nil {
+		return errors.Wrap(err, "formatting configuration")
 	}
 
 	if file == "" {
@@ -74,7 +95,7 @@ func Save(file string, o interface{}, force bool) error {
 		}
 		if s.Mode().IsRegular() {
 			if force {
-				flags |= os.O_CREATE
+				flags |= os.O_CREATE | os.O_TRUNC
 			} else {
 				return errors.Errorf("%s already exists, see --force", file)
 			}
@@ -92,7 +113,8 @@ func Save(file string, o interface{}, force bool) error {
 }
 
 // Find returns a list of registration files from the registration directory
-// which match the specified registration type (e.g. check|dashboard|graph|worksheet)
+// which match the specified registration type (e.g. check|dashboard|graph|worksheet),
+// in any of the registration formats (see Extensions)
 func Find(regDir, regType string) (*[]string, error) {
 	if regDir == "" {
 		return nil, errors.Errorf("invalid registration directory (empty)")
@@ -111,6 +133,7 @@ func Find(regDir, regType string) (*[]string, error) {
 
 	regFileSig := "registration-" + regType
 	regFiles := []string{}
+	found := make(map[string]int) // id -> index in regFiles
 
 	for _, file := range files {
 		if !file.Mode().IsRegular() {
@@ -121,6 +144,22 @@ func Find(regDir, regType string) (*[]string, error) {
 			continue
 		}
 
+		ext := filepath.Ext(file.Name())
+		if !isRegExt(ext) {
+			continue
+		}
+
+		// one file per registration, if more than one format exists
+		// use the first in order of Extensions
+		id := ID(file.Name())
+		if idx, ok := found[id]; ok {
+			if extRank(ext) < extRank(filepath.Ext(regFiles[idx])) {
+				regFiles[idx] = file.Name()
+			}
+			continue
+		}
+		found[id] = len(regFiles)
+
 		regFiles = append(regFiles, file.Name())
 	}
 
@@ -129,6 +168,7 @@ func Find(regDir, regType string) (*[]string, error) {
 
 // Load a registration file into the destination interface, returns boolean
 // indicating if the file was found and any error reading/parsing the file.
+// The format is based on the file extension (json, toml or yaml).
 func Load(regFile string, v interface{}) (bool, error) {
 	if regFile == "" {
 		return false, errors.Errorf("invalid registration file (empty)")
@@ -150,7 +190,7 @@ func Load(regFile string, v interface{}) (bool, error) {
 		return !os.IsNotExist(err), err
 	}
 
-	if err := json.Unmarshal(data, v); err != nil {
+	if err := decode(filepath.Ext(regFile), data, v); err != nil {
 		log.Warn().Err(err).Str("file", regFile).Msg("parsing")
 		return true, errors.Wrapf(err, "parsing registration (%s)", regFile)
 	}
@@ -6,8 +6,10 @@
 package regfiles
 
 import (
+	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -59,6 +61,23 @@ func TestSave(t *testing.T) {
 			}
 		})
 	}
+
+	t.Log("valid overwrite (shorter)")
+	{
+		if err := Save(file, &circapi.CheckBundle{CID: "/check_bundle/1234", DisplayName: "foo"}, true); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if err := Save(file, &circapi.CheckBundle{CID: "t"}, true); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		var b circapi.CheckBundle
+		if _, err := Load(file, &b); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if b.CID != "t" {
+			t.Fatalf("unexpected cid (%s)", b.CID)
+		}
+	}
 }
 
 func TestFind(t *testing.T) {
@@ -162,3 +181,112 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestFormats(t *testing.T) {
+	t.Log("Testing Save/Load formats")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-regfiles-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	units := "ops"
+	bundle := &circapi.CheckBundle{
+		CID:          "/check_bundle/123",
+		Checks:       []string{"/check/123"},
+		CheckUUIDs:   []string{"5f2a4b8e-1c3d-4e5f-8a9b-0c1d2e3f4a5b"},
+		Config:       circapi.CheckBundleConfig{"url": "http://127.0.0.1:2609/"},
+		DisplayName:  "db01 cosi/system",
+		LastModified: 1513175196,
+		Metrics: []circapi.CheckBundleMetric{
+			{Name: "cpu`idle", Status: "active", Type: "numeric", Tags: []string{"cosi:install"}, Units: &units},
+			{Name: "load`1min", Status: "active", Type: "numeric", Tags: []string{"cosi:install"}},
+		},
+		Period:  60,
+		Tags:    []string{"cosi:install"},
+		Timeout: 10.5,
+		Type:    "json:nad",
+	}
+
+	for _, ext := range Extensions {
+		ext := ext
+		t.Run(ext, func(t *testing.T) {
+			file := path.Join(dir, "registration-check-system"+ext)
+			if err := Save(file, bundle, false); err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			var loaded circapi.CheckBundle
+			found, err := Load(file, &loaded)
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if !found {
+				t.Fatal("expected found")
+			}
+			if !reflect.DeepEqual(*bundle, loaded) {
+				t.Fatalf("loaded registration differs\n%#v\n%#v", *bundle, loaded)
+			}
+		})
+	}
+
+	t.Log("invalid (yaml)")
+	{
+		file := path.Join(dir, "registration-bad.yaml")
+		if err := ioutil.WriteFile(file, []byte("_cid: [\n"), 0644); err != nil {
+			t.Fatalf("writing file (%s)", err)
+		}
+		var v circapi.CheckBundle
+		if _, err := Load(file, &v); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+}
+
+func TestFindFormats(t *testing.T) {
+	t.Log("Testing Find/File/ID formats")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	regDir := "testdata/formats"
+	files, err := Find(regDir, "graph")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	expected := []string{"registration-graph-a.json", "registration-graph-b.toml", "registration-graph-c.yaml"}
+	if !reflect.DeepEqual(*files, expected) {
+		t.Fatalf("unexpected files (%v)", *files)
+	}
+
+	tests := []struct {
+		id   string
+		file string
+		cid  string
+	}{
+		{"graph-a", "testdata/formats/registration-graph-a.json", "/graph/a"},
+		{"graph-b", "testdata/formats/registration-graph-b.toml", "/graph/b"},
+		{"graph-c", "testdata/formats/registration-graph-c.yaml", "/graph/c"},
+		{"graph-d", "testdata/formats/registration-graph-d.json", ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.id, func(t *testing.T) {
+			file := File(regDir, tst.id)
+			if file != tst.file {
+				t.Fatalf("unexpected file (%s)", file)
+			}
+			if id := ID(file); id != tst.id {
+				t.Fatalf("unexpected id (%s)", id)
+			}
+			var g circapi.Graph
+			found, err := Load(file, &g)
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if found != (tst.cid != "") || g.CID != tst.cid {
+				t.Fatalf("unexpected registration (%t) %s", found, g.CID)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@
 package registration
 
 import (
+	"os"
 	"path/filepath"
 	"time"
 
@@ -42,6 +43,30 @@ const (
 	// KeyShowConfig flags the registration options config should be dumped and
 	// `cosi register` should then exit.
 	KeyShowConfig = "register.show_config"
+
+	// KeyBrokerSelect defines the method used to select a broker when more
+	// than one is viable (random|latency). Overrides brokers.select in regconf.
+	KeyBrokerSelect = "register.broker_select"
+
+	// KeyBrokerMaxResponseTime defines the maximum time to wait for a tcp
+	// connection to a broker (e.g. 500ms). Overrides brokers.max_response_time
+	// in regconf.
+	KeyBrokerMaxResponseTime = "register.broker_max_response_time"
+
+	// KeyRollbackOnError enables deleting assets created during a
+	// registration run if the run fails.
+	KeyRollbackOnError = "register.rollback_on_error"
+
+	// KeyDryRun enables a dry run, assets are rendered but not created. The
+	// would-be API payloads are written to the directory or, if "-", stdout.
+	KeyDryRun = "register.dry_run"
+
+	// KeyUpdate enables update mode, existing graphs, worksheets and
+	// dashboards are compared to the current templates and updated if they
+	// differ. Assets modified since registration are not updated.
+	KeyUpdate = "register.update"
+
+	defaultMaxBrokerResponseTime = 500 * time.Millisecond
 )
 
 // Registration defines the registration client
@@ -58,24 +83,72 @@ type Registration struct {
 	worksheetList         map[string]*circapi.Worksheet
 	templates             *templates.Templates
 	maxBrokerResponseTime time.Duration
+	brokerType            string
+	brokerSelect          string
+	dryRun                bool
+	rollbackOnError       bool
+	update                bool
+	plugin                bool   // registering plugin assets on a registered system
+	dryRunTmpDir          string // removed after a dry run to stdout
+	renderID              string // template being rendered
+	renderAPI             CircAPI
 	logger                zerolog.Logger
 }
 
 // New creates a new registration client
 func New(circClient CircAPI) (*Registration, error) {
+	r, err := newRegistration(circClient)
+	if err != nil {
+		return nil, err
+	}
+
+	r.rollbackOnError = viper.GetBool(KeyRollbackOnError)
+	r.update = viper.GetBool(KeyUpdate)
+
+	if dest := viper.GetString(KeyDryRun); dest != "" {
+		dr, regDir, err := newDryRun(dest, os.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		r.cliCirc = dr
+		r.regDir = regDir
+		r.dryRun = true
+		if dest == dryRunStdout {
+			r.dryRunTmpDir = regDir
+		}
+		r.logger.Info().Str("dest", dest).Msg("dry run, assets will NOT be created")
+	}
+
+	// configure and finalize registration setup
+	if err := r.configure(); err != nil {
+		if r.dryRunTmpDir != "" {
+			os.RemoveAll(r.dryRunTmpDir)
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// newRegistration creates the base registration client
+func newRegistration(circClient CircAPI) (*Registration, error) {
 	if circClient == nil {
 		return nil, errors.New("invalid state, nil Circonus API client")
 	}
 
-	cosiClient, err := cosiapi.New(&cosiapi.Config{
-		OSType:    viper.GetString(config.KeySystemOSType),
-		OSDistro:  viper.GetString(config.KeySystemOSDistro),
-		OSVersion: viper.GetString(config.KeySystemOSVersion),
-		SysArch:   viper.GetString(config.KeySystemArch),
-		CosiURL:   viper.GetString(config.KeyCosiURL),
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "creating cosi API client")
+	var cosiClient CosiAPI = offlineCosi{}
+	if !viper.GetBool(config.KeyOffline) {
+		cli, err := cosiapi.New(&cosiapi.Config{
+			OSType:    viper.GetString(config.KeySystemOSType),
+			OSDistro:  viper.GetString(config.KeySystemOSDistro),
+			OSVersion: viper.GetString(config.KeySystemOSVersion),
+			SysArch:   viper.GetString(config.KeySystemArch),
+			CosiURL:   viper.GetString(config.KeyCosiURL),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating cosi API client")
+		}
+		cosiClient = cli
 	}
 
 	t, err := templates.New(cosiClient)
@@ -93,25 +166,75 @@ func New(circClient CircAPI) (*Registration, error) {
 		rulesetList:           make(map[string]*circapi.RuleSet),
 		templateList:          make(map[string]bool),
 		worksheetList:         make(map[string]*circapi.Worksheet),
-		maxBrokerResponseTime: time.Millisecond * 500, // configurable option?
+		maxBrokerResponseTime: defaultMaxBrokerResponseTime,
+		brokerSelect:          brokerSelectRandom,
 		logger:                log.With().Str("cmd", "register").Logger(),
 	}
 
-	// configure and finalize registration setup
-	if err := r.configure(); err != nil {
-		return nil, err
-	}
-
 	return r, nil
 }
 
-// Register initiates registering the system
+// Register initiates registering the system. Every asset created is
+// recorded in a journal, if registration fails and rollback on error is
+// enabled, the assets created and their registration files are removed.
 func (r *Registration) Register() error {
+	if r.dryRunTmpDir != "" {
+		defer os.RemoveAll(r.dryRunTmpDir)
+	}
+
+	j, err := newJournal(r.regDir)
+	if err != nil {
+		return err
+	}
+
+	var state *regState
+	if !r.dryRun {
+		state, err = loadState(r.regDir)
+		if err != nil {
+			return err
+		}
+		if state.isResumed() {
+			r.logger.Info().Str("state", state.file).Msg("resuming previous registration")
+		}
+	}
+
+	regErr := r.register(&journalAPI{CircAPI: r.cliCirc, journal: j}, state)
+	if regErr == nil {
+		return state.remove()
+	}
+
+	created := j.list()
+	if len(created) == 0 || r.dryRun {
+		return regErr
+	}
+
+	if !r.rollbackOnError {
+		for _, entry := range created {
+			r.logger.Warn().Str("type", entry.Type).Str("cid", entry.CID).Msg("asset created before failure, not rolled back")
+		}
+		return regErr
+	}
+
+	r.logger.Warn().Err(regErr).Int("assets", len(created)).Msg("registration failed, rolling back")
+	if err := r.rollback(j); err != nil {
+		return errors.Wrapf(regErr, "registration failed (%s)", err)
+	}
+	if err := state.restore(); err != nil {
+		return errors.Wrapf(regErr, "registration failed, rolled back (%s)", err)
+	}
+
+	return errors.Wrap(regErr, "registration failed, rolled back")
+}
+
+// register creates the registration assets using the supplied client,
+// steps (and items within steps) already recorded as complete in the
+// state are skipped
+func (r *Registration) register(client CircAPI, state *regState) error {
 	var gi *map[string]graphs.GraphInfo
 	var ci *checks.CheckInfo
 
 	c, err := checks.New(&checks.Options{
-		Client:    r.cliCirc,
+		Client:    client,
 		Config:    r.config,
 		RegDir:    r.regDir,
 		Templates: r.templates,
@@ -121,9 +244,17 @@ func (r *Registration) Register() error {
 	}
 
 	{ // create checks
+		// always run, existing check registrations are loaded and the
+		// check info is needed by all subsequent steps
+		if state.isComplete(stepChecks) {
+			r.logger.Info().Str("step", stepChecks).Msg("already complete, reloading check registrations")
+		}
 		if err := c.Register(); err != nil {
 			return err
 		}
+		if err := state.complete(stepChecks); err != nil {
+			return err
+		}
 
 		ci, err = c.GetCheckInfo("system")
 		if err != nil {
@@ -133,24 +264,53 @@ func (r *Registration) Register() error {
 
 	{ // create graphs
 		g, err := graphs.New(&graphs.Options{
-			Client:    r.cliCirc,
+			Client:    client,
 			Config:    r.config,
 			RegDir:    r.regDir,
 			Templates: r.templates,
 			CheckInfo: ci,
 			Metrics:   r.availableMetrics,
+			Update:    r.update,
+			OnComplete: func(id string) {
+				if err := state.itemComplete(stepGraphs, id); err != nil {
+					r.logger.Warn().Err(err).Str("id", id).Msg("recording graph progress")
+				}
+			},
 		})
 		if err != nil {
 			return err
 		}
 
-		if err = g.Register(r.templateList); err != nil {
+		// existing graphs are needed for graph info and the metric list
+		if err := g.LoadRegistrations(); err != nil {
 			return err
 		}
 
+		if state.isComplete(stepGraphs) {
+			r.logSkipped(stepGraphs)
+		} else {
+			pending, done := state.pending(stepGraphs, r.templateList)
+			for _, id := range done {
+				r.logger.Info().Str("step", stepGraphs).Str("id", id).Msg("skipping, already complete")
+			}
+			if err = g.Register(pending); err != nil {
+				return err
+			}
+			if err := state.complete(stepGraphs); err != nil {
+				return err
+			}
+		}
+
 		// enable any new metrics
-		if err := c.UpdateSystemCheck(g.GetMetricList()); err != nil {
-			return err
+		if state.isComplete(stepGraphMetrics) {
+			r.logSkipped(stepGraphMetrics)
+		} else {
+			if err := c.UpdateSystemCheck(g.GetMetricList()); err != nil {
+				return err
+			}
+			if err := state.complete(stepGraphMetrics); err != nil {
+				return err
+			}
 		}
 
 		gi, err = g.GetGraphInfo()
@@ -159,48 +319,84 @@ func (r *Registration) Register() error {
 		}
 	}
 
-	{ // create worksheet(s)
+	if state.isComplete(stepWorksheets) {
+		r.logSkipped(stepWorksheets)
+	} else { // create worksheet(s)
 		w, err := worksheets.New(&worksheets.Options{
-			Client:    r.cliCirc,
+			Client:    client,
 			Config:    r.config,
 			RegDir:    r.regDir,
 			Templates: r.templates,
+			CheckInfo: ci,
+			GraphInfo: gi,
+			Update:    r.update,
+			OnComplete: func(id string) {
+				if err := state.itemComplete(stepWorksheets, id); err != nil {
+					r.logger.Warn().Err(err).Str("id", id).Msg("recording worksheet progress")
+				}
+			},
 		})
 		if err != nil {
 			return err
 		}
-		if err := w.Register(r.templateList); err != nil {
+		pending, done := state.pending(stepWorksheets, r.templateList)
+		for _, id := range done {
+			r.logger.Info().Str("step", stepWorksheets).Str("id", id).Msg("skipping, already complete")
+		}
+		if err := w.Register(pending); err != nil {
+			return err
+		}
+		if err := state.complete(stepWorksheets); err != nil {
 			return err
 		}
 	}
 
-	{ // create dashboard(s)
+	if state.isComplete(stepDashboards) && state.isComplete(stepDashboardMetrics) {
+		r.logSkipped(stepDashboards)
+		r.logSkipped(stepDashboardMetrics)
+	} else { // create dashboard(s)
 		d, err := dashboards.New(&dashboards.Options{
-			Client:    r.cliCirc,
+			Client:    client,
 			Config:    r.config,
 			RegDir:    r.regDir,
 			Templates: r.templates,
 			CheckInfo: ci,
 			GraphInfo: gi,
 			Metrics:   r.availableMetrics,
+			Update:    r.update,
+			OnComplete: func(id string) {
+				if err := state.itemComplete(stepDashboards, id); err != nil {
+					r.logger.Warn().Err(err).Str("id", id).Msg("recording dashboard progress")
+				}
+			},
 		})
 		if err != nil {
 			return err
 		}
+		// the full list is used, existing dashboards are loaded from their
+		// registration files, the metric list requires all dashboards
 		if err := d.Register(r.templateList); err != nil {
 			return err
 		}
+		if err := state.complete(stepDashboards); err != nil {
+			return err
+		}
 
 		// enable any new metrics
 		if err := c.UpdateSystemCheck(d.GetMetricList()); err != nil {
 			return err
 		}
+		if err := state.complete(stepDashboardMetrics); err != nil {
+			return err
+		}
 	}
 
-	{ // create ruleset(s)
+	if state.isComplete(stepRulesets) {
+		r.logSkipped(stepRulesets)
+	} else { // create ruleset(s)
 		rs, err := rulesets.New(&rulesets.Options{
 			CheckInfo:  ci,
-			Client:     r.cliCirc,
+			Client:     client,
 			Config:     r.config,
 			RegDir:     r.regDir,
 			RulesetDir: filepath.Join(defaults.BasePath, "rulesets"),
@@ -211,9 +407,22 @@ func (r *Registration) Register() error {
 		if err := rs.Register(); err != nil {
 			return err
 		}
+		if err := state.complete(stepRulesets); err != nil {
+			return err
+		}
+	}
+
+	if r.dryRun {
+		r.logger.Info().Msg("dry run complete, no assets were created")
+		return nil
 	}
 
 	r.logger.Info().Msg("registration complete")
 
 	return nil
 }
+
+// logSkipped reports a registration step skipped when resuming
+func (r *Registration) logSkipped(step string) {
+	r.logger.Info().Str("step", step).Msg("skipping, already complete")
+}
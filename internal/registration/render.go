@@ -0,0 +1,199 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/circonus-labs/cosi-tool/internal/config/defaults"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/dashboards"
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
+	"github.com/circonus-labs/cosi-tool/internal/registration/worksheets"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	"github.com/pkg/errors"
+)
+
+// NewRender creates a registration client for previewing a template. The
+// template is expanded against the metrics available from the agent and
+// the would-be API payloads for the template are written to w. Nothing is
+// created, the Circonus API is not used and existing registrations are
+// ignored (the cached templates and dashboard meta files are used).
+func NewRender(templateID string, w io.Writer) (*Registration, error) {
+	if templateID == "" {
+		return nil, errors.New("invalid template id (empty)")
+	}
+	if w == nil {
+		return nil, errors.New("invalid output (nil)")
+	}
+	switch renderKind(templateID) {
+	case "check", "dashboard", "graph", "worksheet":
+	default:
+		return nil, errors.Errorf("invalid template id (%s) - must be check-*, dashboard-*, graph-* or worksheet-*", templateID)
+	}
+
+	regDir, err := ioutil.TempDir("", "cosi-render")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating render registration directory")
+	}
+	if err := seedRenderDir(defaults.RegPath, regDir); err != nil {
+		os.RemoveAll(regDir)
+		return nil, err
+	}
+
+	// assets the template depends on (e.g. the system check) are rendered
+	// but the payloads are discarded
+	r, err := newRegistration(&dryRunAPI{out: ioutil.Discard})
+	if err != nil {
+		os.RemoveAll(regDir)
+		return nil, err
+	}
+	r.regDir = regDir
+	r.dryRun = true
+	r.dryRunTmpDir = regDir
+	r.renderID = templateID
+	r.renderAPI = &dryRunAPI{out: w}
+
+	if err := r.configure(); err != nil {
+		os.RemoveAll(regDir)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Render writes the expanded payloads for the template
+func (r *Registration) Render() error {
+	if r.renderID == "" {
+		return errors.New("invalid state, not a render registration")
+	}
+	defer os.RemoveAll(r.dryRunTmpDir)
+
+	kind := renderKind(r.renderID)
+	client := func(assetKind string) CircAPI {
+		if assetKind == kind {
+			return r.renderAPI
+		}
+		return r.cliCirc
+	}
+
+	c, err := checks.New(&checks.Options{
+		Client:    client("check"),
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.Register(); err != nil {
+		return err
+	}
+	if kind == "check" {
+		return nil
+	}
+	ci, err := c.GetCheckInfo("system")
+	if err != nil {
+		return errors.Wrap(err, "unable to get system check info")
+	}
+
+	if kind == "worksheet" {
+		w, err := worksheets.New(&worksheets.Options{
+			Client:    client("worksheet"),
+			Config:    r.config,
+			RegDir:    r.regDir,
+			Templates: r.templates,
+			CheckInfo: ci,
+		})
+		if err != nil {
+			return err
+		}
+		return w.Register(map[string]bool{r.renderID: true})
+	}
+
+	// dashboard widgets link to graphs, render the default graphs
+	// for the system so the widgets can be expanded
+	graphList := map[string]bool{}
+	if kind == "graph" {
+		graphList[r.renderID] = true
+	} else {
+		list, err := templates.DefaultTemplateList(r.availableMetrics)
+		if err != nil {
+			return errors.Wrap(err, "setting default template list")
+		}
+		for _, id := range *list {
+			if renderKind(id) == "graph" {
+				graphList[id] = true
+			}
+		}
+	}
+
+	g, err := graphs.New(&graphs.Options{
+		Client:    client("graph"),
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+		CheckInfo: ci,
+		Metrics:   r.availableMetrics,
+	})
+	if err != nil {
+		return err
+	}
+	if err := g.Register(graphList); err != nil {
+		return err
+	}
+	if kind == "graph" {
+		return nil
+	}
+	gi, err := g.GetGraphInfo()
+	if err != nil {
+		return err
+	}
+
+	d, err := dashboards.New(&dashboards.Options{
+		Client:    client("dashboard"),
+		Config:    r.config,
+		RegDir:    r.regDir,
+		Templates: r.templates,
+		CheckInfo: ci,
+		GraphInfo: gi,
+		Metrics:   r.availableMetrics,
+	})
+	if err != nil {
+		return err
+	}
+	return d.Register(map[string]bool{r.renderID: true})
+}
+
+// renderKind returns the asset kind for a template id (e.g. graph for graph-cpu)
+func renderKind(templateID string) string {
+	return strings.SplitN(templateID, "-", 2)[0]
+}
+
+// seedRenderDir copies the cached templates and dashboard meta files from
+// the registration directory to the render registration directory
+func seedRenderDir(src, dst string) error {
+	for _, pattern := range []string{"template-*.toml", "dashboard-*.json", "meta-*.json"} {
+		files, err := filepath.Glob(filepath.Join(src, pattern))
+		if err != nil {
+			return errors.Wrap(err, "finding files to render")
+		}
+		for _, fn := range files {
+			data, err := ioutil.ReadFile(fn)
+			if err != nil {
+				return errors.Wrap(err, "reading file to render")
+			}
+			if err := ioutil.WriteFile(filepath.Join(dst, filepath.Base(fn)), data, 0644); err != nil {
+				return errors.Wrap(err, "copying file to render")
+			}
+		}
+	}
+	return nil
+}
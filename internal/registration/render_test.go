@@ -0,0 +1,123 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/cosi-tool/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+func TestNewRender(t *testing.T) {
+	t.Log("Testing NewRender")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tests := []struct {
+		name        string
+		id          string
+		nilOut      bool
+		expectedErr string
+	}{
+		{"invalid (empty id)", "", false, "invalid template id (empty)"},
+		{"invalid (nil output)", "graph-cpu", true, "invalid output (nil)"},
+		{"invalid (id)", "foo-bar", false, "invalid template id (foo-bar) - must be check-*, dashboard-*, graph-* or worksheet-*"},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var err error
+			if tst.nilOut {
+				_, err = NewRender(tst.id, nil)
+			} else {
+				_, err = NewRender(tst.id, &buf)
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != tst.expectedErr {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+		})
+	}
+
+	t.Log("invalid (not render)")
+	{
+		r := &Registration{}
+		err := r.Render()
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid state, not a render registration" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Log("Testing Render")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	metrics := map[string]interface{}{
+		"disk`sda`reads":  map[string]interface{}{"_type": "L", "_value": 1},
+		"disk`sdb`reads":  map[string]interface{}{"_type": "L", "_value": 1},
+		"disk`dm-0`reads": map[string]interface{}{"_type": "L", "_value": 1},
+	}
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(metrics)
+	}))
+	defer agent.Close()
+
+	settings := map[string]string{
+		config.KeyAgentURL:        agent.URL,
+		config.KeyCosiID:          "1b1b1b1b-2c2c-3d3d-4e4e-5f5f5f5f5f5f",
+		config.KeyTemplateDir:     "testdata/render",
+		config.KeyCosiURL:         "http://127.0.0.1:1/",
+		config.KeySystemOSType:    "linux",
+		config.KeySystemOSDistro:  "ubuntu",
+		config.KeySystemOSVersion: "18.04",
+		config.KeySystemArch:      "x86_64",
+	}
+	for k, v := range settings {
+		viper.Set(k, v)
+	}
+	defer func() {
+		for k := range settings {
+			viper.Set(k, "")
+		}
+	}()
+
+	var buf bytes.Buffer
+	r, err := NewRender("graph-disk", &buf)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := r.Render(); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "check_bundle") {
+		t.Fatalf("unexpected check payload (%s)", out)
+	}
+	for _, item := range []string{"sda", "sdb"} {
+		if !strings.Contains(out, "disk "+item) {
+			t.Fatalf("expected graph for %s (%s)", item, out)
+		}
+	}
+	if strings.Contains(out, "dm-0") {
+		t.Fatalf("expected dm-0 to be filtered (%s)", out)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/pkg/errors"
+)
+
+const (
+	// stateFileName is the registration progress state file, it only
+	// exists while a registration is incomplete
+	stateFileName = "register-state.json"
+
+	stepChecks           = "checks"
+	stepGraphs           = "graphs"
+	stepGraphMetrics     = "graph_metrics"
+	stepWorksheets       = "worksheets"
+	stepDashboards       = "dashboards"
+	stepDashboardMetrics = "dashboard_metrics"
+	stepRulesets         = "rulesets"
+)
+
+// stepState is the progress of a single registration step
+type stepState struct {
+	Complete  bool      `json:"complete"`
+	Completed time.Time `json:"completed,omitempty"`
+	Items     []string  `json:"items,omitempty"` // template ids completed within the step
+}
+
+// regState records per-step registration progress so that a failed
+// registration can be resumed. A nil state is a no-op (e.g. dry run).
+type regState struct {
+	Steps      map[string]*stepState `json:"steps"`
+	file       string
+	orig       []byte // state file content when loaded, nil if it did not exist
+	resumed    bool
+	sync.Mutex `json:"-"`
+}
+
+// loadState reads the state file from the registration directory, if
+// there is no state file, an empty state is returned
+func loadState(regDir string) (*regState, error) {
+	if regDir == "" {
+		return nil, errors.New("invalid registration directory (empty)")
+	}
+
+	s := &regState{
+		Steps: make(map[string]*stepState),
+		file:  filepath.Join(regDir, stateFileName),
+	}
+
+	data, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, errors.Wrap(err, "reading registration state")
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "parsing registration state (%s)", s.file)
+	}
+	if s.Steps == nil {
+		s.Steps = make(map[string]*stepState)
+	}
+	s.orig = data
+	s.resumed = true
+
+	return s, nil
+}
+
+// isResumed returns true if the state was loaded from a previous, incomplete, registration
+func (s *regState) isResumed() bool {
+	if s == nil {
+		return false
+	}
+	return s.resumed
+}
+
+// isComplete returns true if the step has been completed
+func (s *regState) isComplete(step string) bool {
+	if s == nil {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	st, ok := s.Steps[step]
+	return ok && st.Complete
+}
+
+// complete marks a step as completed and saves the state
+func (s *regState) complete(step string) error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	st := s.step(step)
+	st.Complete = true
+	st.Completed = time.Now()
+	return s.save()
+}
+
+// itemComplete records a template id as completed within a step and saves the state
+func (s *regState) itemComplete(step, id string) error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	st := s.step(step)
+	for _, item := range st.Items {
+		if item == id {
+			return nil
+		}
+	}
+	st.Items = append(st.Items, id)
+	return s.save()
+}
+
+// items returns the template ids completed within a step
+func (s *regState) items(step string) []string {
+	if s == nil {
+		return []string{}
+	}
+	s.Lock()
+	defer s.Unlock()
+	st, ok := s.Steps[step]
+	if !ok {
+		return []string{}
+	}
+	items := make([]string, len(st.Items))
+	copy(items, st.Items)
+	return items
+}
+
+// remove deletes the state file, called once registration is complete
+func (s *regState) remove() error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if err := os.Remove(s.file); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing registration state")
+	}
+	return nil
+}
+
+// restore puts the state file back the way it was when loaded, called
+// when the assets created during a run have been rolled back
+func (s *regState) restore() error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.orig == nil {
+		if err := os.Remove(s.file); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing registration state")
+		}
+		return nil
+	}
+	if err := ioutil.WriteFile(s.file, s.orig, 0644); err != nil {
+		return errors.Wrap(err, "restoring registration state")
+	}
+	return nil
+}
+
+// pending returns a copy of the template list with the items already
+// completed for a step removed
+func (s *regState) pending(step string, list map[string]bool) (map[string]bool, []string) {
+	done := s.items(step)
+	pending := make(map[string]bool, len(list))
+	for k, v := range list {
+		pending[k] = v
+	}
+	for _, id := range done {
+		delete(pending, id)
+	}
+	return pending, done
+}
+
+// step returns the state for a step, creating it if needed (caller must hold lock)
+func (s *regState) step(step string) *stepState {
+	st, ok := s.Steps[step]
+	if !ok {
+		st = &stepState{}
+		s.Steps[step] = st
+	}
+	return st
+}
+
+// save writes the state file (caller must hold lock)
+func (s *regState) save() error {
+	if err := regfiles.Save(s.file, s, true); err != nil {
+		return errors.Wrap(err, "saving registration state")
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package registration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLoadState(t *testing.T) {
+	t.Log("Testing loadState")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-state-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Log("invalid (empty dir)")
+	{
+		_, err := loadState("")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid registration directory (empty)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("no state file")
+	{
+		s, err := loadState(dir)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if s.isResumed() {
+			t.Fatal("expected not resumed")
+		}
+		if s.isComplete(stepChecks) {
+			t.Fatal("expected checks not complete")
+		}
+	}
+
+	t.Log("invalid state file")
+	{
+		if err := ioutil.WriteFile(filepath.Join(dir, stateFileName), []byte("{"), 0644); err != nil {
+			t.Fatalf("writing state file (%s)", err)
+		}
+		_, err := loadState(dir)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expect := "parsing registration state (" + filepath.Join(dir, stateFileName) + "): unexpected end of JSON input"
+		if err.Error() != expect {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		os.Remove(filepath.Join(dir, stateFileName))
+	}
+}
+
+func TestRegState(t *testing.T) {
+	t.Log("Testing regState")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-state-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := loadState(dir)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if err := s.complete(stepChecks); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := s.itemComplete(stepGraphs, "graph-cpu"); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if err := s.itemComplete(stepGraphs, "graph-cpu"); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	t.Log("resume")
+	{
+		rs, err := loadState(dir)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !rs.isResumed() {
+			t.Fatal("expected resumed")
+		}
+		if !rs.isComplete(stepChecks) {
+			t.Fatal("expected checks complete")
+		}
+		if rs.isComplete(stepGraphs) {
+			t.Fatal("expected graphs not complete")
+		}
+
+		list := map[string]bool{"check-system": true, "graph-cpu": true, "graph-vm": true}
+		pending, done := rs.pending(stepGraphs, list)
+		if len(done) != 1 || done[0] != "graph-cpu" {
+			t.Fatalf("expected [graph-cpu] got %v", done)
+		}
+		if _, ok := pending["graph-cpu"]; ok {
+			t.Fatalf("expected graph-cpu to be removed (%v)", pending)
+		}
+		if len(pending) != 2 {
+			t.Fatalf("expected 2 pending got %v", pending)
+		}
+		if len(list) != 3 {
+			t.Fatalf("expected original list unchanged (%v)", list)
+		}
+
+		t.Log("restore")
+		if err := rs.complete(stepGraphs); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if err := rs.restore(); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		rs2, err := loadState(dir)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if rs2.isComplete(stepGraphs) {
+			t.Fatal("expected graphs not complete after restore")
+		}
+	}
+
+	t.Log("remove")
+	{
+		if err := s.remove(); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, stateFileName)); !os.IsNotExist(err) {
+			t.Fatalf("expected state file to be removed (%v)", err)
+		}
+		if err := s.remove(); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("nil state (dry run)")
+	{
+		var ns *regState
+		if ns.isComplete(stepChecks) {
+			t.Fatal("expected not complete")
+		}
+		if err := ns.complete(stepChecks); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		pending, done := ns.pending(stepGraphs, map[string]bool{"graph-cpu": true})
+		if len(done) != 0 || len(pending) != 1 {
+			t.Fatalf("unexpected pending %v done %v", pending, done)
+		}
+	}
+}
@@ -6,14 +6,14 @@
 package worksheets
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"path"
 	"strings"
 
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/registration/checks"
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
 	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	"github.com/circonus-labs/cosi-tool/internal/templates"
@@ -24,6 +24,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	systemWorksheetID = "worksheet-system"
+)
+
 // Worksheets defines the registration instance
 type Worksheets struct {
 	worksheetList map[string]*circapi.Worksheet
@@ -31,7 +35,11 @@ type Worksheets struct {
 	config        *options.Options
 	regDir        string
 	templates     *templates.Templates
+	checkInfo     *checks.CheckInfo
+	graphInfo     map[string]graphs.GraphInfo
 	regFiles      *[]string
+	onComplete    func(id string)
+	update        bool
 	logger        zerolog.Logger
 }
 
@@ -41,6 +49,17 @@ type Options struct {
 	Config    *options.Options
 	RegDir    string
 	Templates *templates.Templates
+	// OnComplete is optional, called with the template id once the
+	// worksheet for the template has been created
+	OnComplete func(id string)
+	// Update existing worksheets which differ from the current template
+	Update bool
+	// CheckInfo is optional, the system check variables (e.g. CheckUUID)
+	// are only available to worksheet templates when it is set
+	CheckInfo *checks.CheckInfo
+	// GraphInfo is optional, worksheets referencing graphs which have been
+	// recreated (e.g. deleted in the UI) are re-linked to the new graphs
+	GraphInfo *map[string]graphs.GraphInfo
 }
 
 // New creates a new Worksheets instance
@@ -66,13 +85,22 @@ func New(o *Options) (*Worksheets, error) {
 		return nil, errors.Wrap(err, "finding worksheet registrations")
 	}
 
+	var gi map[string]graphs.GraphInfo
+	if o.GraphInfo != nil {
+		gi = *o.GraphInfo
+	}
+
 	w := Worksheets{
 		worksheetList: make(map[string]*circapi.Worksheet),
 		client:        o.Client,
 		config:        o.Config,
 		regDir:        o.RegDir,
 		templates:     o.Templates,
+		checkInfo:     o.CheckInfo,
+		graphInfo:     gi,
 		regFiles:      regs,
+		onComplete:    o.OnComplete,
+		update:        o.Update,
 		logger:        log.With().Str("cmd", "register.worksheets").Logger(),
 	}
 
@@ -101,10 +129,17 @@ func (w *Worksheets) Register(list map[string]bool) error {
 		if !create {
 			continue
 		}
+		if id == systemWorksheetID && !w.config.Worksheets.System.Create {
+			w.logger.Info().Str("id", id).Msg("disabled in regconf (worksheets.system.create), skipping")
+			continue
+		}
 
 		if err := w.create(id); err != nil {
 			return err
 		}
+		if w.onComplete != nil {
+			w.onComplete(id)
+		}
 	}
 
 	return nil
@@ -125,21 +160,19 @@ func (w *Worksheets) create(id string) error {
 	}
 
 	// set up the template expansion data
-	type templateVars struct {
-		HostName string
-	}
-	tvars := templateVars{
-		HostName: w.config.Host.Name,
-	}
+	tvars := w.config.TemplateVars()
+	w.checkInfo.AddTemplateVars(tvars)
 
 	for cfgName, wcfg := range t.Configs {
 		worksheetID := id + "-" + cfgName
 
 		w.logger.Info().Str("id", worksheetID).Msg("building worksheet")
 
-		if loaded, err := w.checkForRegistration(worksheetID); err != nil {
+		loaded, err := w.checkForRegistration(worksheetID)
+		if err != nil {
 			return err
-		} else if loaded {
+		}
+		if loaded && !w.update {
 			continue
 		}
 
@@ -169,6 +202,12 @@ func (w *Worksheets) create(id string) error {
 		}
 		cfg.Notes = &notes
 
+		if id == systemWorksheetID {
+			if err := w.applyOverrides(cfg, tvars); err != nil {
+				return err
+			}
+		}
+
 		if e := log.Debug(); e.Enabled() {
 			cfgFile := path.Join(w.regDir, "config-"+worksheetID+".json")
 			w.logger.Debug().Str("cfg_file", cfgFile).Msg("saving registration config")
@@ -177,6 +216,13 @@ func (w *Worksheets) create(id string) error {
 			}
 		}
 
+		if loaded {
+			if err := w.updateWorksheet(worksheetID, cfg); err != nil {
+				return err
+			}
+			continue
+		}
+
 		sheet, err := worksheet.Create(w.client, cfg)
 		if err != nil {
 			return err
@@ -192,8 +238,10 @@ func (w *Worksheets) create(id string) error {
 }
 
 // checkForRegistration looks through existing registration files and
-// if the id is found, it is loaded. Returns a boolean indicating
-// if the registration was found+loaded successfully or an error
+// if the id is found, it is loaded and verified to still exist via the
+// API. Returns a boolean indicating if the registration was found+loaded
+// successfully or an error. A registration for a worksheet which no longer
+// exists is ignored so that the worksheet will be recreated.
 func (w *Worksheets) checkForRegistration(id string) (bool, error) {
 	if id == "" {
 		return false, errors.New("invalid id (empty)")
@@ -210,6 +258,23 @@ func (w *Worksheets) checkForRegistration(id string) (bool, error) {
 			return false, errors.Wrapf(err, "loading %s", regFileSig)
 		}
 		if found {
+			current, err := w.verifyRegistration(id, &ws)
+			if err != nil {
+				return false, err
+			}
+			if current == nil {
+				return false, nil
+			}
+			relinked, err := w.relinkGraphs(id, current)
+			if err != nil {
+				return false, err
+			}
+			if relinked != nil {
+				if err := regfiles.Save(path.Join(w.regDir, rf), relinked, true); err != nil {
+					return false, errors.Wrapf(err, "saving %s registration", id)
+				}
+				ws = *relinked
+			}
 			w.worksheetList[regFileSig] = &ws
 			return found, nil
 		}
@@ -230,31 +295,74 @@ func parseTemplateConfig(id, templateCfg string, templateVars interface{}) (*cir
 		return nil, errors.New("invalid template vars (nil)")
 	}
 
-	// parse template
-	tmpl, err := template.New(id).Parse(templateCfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing template")
-	}
-	tmpl = tmpl.Option("missingkey=error")
 	// expand template w/data
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-	if err := tmpl.Execute(bw, templateVars); err != nil {
+	data, err := templates.Expand(id, templateCfg, templateVars)
+	if err != nil {
 		if e := log.Debug(); e.Enabled() {
 			fmt.Printf("%#v\n%#v\n", templateCfg, templateVars)
 		}
-		return nil, errors.Wrap(err, "executing template")
+		return nil, err
 	}
-	bw.Flush()
 
 	// create config
 	var ws circapi.Worksheet
-	if err := json.Unmarshal(b.Bytes(), &ws); err != nil {
+	if err := json.Unmarshal(data, &ws); err != nil {
 		if e := log.Debug(); e.Enabled() {
-			fmt.Println(b.String())
+			fmt.Println(string(data))
 		}
 		return nil, errors.Wrap(err, "parsing expanded template result")
 	}
 
 	return &ws, nil
 }
+
+// verifyRegistration confirms a registered worksheet still exists via the
+// API, returning the current worksheet or nil if it no longer exists
+func (w *Worksheets) verifyRegistration(id string, reg *circapi.Worksheet) (*circapi.Worksheet, error) {
+	if reg.CID == "" {
+		return nil, errors.Errorf("invalid registration for %s (empty cid)", id)
+	}
+	cid := reg.CID
+	ws, err := w.client.FetchWorksheet(circapi.CIDType(&cid))
+	if err != nil {
+		if apierr.IsNotFound(err) {
+			w.logger.Warn().Str("id", id).Str("cid", cid).Msg("registered worksheet no longer exists, recreating")
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "verifying %s registration (%s)", id, cid)
+	}
+	return ws, nil
+}
+
+// relinkGraphs points worksheet graphs which have been recreated at the
+// new graphs. Returns the updated worksheet or nil if no graphs needed
+// to be re-linked.
+func (w *Worksheets) relinkGraphs(id string, ws *circapi.Worksheet) (*circapi.Worksheet, error) {
+	replaced := make(map[string]string)
+	for _, gi := range w.graphInfo {
+		if gi.PrevUUID != "" {
+			replaced["/graph/"+gi.PrevUUID] = gi.CID
+		}
+	}
+	if len(replaced) == 0 {
+		return nil, nil
+	}
+
+	relinked := 0
+	for gidx, g := range ws.Graphs {
+		if newCID, ok := replaced[g.GraphCID]; ok {
+			w.logger.Info().Str("id", id).Str("old_graph", g.GraphCID).Str("new_graph", newCID).Msg("re-linking worksheet to recreated graph")
+			ws.Graphs[gidx].GraphCID = newCID
+			relinked++
+		}
+	}
+	if relinked == 0 {
+		return nil, nil
+	}
+
+	updated, err := w.client.UpdateWorksheet(ws)
+	if err != nil {
+		return nil, errors.Wrapf(err, "re-linking %s graphs", id)
+	}
+	return updated, nil
+}
@@ -10,6 +10,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/circonus-labs/cosi-tool/internal/registration/graphs"
 	"github.com/circonus-labs/cosi-tool/internal/registration/options"
 	"github.com/circonus-labs/cosi-tool/internal/templates"
 	circapi "github.com/circonus-labs/go-apiclient"
@@ -22,8 +23,20 @@ func genMockCircAPI() CircAPI {
 			if cfg.CID == "error" {
 				return nil, errors.New("forced mock api error")
 			}
+			if cfg.CID == "" {
+				cfg.CID = "/worksheet/" + cfg.Title
+			}
 			return cfg, nil
 		},
+		FetchWorksheetFunc: func(cid circapi.CIDType) (*circapi.Worksheet, error) {
+			switch *cid {
+			case "/worksheet/deleted":
+				return nil, errors.New("API response code 404: {\"code\":404}")
+			case "/worksheet/fetch-error":
+				return nil, errors.New("forced mock api error")
+			}
+			return &circapi.Worksheet{CID: *cid}, nil
+		},
 	}
 }
 
@@ -62,7 +75,7 @@ func TestNew(t *testing.T) {
 		tst := test
 		t.Run(tst.name, func(t *testing.T) {
 			t.Parallel()
-			_, err := New(&Options{tst.client, tst.config, tst.regDir, tst.templates})
+			_, err := New(&Options{tst.client, tst.config, tst.regDir, tst.templates, nil, false, nil, nil})
 			if tst.shouldFail {
 				if err == nil {
 					t.Fatal("expected error")
@@ -204,6 +217,8 @@ func TestCheckForRegistration(t *testing.T) {
 		{"missing", "worksheet-missing", false, false, ""},
 		{"error (parsing)", "worksheet-error", false, true, "loading registration-worksheet-error: parsing registration (testdata/registration-worksheet-error.json): unexpected end of JSON input"},
 		{"valid", "worksheet-valid", true, false, ""},
+		{"deleted (404)", "worksheet-deleted", false, false, ""},
+		{"verify error", "worksheet-fetch-error", false, true, "verifying worksheet-fetch-error registration (/worksheet/fetch-error): forced mock api error"},
 	}
 
 	for _, test := range tests {
@@ -235,6 +250,72 @@ func TestCheckForRegistration(t *testing.T) {
 	}
 }
 
+func TestRelinkGraphs(t *testing.T) {
+	t.Log("Testing relinkGraphs")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	client := genMockCircAPI().(*CircAPIMock)
+	client.UpdateWorksheetFunc = func(cfg *circapi.Worksheet) (*circapi.Worksheet, error) {
+		return cfg, nil
+	}
+
+	w, err := New(&Options{
+		Client:    client,
+		Config:    &options.Options{},
+		RegDir:    "testdata",
+		Templates: &templates.Templates{},
+		GraphInfo: &map[string]graphs.GraphInfo{
+			"graph-cpu": {CID: "/graph/new", UUID: "new", PrevUUID: "old"},
+			"graph-vm":  {CID: "/graph/vm", UUID: "vm"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create worksheets object (%s)", err)
+	}
+
+	t.Log("no recreated graphs referenced")
+	{
+		ws := &circapi.Worksheet{
+			CID:    "/worksheet/1",
+			Graphs: []circapi.WorksheetGraph{{GraphCID: "/graph/vm"}},
+		}
+		updated, err := w.relinkGraphs("worksheet-test", ws)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if updated != nil {
+			t.Fatal("expected nil (no update)")
+		}
+		if len(client.UpdateWorksheetCalls()) != 0 {
+			t.Fatal("expected no update calls")
+		}
+	}
+
+	t.Log("recreated graph referenced")
+	{
+		ws := &circapi.Worksheet{
+			CID:    "/worksheet/1",
+			Graphs: []circapi.WorksheetGraph{{GraphCID: "/graph/vm"}, {GraphCID: "/graph/old"}},
+		}
+		updated, err := w.relinkGraphs("worksheet-test", ws)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if updated == nil {
+			t.Fatal("expected updated worksheet")
+		}
+		if updated.Graphs[0].GraphCID != "/graph/vm" {
+			t.Fatalf("expected /graph/vm got (%s)", updated.Graphs[0].GraphCID)
+		}
+		if updated.Graphs[1].GraphCID != "/graph/new" {
+			t.Fatalf("expected /graph/new got (%s)", updated.Graphs[1].GraphCID)
+		}
+		if len(client.UpdateWorksheetCalls()) != 1 {
+			t.Fatal("expected 1 update call")
+		}
+	}
+}
+
 func TestParseTemplateConfig(t *testing.T) {
 	t.Log("Testing parseTemplateConfig")
 
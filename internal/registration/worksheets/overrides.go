@@ -0,0 +1,63 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package worksheets
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// applyOverrides applies the system worksheet title, description, and tag
+// overrides from the registration options config. The title and description
+// are expanded as templates using the same variables as the worksheet
+// template (e.g. {{.HostName}}).
+func (w *Worksheets) applyOverrides(ws *circapi.Worksheet, templateVars interface{}) error {
+	if ws == nil {
+		return errors.New("invalid worksheet (nil)")
+	}
+
+	cfg := w.config.Worksheets.System
+
+	if cfg.Title != "" {
+		title, err := expandOverride("worksheets.system.title", cfg.Title, templateVars)
+		if err != nil {
+			return err
+		}
+		ws.Title = title
+	}
+
+	if cfg.Description != "" {
+		desc, err := expandOverride("worksheets.system.description", cfg.Description, templateVars)
+		if err != nil {
+			return err
+		}
+		ws.Description = &desc
+	}
+
+	if len(cfg.Tags) > 0 {
+		ws.Tags = append(ws.Tags, cfg.Tags...)
+	}
+
+	return nil
+}
+
+func expandOverride(name, text string, templateVars interface{}) (string, error) {
+	tmpl, err := templates.Parse(name, text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing regconf %s override", name)
+	}
+	var b bytes.Buffer
+	bw := bufio.NewWriter(&b)
+	if err := tmpl.Execute(bw, templateVars); err != nil {
+		return "", errors.Wrapf(err, "executing regconf %s override", name)
+	}
+	bw.Flush()
+	return b.String(), nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package worksheets
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	t.Log("Testing applyOverrides")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tvars := struct{ HostName string }{"foo"}
+
+	tests := []struct {
+		name         string
+		cfg          options.SystemWorksheet
+		ws           *circapi.Worksheet
+		expectTitle  string
+		expectDesc   string
+		expectedTags int
+		shouldFail   bool
+		expectedErr  string
+	}{
+		{"invalid worksheet (nil)", options.SystemWorksheet{}, nil, "", "", 0, true, "invalid worksheet (nil)"},
+		{"no overrides", options.SystemWorksheet{}, &circapi.Worksheet{Title: "orig"}, "orig", "", 0, false, ""},
+		{"title, description, tags", options.SystemWorksheet{Title: "{{.HostName}} ws", Description: "{{.HostName}} desc", Tags: []string{"team:ops"}}, &circapi.Worksheet{Title: "orig", Tags: []string{"cosi:install"}}, "foo ws", "foo desc", 2, false, ""},
+		{"bad title var", options.SystemWorksheet{Title: "{{.BadName}}"}, &circapi.Worksheet{Title: "orig"}, "", "", 0, true, `executing regconf worksheets.system.title override: template: worksheets.system.title:1:2: executing "worksheets.system.title" at <.BadName>: can't evaluate field BadName in type struct { HostName string }`},
+		{"bad description", options.SystemWorksheet{Description: "{{.HostName"}, &circapi.Worksheet{Title: "orig"}, "", "", 0, true, `parsing regconf worksheets.system.description override: template: worksheets.system.description:1: unclosed action`},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			w, err := New(&Options{
+				Client:    genMockCircAPI(),
+				Config:    &options.Options{Worksheets: options.Worksheets{System: tst.cfg}},
+				RegDir:    "testdata",
+				Templates: &templates.Templates{},
+			})
+			if err != nil {
+				t.Fatalf("unable to create worksheets object (%s)", err)
+			}
+			err = w.applyOverrides(tst.ws, tvars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if tst.ws.Title != tst.expectTitle {
+				t.Fatalf("expected title (%s) got (%s)", tst.expectTitle, tst.ws.Title)
+			}
+			if tst.expectDesc != "" {
+				if tst.ws.Description == nil || *tst.ws.Description != tst.expectDesc {
+					t.Fatalf("expected description (%s) got (%v)", tst.expectDesc, tst.ws.Description)
+				}
+			}
+			if len(tst.ws.Tags) != tst.expectedTags {
+				t.Fatalf("expected %d tags got %v", tst.expectedTags, tst.ws.Tags)
+			}
+		})
+	}
+}
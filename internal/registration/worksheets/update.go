@@ -0,0 +1,83 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package worksheets
+
+import (
+	"path"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/circonus-labs/cosi-tool/internal/worksheet"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+)
+
+// updateWorksheet reconciles an existing worksheet with the configuration
+// rendered from the current template. Worksheets modified since registration
+// (e.g. edited in the UI) are left as-is to preserve the user's changes.
+func (w *Worksheets) updateWorksheet(id string, cfg *circapi.Worksheet) error {
+	if id == "" {
+		return errors.New("invalid id (empty)")
+	}
+	if cfg == nil {
+		return errors.New("invalid worksheet config (nil)")
+	}
+
+	regFile := regfiles.File(w.regDir, id)
+	var reg circapi.Worksheet
+	found, err := regfiles.Load(regFile, &reg)
+	if err != nil {
+		return errors.Wrapf(err, "loading %s", id)
+	}
+	if !found {
+		return errors.Errorf("no registration found for %s", id)
+	}
+
+	current, err := worksheet.FetchByID(w.client, reg.CID)
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s", id)
+	}
+
+	// the api worksheet object does not carry a last modified time,
+	// compare the current worksheet to the registration to detect edits
+	edits, err := regfiles.Diff(&reg, current)
+	if err != nil {
+		return errors.Wrapf(err, "comparing %s registration", id)
+	}
+	if len(edits) > 0 {
+		w.logger.Warn().Str("id", id).Interface("changes", edits).Msg("worksheet modified since registration, preserving user edits")
+		w.worksheetList[id] = current
+		return nil
+	}
+
+	changes, err := regfiles.DiffConfig(current, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "comparing %s template", id)
+	}
+	if len(changes) == 0 {
+		w.logger.Info().Str("id", id).Msg("worksheet up to date")
+		w.worksheetList[id] = current
+		return nil
+	}
+
+	w.logger.Info().Str("id", id).Interface("changes", changes).Msg("updating worksheet")
+
+	cfg.CID = reg.CID
+	cfgFile := path.Join(w.regDir, "config-"+id+".json")
+	if err := regfiles.Save(cfgFile, cfg, true); err != nil {
+		return errors.Wrapf(err, "saving config (%s)", cfgFile)
+	}
+	if err := worksheet.Update(w.client, cfgFile, regFile, true); err != nil {
+		return errors.Wrapf(err, "updating %s", id)
+	}
+
+	var updated circapi.Worksheet
+	if _, err := regfiles.Load(regFile, &updated); err != nil {
+		return errors.Wrapf(err, "loading %s", id)
+	}
+	w.worksheetList[id] = &updated
+
+	return nil
+}
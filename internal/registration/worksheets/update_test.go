@@ -0,0 +1,141 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package worksheets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/cosi-tool/internal/registration/options"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/circonus-labs/cosi-tool/internal/templates"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/rs/zerolog"
+)
+
+func TestUpdateWorksheet(t *testing.T) {
+	t.Log("Testing updateWorksheet")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-worksheets-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	const cid = "/worksheet/abcdef01-2345-6789-abcd-ef0123456789"
+
+	var remote circapi.Worksheet
+	client := &CircAPIMock{
+		FetchWorksheetFunc: func(c circapi.CIDType) (*circapi.Worksheet, error) {
+			ws := remote
+			return &ws, nil
+		},
+		UpdateWorksheetFunc: func(cfg *circapi.Worksheet) (*circapi.Worksheet, error) {
+			return cfg, nil
+		},
+	}
+
+	w, err := New(&Options{
+		Client:    client,
+		Config:    &options.Options{},
+		RegDir:    dir,
+		Templates: &templates.Templates{},
+		Update:    true,
+	})
+	if err != nil {
+		t.Fatalf("unable to create worksheets object (%s)", err)
+	}
+
+	{
+		t.Log("invalid (no registration)")
+		err := w.updateWorksheet("worksheet-missing", &circapi.Worksheet{})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "no registration found for worksheet-missing" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	reg := circapi.Worksheet{CID: cid, Title: "foo"}
+	regFile := filepath.Join(dir, "registration-worksheet-test.json")
+	if err := regfiles.Save(regFile, &reg, true); err != nil {
+		t.Fatalf("saving registration (%s)", err)
+	}
+
+	{
+		t.Log("up to date")
+		remote = reg
+		if err := w.updateWorksheet("worksheet-test", &circapi.Worksheet{Title: "foo"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateWorksheetCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("up to date (server defaults)")
+		description := ""
+		served := circapi.Worksheet{
+			CID:         cid,
+			Title:       "foo",
+			Description: &description,
+			Favorite:    false,
+			Graphs:      []circapi.WorksheetGraph{{GraphCID: "/graph/01234567-89ab-cdef-0123-456789abcdef"}},
+			Tags:        []string{"cosi:managed"},
+		}
+		if err := regfiles.Save(regFile, &served, true); err != nil {
+			t.Fatalf("saving registration (%s)", err)
+		}
+		remote = served
+		cfg := &circapi.Worksheet{
+			Title:  "foo",
+			Graphs: []circapi.WorksheetGraph{{GraphCID: "/graph/01234567-89ab-cdef-0123-456789abcdef"}},
+		}
+		if err := w.updateWorksheet("worksheet-test", cfg); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateWorksheetCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+		if err := regfiles.Save(regFile, &reg, true); err != nil {
+			t.Fatalf("saving registration (%s)", err)
+		}
+	}
+
+	{
+		t.Log("user edits preserved")
+		remote = circapi.Worksheet{CID: cid, Title: "edited in ui"}
+		if err := w.updateWorksheet("worksheet-test", &circapi.Worksheet{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateWorksheetCalls()) != 0 {
+			t.Fatal("expected no update")
+		}
+	}
+
+	{
+		t.Log("template changed")
+		remote = reg
+		if err := w.updateWorksheet("worksheet-test", &circapi.Worksheet{Title: "bar"}); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(client.UpdateWorksheetCalls()) != 1 {
+			t.Fatal("expected update")
+		}
+		var saved circapi.Worksheet
+		if _, err := regfiles.Load(regFile, &saved); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if saved.CID != cid || saved.Title != "bar" {
+			t.Fatalf("unexpected registration (%#v)", saved)
+		}
+	}
+}
@@ -7,10 +7,8 @@ package ruleset
 
 // List local cosi graphs
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/url"
 	"path"
 	"strings"
@@ -76,13 +74,12 @@ func getDetail(regDir, regFile, uiURL string) (*detail, error) {
 
 	var rs circapi.RuleSet
 
-	data, err := ioutil.ReadFile(path.Join(regDir, regFile))
+	found, err := regfiles.Load(path.Join(regDir, regFile), &rs)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading ruleset registration file")
 	}
-
-	if err := json.Unmarshal(data, &rs); err != nil {
-		return nil, errors.Wrap(err, "parsing ruleset registration json")
+	if !found {
+		return nil, errors.Errorf("ruleset registration file (%s) not found", regFile)
 	}
 
 	d := detail{
@@ -5,7 +5,11 @@
 
 package templates
 
-import cosiapi "github.com/circonus-labs/cosi-server/api"
+import (
+	"context"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+)
 
 //go:generate moq -out api_cosi_test.go . API
 
@@ -13,3 +17,9 @@ import cosiapi "github.com/circonus-labs/cosi-server/api"
 type CosiAPI interface {
 	FetchTemplate(id string) (*cosiapi.Template, error)
 }
+
+// CosiContextAPI is implemented by cosi server api clients which can cancel
+// a template fetch (e.g. when the fetch timeout is exceeded)
+type CosiContextAPI interface {
+	FetchTemplateContext(ctx context.Context, id string) (*cosiapi.Template, error)
+}
@@ -0,0 +1,283 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bundleManifestFile = "manifest.json"
+	bundleVersion      = 1
+)
+
+// Platform defines the system a template bundle was created for
+type Platform struct {
+	OSType    string `json:"os_type"`
+	OSDistro  string `json:"os_distro"`
+	OSVersion string `json:"os_version"`
+	SysArch   string `json:"sys_arch"`
+}
+
+// String returns the platform as type/distro/version/arch
+func (p Platform) String() string {
+	return strings.Join([]string{p.OSType, p.OSDistro, p.OSVersion, p.SysArch}, "/")
+}
+
+// BundleManifest defines the contents of a template bundle
+type BundleManifest struct {
+	Version   int               `json:"version"`
+	Created   time.Time         `json:"created"`
+	Platform  Platform          `json:"platform"`
+	Templates map[string]string `json:"templates"`           // template id -> sha256 of the template file
+	Signature string            `json:"signature,omitempty"` // hmac-sha256 of the manifest, if the bundle is signed
+}
+
+// IDs returns the sorted template ids in the bundle
+func (m *BundleManifest) IDs() []string {
+	ids := make([]string, 0, len(m.Templates))
+	for id := range m.Templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Export fetches the templates from cosi-server and writes a bundle
+// (tar.gz) of the templates and a manifest of their checksums to w. If key
+// is not empty the manifest is signed. Templates not available from
+// cosi-server for the platform are skipped.
+func (t *Templates) Export(w io.Writer, platform Platform, ids []string, key []byte) (*BundleManifest, error) {
+	if w == nil {
+		return nil, errors.New("invalid writer (nil)")
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("invalid template id list (empty)")
+	}
+
+	m := &BundleManifest{
+		Version:   bundleVersion,
+		Created:   time.Now().UTC().Truncate(time.Second),
+		Platform:  platform,
+		Templates: make(map[string]string),
+	}
+	files := make(map[string][]byte)
+	for _, id := range ids {
+		if _, done := m.Templates[id]; done {
+			continue
+		}
+		tmpl, err := t.Fetch(id)
+		if err != nil {
+			if apierr.IsNotFound(err) {
+				log.Warn().Str("id", id).Str("platform", platform.String()).Msg("template not available, skipping")
+				continue
+			}
+			return nil, errors.Wrapf(err, "fetching %s", id)
+		}
+		data, err := toml.Marshal(*tmpl) // NOTE: deref ptr, toml.Marshal can't take &struct{}
+		if err != nil {
+			return nil, errors.Wrapf(err, "formatting %s", id)
+		}
+		m.Templates[id] = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+		files[path.Base(TemplateFile("", id))] = data
+	}
+	if len(m.Templates) == 0 {
+		return nil, errors.New("no templates available to export")
+	}
+
+	if len(key) > 0 {
+		sig, err := m.sign(key)
+		if err != nil {
+			return nil, err
+		}
+		m.Signature = sig
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "formatting manifest")
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: m.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrapf(err, "writing bundle (%s)", name)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return errors.Wrapf(err, "writing bundle (%s)", name)
+		}
+		return nil
+	}
+	if err := add(bundleManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	for _, id := range m.IDs() {
+		name := path.Base(TemplateFile("", id))
+		if err := add(name, files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "writing bundle")
+	}
+	if err := gz.Close(); err != nil {
+		return nil, errors.Wrap(err, "writing bundle")
+	}
+
+	return m, nil
+}
+
+// ReadBundle reads and verifies a template bundle. Every template must
+// match the checksum in the manifest. If key is not empty the bundle must
+// be signed with the key, a signed bundle can not be read without the key.
+func ReadBundle(r io.Reader, key []byte) (*BundleManifest, map[string]*cosiapi.Template, error) {
+	if r == nil {
+		return nil, nil, errors.New("invalid reader (nil)")
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading bundle")
+	}
+	defer gz.Close()
+
+	var m *BundleManifest
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "reading bundle")
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "reading bundle (%s)", hdr.Name)
+		}
+		if hdr.Name == bundleManifestFile {
+			m = &BundleManifest{}
+			if err := json.Unmarshal(data, m); err != nil {
+				return nil, nil, errors.Wrap(err, "parsing bundle manifest")
+			}
+			continue
+		}
+		files[hdr.Name] = data
+	}
+
+	if m == nil {
+		return nil, nil, errors.New("invalid bundle, no manifest")
+	}
+	if m.Version != bundleVersion {
+		return nil, nil, errors.Errorf("unsupported bundle version (%d)", m.Version)
+	}
+
+	switch {
+	case len(key) > 0 && m.Signature == "":
+		return nil, nil, errors.New("bundle is not signed")
+	case len(key) == 0 && m.Signature != "":
+		return nil, nil, errors.New("bundle is signed, a key is required to verify it")
+	case len(key) > 0:
+		sig, err := m.sign(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !hmac.Equal([]byte(sig), []byte(m.Signature)) {
+			return nil, nil, errors.New("invalid bundle signature")
+		}
+	}
+
+	tmpls := make(map[string]*cosiapi.Template)
+	for _, id := range m.IDs() {
+		name := path.Base(TemplateFile("", id))
+		data, ok := files[name]
+		if !ok {
+			return nil, nil, errors.Errorf("invalid bundle, missing %s", name)
+		}
+		delete(files, name)
+		if sum := fmt.Sprintf("sha256:%x", sha256.Sum256(data)); sum != m.Templates[id] {
+			return nil, nil, errors.Errorf("invalid bundle, checksum mismatch %s", name)
+		}
+		var tmpl cosiapi.Template
+		if err := toml.Unmarshal(data, &tmpl); err != nil {
+			return nil, nil, errors.Wrapf(err, "parsing %s", name)
+		}
+		tmpls[id] = &tmpl
+	}
+	if len(files) > 0 {
+		extra := make([]string, 0, len(files))
+		for name := range files {
+			extra = append(extra, name)
+		}
+		sort.Strings(extra)
+		return nil, nil, errors.Errorf("invalid bundle, %s not in manifest", strings.Join(extra, ", "))
+	}
+
+	return m, tmpls, nil
+}
+
+// Import verifies a template bundle (see ReadBundle) and saves the
+// templates into the cache (dir). If system is not nil, the bundle must
+// have been created for the system.
+func (t *Templates) Import(r io.Reader, dir string, key []byte, system *Platform) (*BundleManifest, error) {
+	if dir == "" {
+		return nil, errors.New("invalid directory (empty)")
+	}
+
+	m, tmpls, err := ReadBundle(r, key)
+	if err != nil {
+		return nil, err
+	}
+	if system != nil && m.Platform != *system {
+		return nil, errors.Errorf("bundle created for %s, this system is %s", m.Platform, *system)
+	}
+	for _, id := range m.IDs() {
+		if _, err := t.cacheTemplate(dir, id, tmpls[id]); err != nil {
+			return nil, errors.Wrapf(err, "importing %s", id)
+		}
+	}
+
+	return m, nil
+}
+
+// sign returns the hmac-sha256 of the manifest (without a signature)
+func (m *BundleManifest) sign(key []byte) (string, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", errors.Wrap(err, "formatting manifest")
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(data)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,203 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/rs/zerolog"
+)
+
+func TestBundle(t *testing.T) {
+	t.Log("Testing Export/Import")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	client := &APIMock{
+		FetchTemplateFunc: func(id string) (*cosiapi.Template, error) {
+			switch id {
+			case "graph-gone":
+				return nil, errors.New("API response 404 Not Found")
+			case "graph-error":
+				return nil, errors.New("forced mock api call error")
+			}
+			parts := strings.SplitN(id, "-", 2)
+			return &cosiapi.Template{Type: parts[0], Name: parts[1], Version: "1.0.0"}, nil
+		},
+	}
+	tmpl := Templates{client: client}
+	platform := Platform{OSType: "linux", OSDistro: "ubuntu", OSVersion: "18.04", SysArch: "x86_64"}
+	key := []byte("secret")
+
+	t.Log("invalid (fetch error)")
+	{
+		var buf bytes.Buffer
+		_, err := tmpl.Export(&buf, platform, []string{"graph-cpu", "graph-error"}, nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "fetching graph-error: forced mock api call error" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("invalid (nothing to export)")
+	{
+		var buf bytes.Buffer
+		_, err := tmpl.Export(&buf, platform, []string{"graph-gone"}, nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "no templates available to export" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	var signed bytes.Buffer
+	m, err := tmpl.Export(&signed, platform, []string{"check-system", "graph-cpu", "graph-gone", "graph-cpu"}, key)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if ids := strings.Join(m.IDs(), ","); ids != "check-system,graph-cpu" {
+		t.Fatalf("unexpected ids (%s)", ids)
+	}
+
+	var unsigned bytes.Buffer
+	if _, err := tmpl.Export(&unsigned, platform, []string{"graph-cpu"}, nil); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	tests := []struct {
+		name        string
+		bundle      []byte
+		key         []byte
+		expectedErr string
+	}{
+		{"invalid (not a bundle)", []byte("foo"), nil, "reading bundle: unexpected EOF"},
+		{"invalid (signed, no key)", signed.Bytes(), nil, "bundle is signed, a key is required to verify it"},
+		{"invalid (signed, wrong key)", signed.Bytes(), []byte("foo"), "invalid bundle signature"},
+		{"invalid (unsigned, key)", unsigned.Bytes(), key, "bundle is not signed"},
+		{"invalid (checksum)", tamper(t, signed.Bytes(), "template-graph-cpu.toml"), key, "invalid bundle, checksum mismatch template-graph-cpu.toml"},
+		{"invalid (extra file)", tamper(t, unsigned.Bytes(), "template-graph-foo.toml"), nil, "invalid bundle, template-graph-foo.toml not in manifest"},
+		{"valid (signed)", signed.Bytes(), key, ""},
+		{"valid (unsigned)", unsigned.Bytes(), nil, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			_, _, err := ReadBundle(bytes.NewReader(tst.bundle), tst.key)
+			if tst.expectedErr != "" {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "cosi-templates-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Log("invalid (import, different system)")
+	{
+		other := platform
+		other.OSVersion = "20.04"
+		_, err := tmpl.Import(bytes.NewReader(signed.Bytes()), dir, key, &other)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "bundle created for linux/ubuntu/18.04/x86_64, this system is linux/ubuntu/20.04/x86_64" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid (import, offline load)")
+	{
+		if _, err := tmpl.Import(bytes.NewReader(signed.Bytes()), dir, key, &platform); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		offline := Templates{client: client, offline: true}
+		calls := len(client.FetchTemplateCalls())
+		if _, src, _, err := offline.LoadWithSource(dir, "graph-cpu"); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		} else if src != SourceCache {
+			t.Fatalf("unexpected source (%s)", src)
+		}
+		_, _, found, err := offline.LoadWithSource(dir, "graph-disk")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if found {
+			t.Fatal("expected not found")
+		}
+		if err.Error() != "template (graph-disk) not found in the custom templates directory or the cache (offline mode, see 'cosi template import')" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if n := len(client.FetchTemplateCalls()); n != calls {
+			t.Fatalf("expected no cosi-server calls in offline mode (%d)", n-calls)
+		}
+	}
+}
+
+// tamper rewrites a bundle replacing (or adding) the named file
+func tamper(t *testing.T, bundle []byte, name string) []byte {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("reading bundle (%s)", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	data := []byte("type = \"graph\"\n")
+	write := func(n string, d []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: n, Mode: 0644, Size: int64(len(d))}); err != nil {
+			t.Fatalf("writing bundle (%s)", err)
+		}
+		if _, err := tw.Write(d); err != nil {
+			t.Fatalf("writing bundle (%s)", err)
+		}
+	}
+	replaced := false
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		d, _ := ioutil.ReadAll(tr)
+		if hdr.Name == name {
+			d = data
+			replaced = true
+		}
+		write(hdr.Name, d)
+	}
+	if !replaced {
+		write(name, data)
+	}
+	tw.Close()
+	gw.Close()
+	return out.Bytes()
+}
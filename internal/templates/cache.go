@@ -0,0 +1,240 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// KeyCacheTTL is the maximum age of a cached template, an expired
+	// template is re-fetched from cosi-server when loaded (0 = never expire)
+	KeyCacheTTL = "template.cache_ttl"
+	// DefaultCacheTTL is the default cached template ttl
+	DefaultCacheTTL = time.Duration(0)
+
+	// KeyRefreshAll is a flag to refresh all cached templates
+	KeyRefreshAll = "template.refresh.all"
+
+	// KeyRefreshUpdate is a flag to update the assets using changed templates
+	KeyRefreshUpdate = "template.refresh.update"
+
+	// StatusUpdated cached template was replaced with a changed template
+	StatusUpdated = "updated"
+	// StatusUnchanged cached template is current
+	StatusUnchanged = "unchanged"
+	// StatusNotFound template is no longer available from cosi-server
+	StatusNotFound = "not found"
+	// StatusError fetching the template failed
+	StatusError = "error"
+
+	cacheMetaFile = "template-cache.json"
+)
+
+// CacheInfo defines the metadata recorded for a cached template
+type CacheInfo struct {
+	Fetched time.Time `json:"fetched"`
+	Version string    `json:"version"`
+	Hash    string    `json:"hash"` // sha256 of the cached template file
+}
+
+// RefreshResult defines the result of refreshing a cached template
+type RefreshResult struct {
+	ID         string
+	Status     string
+	OldVersion string
+	NewVersion string
+	Overridden bool // a custom template is used in place of the cached template
+	Err        error
+}
+
+// Refresh re-fetches the templates from cosi-server and updates the cached
+// templates which have changed. If ids is empty, all cached templates are
+// refreshed.
+func (t *Templates) Refresh(dir string, ids []string) ([]*RefreshResult, error) {
+	if dir == "" {
+		return nil, errors.New("invalid directory (empty)")
+	}
+
+	if len(ids) == 0 {
+		cached, err := templateIDs(dir)
+		if err != nil {
+			return nil, err
+		}
+		for id := range cached {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no cached templates found")
+	}
+
+	custom, err := templateIDs(t.customDir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*RefreshResult, 0, len(ids))
+	for _, id := range ids {
+		r := &RefreshResult{ID: id}
+		_, r.Overridden = custom[id]
+		results = append(results, r)
+
+		if old, found, err := readTemplate(TemplateFile(dir, id)); err == nil && found {
+			r.OldVersion = old.Version
+		}
+
+		tmpl, err := t.Fetch(id)
+		if err != nil {
+			r.Status = StatusError
+			if apierr.IsNotFound(err) {
+				r.Status = StatusNotFound
+			}
+			r.Err = err
+			continue
+		}
+		r.NewVersion = tmpl.Version
+
+		changed, err := t.cacheTemplate(dir, id, tmpl)
+		if err != nil {
+			r.Status = StatusError
+			r.Err = err
+			continue
+		}
+		r.Status = StatusUnchanged
+		if changed {
+			r.Status = StatusUpdated
+		}
+	}
+
+	return results, nil
+}
+
+// ChangedTemplates returns the ids of the templates updated by a refresh
+func ChangedTemplates(results []*RefreshResult) []string {
+	ids := []string{}
+	for _, r := range results {
+		if r.Status == StatusUpdated {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids
+}
+
+// ShowRefresh displays the results of a refresh
+func ShowRefresh(w io.Writer, results []*RefreshResult) {
+	format := "%-30s %-10s %s\n"
+	fmt.Fprintf(w, format, "ID", "Status", "Version")
+	for _, r := range results {
+		version := r.NewVersion
+		if r.Status == StatusUpdated && r.OldVersion != r.NewVersion {
+			version = r.OldVersion + " -> " + r.NewVersion
+		}
+		if r.Err != nil {
+			version = r.Err.Error()
+		}
+		if r.Overridden {
+			version += " (overridden by custom template)"
+		}
+		fmt.Fprintf(w, format, r.ID, r.Status, version)
+	}
+}
+
+// CacheInfo returns the metadata for a cached template, false if there is
+// no metadata recorded for the template
+func (t *Templates) CacheInfo(dir, id string) (*CacheInfo, bool, error) {
+	meta, err := loadCacheMeta(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	ci, ok := meta[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return &ci, true, nil
+}
+
+// expired returns true if the cached template is older than the cache ttl.
+// Templates cached without metadata use the file modification time.
+func (t *Templates) expired(dir, id string) bool {
+	if t.cacheTTL <= 0 {
+		return false
+	}
+	fetched := time.Time{}
+	if ci, found, err := t.CacheInfo(dir, id); err == nil && found {
+		fetched = ci.Fetched
+	} else if fi, err := os.Stat(TemplateFile(dir, id)); err == nil {
+		fetched = fi.ModTime()
+	}
+	return time.Since(fetched) > t.cacheTTL
+}
+
+// cacheTemplate saves a template fetched from cosi-server into the cache
+// and records the cache metadata. Returns true if the cached template changed.
+func (t *Templates) cacheTemplate(dir, id string, tmpl *cosiapi.Template) (bool, error) {
+	data, err := toml.Marshal(*tmpl) // NOTE: deref ptr, toml.Marshal can't take &struct{}
+	if err != nil {
+		return false, errors.Wrap(err, "formatting template")
+	}
+	hash := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+
+	fn := TemplateFile(dir, id)
+	changed := true
+	if cur, err := ioutil.ReadFile(fn); err == nil {
+		changed = fmt.Sprintf("sha256:%x", sha256.Sum256(cur)) != hash
+	}
+	if changed {
+		if err := ioutil.WriteFile(fn, data, 0644); err != nil {
+			return false, errors.Wrap(err, "saving template")
+		}
+	}
+
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+	meta, err := loadCacheMeta(dir)
+	if err != nil {
+		return false, err
+	}
+	meta[id] = CacheInfo{Fetched: time.Now(), Version: tmpl.Version, Hash: hash}
+	if err := regfiles.Save(path.Join(dir, cacheMetaFile), meta, true); err != nil {
+		return false, errors.Wrap(err, "saving template cache metadata")
+	}
+
+	return changed, nil
+}
+
+// loadCacheMeta reads the template cache metadata
+func loadCacheMeta(dir string) (map[string]CacheInfo, error) {
+	meta := make(map[string]CacheInfo)
+	data, err := ioutil.ReadFile(path.Join(dir, cacheMetaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, nil
+		}
+		return nil, errors.Wrap(err, "reading template cache metadata")
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		log.Warn().Err(err).Str("dir", dir).Msg("invalid template cache metadata, ignoring")
+		return make(map[string]CacheInfo), nil
+	}
+	return meta, nil
+}
@@ -0,0 +1,173 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/rs/zerolog"
+)
+
+func TestRefresh(t *testing.T) {
+	t.Log("Testing Refresh")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-templates-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	versions := map[string]string{"graph-cpu": "1.0.0", "graph-disk": "1.0.0"}
+	client := &APIMock{
+		FetchTemplateFunc: func(id string) (*cosiapi.Template, error) {
+			switch id {
+			case "graph-gone":
+				return nil, errors.New("API response 404 Not Found")
+			case "graph-error":
+				return nil, errors.New("forced mock api call error")
+			}
+			return &cosiapi.Template{Type: "graph", Name: strings.TrimPrefix(id, "graph-"), Version: versions[id]}, nil
+		},
+	}
+	tmpl := Templates{client: client}
+
+	t.Log("invalid (no cached templates)")
+	{
+		_, err := tmpl.Refresh(dir, nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "no cached templates found" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	for _, id := range []string{"graph-cpu", "graph-disk"} {
+		if _, _, err := tmpl.Load(dir, id); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		ci, found, err := tmpl.CacheInfo(dir, id)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if !found || ci.Version != "1.0.0" || !strings.HasPrefix(ci.Hash, "sha256:") {
+			t.Fatalf("unexpected cache info (%#v)", ci)
+		}
+	}
+
+	versions["graph-cpu"] = "1.0.1"
+
+	t.Log("all")
+	{
+		results, err := tmpl.Refresh(dir, nil)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+		if results[0].ID != "graph-cpu" || results[0].Status != StatusUpdated || results[0].OldVersion != "1.0.0" || results[0].NewVersion != "1.0.1" {
+			t.Fatalf("unexpected result (%#v)", results[0])
+		}
+		if results[1].ID != "graph-disk" || results[1].Status != StatusUnchanged {
+			t.Fatalf("unexpected result (%#v)", results[1])
+		}
+		changed := ChangedTemplates(results)
+		if len(changed) != 1 || changed[0] != "graph-cpu" {
+			t.Fatalf("unexpected changed templates (%v)", changed)
+		}
+		var buf bytes.Buffer
+		ShowRefresh(&buf, results)
+		if !strings.Contains(buf.String(), "1.0.0 -> 1.0.1") {
+			t.Fatalf("unexpected output (%s)", buf.String())
+		}
+	}
+
+	t.Log("ids")
+	{
+		results, err := tmpl.Refresh(dir, []string{"graph-gone", "graph-error"})
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if results[0].Status != StatusNotFound || results[1].Status != StatusError {
+			t.Fatalf("unexpected results (%#v, %#v)", results[0], results[1])
+		}
+	}
+}
+
+func TestLoadExpired(t *testing.T) {
+	t.Log("Testing Load (cache ttl)")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	dir, err := ioutil.TempDir("", "cosi-templates-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	version := "1.0.0"
+	fail := false
+	client := &APIMock{
+		FetchTemplateFunc: func(id string) (*cosiapi.Template, error) {
+			if fail {
+				return nil, errors.New("forced mock api call error")
+			}
+			return &cosiapi.Template{Type: "graph", Name: "cpu", Version: version}, nil
+		},
+	}
+	tmpl := Templates{client: client}
+
+	if _, _, err := tmpl.Load(dir, "graph-cpu"); err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	version = "1.0.1"
+
+	t.Log("not expired (no ttl)")
+	{
+		tv, source, _, err := tmpl.LoadWithSource(dir, "graph-cpu")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if source != SourceCache || tv.Version != "1.0.0" {
+			t.Fatalf("unexpected template (%s %s)", source, tv.Version)
+		}
+	}
+
+	tmpl.cacheTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+
+	t.Log("expired (fetch error)")
+	{
+		fail = true
+		tv, source, _, err := tmpl.LoadWithSource(dir, "graph-cpu")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if source != SourceCache || tv.Version != "1.0.0" {
+			t.Fatalf("unexpected template (%s %s)", source, tv.Version)
+		}
+		fail = false
+	}
+
+	t.Log("expired")
+	{
+		tv, source, _, err := tmpl.LoadWithSource(dir, "graph-cpu")
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if source != SourceServer || tv.Version != "1.0.1" {
+			t.Fatalf("unexpected template (%s %s)", source, tv.Version)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DiffIdentical local template matches cosi-server
+	DiffIdentical = "identical"
+	// DiffChanged local template differs from cosi-server
+	DiffChanged = "changed"
+
+	// SectionChanged section differs
+	SectionChanged = "changed"
+	// SectionAdded section only in the cosi-server template
+	SectionAdded = "added on cosi-server"
+	// SectionRemoved section only in the local template
+	SectionRemoved = "removed on cosi-server"
+)
+
+// TemplateDiff is the difference between a local template and the
+// template currently served by cosi-server
+type TemplateDiff struct {
+	ID       string
+	File     string
+	Source   string // custom|cache
+	Status   string // identical|changed|not found (no template on cosi-server)
+	Sections []*DiffSection
+}
+
+// DiffSection are the changes to the top level template settings
+// (template) or a config (configs.<name>)
+type DiffSection struct {
+	Name    string
+	Status  string   // changed|added|removed
+	Changes []string // field changes (key: from -> to) and template body line changes
+}
+
+// Diff compares a local template file with the template served by
+// cosi-server for this system
+func (t *Templates) Diff(id, file string) (*TemplateDiff, error) {
+	if id == "" {
+		return nil, errors.New("invalid id (empty)")
+	}
+	if file == "" {
+		return nil, errors.New("invalid file (empty)")
+	}
+
+	local, found, err := readTemplate(file)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.Errorf("template file (%s) not found", file)
+	}
+
+	d := &TemplateDiff{ID: id, File: file, Status: DiffIdentical}
+
+	remote, err := t.Fetch(id)
+	if err != nil {
+		if apierr.IsNotFound(err) {
+			d.Status = StatusNotFound
+			return d, nil
+		}
+		return nil, errors.Wrapf(err, "fetching %s", id)
+	}
+
+	d.Sections = DiffTemplates(local, remote)
+	if len(d.Sections) > 0 {
+		d.Status = DiffChanged
+	}
+
+	return d, nil
+}
+
+// DiffTemplates returns the sections which differ between two templates,
+// the top level settings (template) followed by each config (configs.<name>)
+func DiffTemplates(from, to *cosiapi.Template) []*DiffSection {
+	sections := []*DiffSection{}
+
+	top := &DiffSection{Name: "template", Status: SectionChanged}
+	diffField(&top.Changes, "type", from.Type, to.Type)
+	diffField(&top.Changes, "name", from.Name, to.Name)
+	diffField(&top.Changes, "version", from.Version, to.Version)
+	diffField(&top.Changes, "description", from.Description, to.Description)
+	diffField(&top.Changes, "variable", from.Variable, to.Variable)
+	diffFilter(&top.Changes, "filters", from.Filter, to.Filter)
+	if len(top.Changes) > 0 {
+		sections = append(sections, top)
+	}
+
+	names := map[string]bool{}
+	for name := range from.Configs {
+		names[name] = true
+	}
+	for name := range to.Configs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		s := &DiffSection{Name: "configs." + name, Status: SectionChanged}
+		fc, inFrom := from.Configs[name]
+		tc, inTo := to.Configs[name]
+		switch {
+		case !inFrom:
+			s.Status = SectionAdded
+		case !inTo:
+			s.Status = SectionRemoved
+		default:
+			diffConfig(&s.Changes, &fc, &tc)
+			if len(s.Changes) == 0 {
+				continue
+			}
+		}
+		sections = append(sections, s)
+	}
+
+	return sections
+}
+
+// ShowDiff displays the template differences, returns the number of
+// templates which differ from cosi-server
+func ShowDiff(w io.Writer, diffs []*TemplateDiff) int {
+	changed, notFound := 0, 0
+	for _, d := range diffs {
+		switch d.Status {
+		case DiffChanged:
+			changed++
+		case StatusNotFound:
+			notFound++
+		}
+		status := d.Status
+		if d.Status == StatusNotFound {
+			status += " on cosi-server"
+		}
+		fmt.Fprintf(w, "%s: %s (%s %s)\n", d.ID, status, d.Source, d.File)
+		for _, s := range d.Sections {
+			if s.Status != SectionChanged {
+				fmt.Fprintf(w, "  %s: %s\n", s.Name, s.Status)
+				continue
+			}
+			fmt.Fprintf(w, "  %s\n", s.Name)
+			for _, c := range s.Changes {
+				fmt.Fprintf(w, "    %s\n", c)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d template(s) compared, %d changed, %d not found on cosi-server\n", len(diffs), changed, notFound)
+	return changed
+}
+
+func diffConfig(changes *[]string, from, to *cosiapi.TemplateConfig) {
+	diffField(changes, "variable", from.Variable, to.Variable)
+	diffBody(changes, "template", from.Template, to.Template)
+
+	for i := 0; i < len(from.Datapoints) || i < len(to.Datapoints); i++ {
+		key := fmt.Sprintf("datapoints[%d]", i)
+		switch {
+		case i >= len(from.Datapoints):
+			*changes = append(*changes, key+": "+SectionAdded)
+		case i >= len(to.Datapoints):
+			*changes = append(*changes, key+": "+SectionRemoved)
+		default:
+			f, t := from.Datapoints[i], to.Datapoints[i]
+			diffField(changes, key+".variable", f.Variable, t.Variable)
+			diffField(changes, key+".metric_regex", f.MetricRx, t.MetricRx)
+			diffFilter(changes, key+".filter", f.Filter, t.Filter)
+			diffBody(changes, key+".template", f.Template, t.Template)
+		}
+	}
+
+	for i := 0; i < len(from.Widgets) || i < len(to.Widgets); i++ {
+		key := fmt.Sprintf("widgets[%d]", i)
+		switch {
+		case i >= len(from.Widgets):
+			*changes = append(*changes, key+": "+SectionAdded)
+		case i >= len(to.Widgets):
+			*changes = append(*changes, key+": "+SectionRemoved)
+		default:
+			f, t := from.Widgets[i], to.Widgets[i]
+			diffField(changes, key+".graph_name", f.GraphName, t.GraphName)
+			diffBody(changes, key+".template", f.Template, t.Template)
+		}
+	}
+}
+
+func diffFilter(changes *[]string, key string, from, to cosiapi.TemplateFilter) {
+	diffList(changes, key+".include", from.Include, to.Include)
+	diffList(changes, key+".exclude", from.Exclude, to.Exclude)
+}
+
+func diffField(changes *[]string, key string, from, to interface{}) {
+	if from == to {
+		return
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", key, fmtDiffValue(from), fmtDiffValue(to)))
+}
+
+func diffList(changes *[]string, key string, from, to []string) {
+	if strings.Join(from, "\x00") == strings.Join(to, "\x00") {
+		return
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %q -> %q", key, from, to))
+}
+
+// diffBody adds the line changes for a template body, lines only in from
+// are prefixed with '-', lines only in to with '+'
+func diffBody(changes *[]string, key, from, to string) {
+	if from == to {
+		return
+	}
+	*changes = append(*changes, key+":")
+	for _, l := range lineDiff(strings.Split(strings.TrimSpace(from), "\n"), strings.Split(strings.TrimSpace(to), "\n")) {
+		*changes = append(*changes, "  "+l)
+	}
+}
+
+// lineDiff returns the removed (-) and added (+) lines between a and b,
+// based on the longest common subsequence of lines
+func lineDiff(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+	return lines
+}
+
+func fmtDiffValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,149 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/rs/zerolog"
+)
+
+func TestDiff(t *testing.T) {
+	t.Log("Testing Diff")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	local := &cosiapi.Template{
+		Type:    "graph",
+		Name:    "cpu",
+		Version: "1.0.0",
+		Configs: map[string]cosiapi.TemplateConfig{
+			"cpu": {
+				Template: "title = \"cpu\"\nstyle = \"line\"",
+				Datapoints: []cosiapi.TemplateDatapoint{
+					{MetricRx: "^cpu`user$", Template: "color = \"red\""},
+				},
+			},
+			"old": {Template: "title = \"old\""},
+		},
+	}
+	remote := &cosiapi.Template{
+		Type:    "graph",
+		Name:    "cpu",
+		Version: "1.1.0",
+		Filter:  cosiapi.TemplateFilter{Include: []string{"cpu"}},
+		Configs: map[string]cosiapi.TemplateConfig{
+			"cpu": {
+				Template: "title = \"cpu usage\"\nstyle = \"line\"",
+				Datapoints: []cosiapi.TemplateDatapoint{
+					{MetricRx: "^cpu`(user|sys)$", Template: "color = \"red\""},
+					{MetricRx: "^cpu`idle$"},
+				},
+			},
+			"new": {Template: "title = \"new\""},
+		},
+	}
+
+	client := &APIMock{
+		FetchTemplateFunc: func(id string) (*cosiapi.Template, error) {
+			switch id {
+			case "graph-cpu":
+				return remote, nil
+			case "graph-same":
+				return local, nil
+			case "graph-gone":
+				return nil, errors.New("API response 404 Not Found")
+			}
+			return nil, errors.New("forced mock api call error")
+		},
+	}
+	tmpl := Templates{client: client}
+
+	dir, err := ioutil.TempDir("", "cosi-templates-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, id := range []string{"graph-cpu", "graph-same", "graph-gone", "graph-error"} {
+		if _, err := tmpl.cacheTemplate(dir, id, local); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		file        string
+		status      string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (id)", "", "", "", true, "invalid id (empty)"},
+		{"invalid (file)", "graph-cpu", "", "", true, "invalid file (empty)"},
+		{"invalid (missing file)", "graph-foo", TemplateFile(dir, "graph-foo"), "", true, "template file (" + TemplateFile(dir, "graph-foo") + ") not found"},
+		{"invalid (fetch error)", "graph-error", TemplateFile(dir, "graph-error"), "", true, "fetching graph-error: forced mock api call error"},
+		{"valid (not found)", "graph-gone", TemplateFile(dir, "graph-gone"), StatusNotFound, false, ""},
+		{"valid (identical)", "graph-same", TemplateFile(dir, "graph-same"), DiffIdentical, false, ""},
+		{"valid (changed)", "graph-cpu", TemplateFile(dir, "graph-cpu"), DiffChanged, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			d, err := tmpl.Diff(tst.id, tst.file)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if d.Status != tst.status {
+				t.Fatalf("unexpected status (%s) expected (%s)", d.Status, tst.status)
+			}
+		})
+	}
+
+	t.Log("valid (sections)")
+	{
+		sections := DiffTemplates(local, remote)
+		var buf bytes.Buffer
+		changed := ShowDiff(&buf, []*TemplateDiff{{ID: "graph-cpu", Source: SourceCache, File: "f", Status: DiffChanged, Sections: sections}})
+		if changed != 1 {
+			t.Fatalf("unexpected changed (%d)", changed)
+		}
+		expected := strings.Join([]string{
+			"graph-cpu: changed (cache f)",
+			"  template",
+			`    version: "1.0.0" -> "1.1.0"`,
+			`    filters.include: [] -> ["cpu"]`,
+			"  configs.cpu",
+			"    template:",
+			`      - title = "cpu"`,
+			`      + title = "cpu usage"`,
+			"    datapoints[0].metric_regex: \"^cpu`user$\" -> \"^cpu`(user|sys)$\"",
+			"    datapoints[1]: added on cosi-server",
+			"  configs.new: added on cosi-server",
+			"  configs.old: removed on cosi-server",
+			"",
+			"1 template(s) compared, 1 changed, 0 not found on cosi-server",
+			"",
+		}, "\n")
+		if buf.String() != expected {
+			t.Fatalf("unexpected output\n%s\nexpected\n%s", buf.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Parse parses template text for a check, graph, worksheet or dashboard
+// config. All asset types are rendered the same way, with text/template
+// (the result is JSON, not HTML, so nothing is escaped), the function map
+// from FuncMap and missingkey=error.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).
+		Option("missingkey=error").
+		Funcs(FuncMap()).
+		Parse(text)
+}
+
+// Expand parses and executes template text with data, returning the expanded result
+func Expand(name, text string, data interface{}) ([]byte, error) {
+	tmpl, err := Parse(name, text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, errors.Wrap(err, "executing template")
+	}
+	return b.Bytes(), nil
+}
+
+// FuncMap returns the functions available to templates:
+//
+//	lower, upper, title, trim     string case and whitespace (e.g. {{upper .HostName}})
+//	replace OLD NEW S             replace all OLD with NEW in S (e.g. {{.HostName | replace "." "_"}})
+//	quote S                       S as a quoted JSON string, including the quotes
+//	json V                        V encoded as JSON
+//	default DEF V                 DEF if V is empty (e.g. {{.Port | default "5432"}})
+//	regexCapture RX S             first capture group of RX in S, empty if no match
+//	add, sub, mul, div, mod A B   integer math (e.g. {{mul .NumCPU 100}})
+//	min, max A B                  smaller/larger of two integers
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"lower":        strings.ToLower,
+		"upper":        strings.ToUpper,
+		"title":        strings.Title,
+		"trim":         strings.TrimSpace,
+		"replace":      replace,
+		"quote":        quote,
+		"json":         toJSON,
+		"default":      defaultValue,
+		"regexCapture": regexCapture,
+		"add":          mathFunc(func(a, b int64) (int64, error) { return a + b, nil }),
+		"sub":          mathFunc(func(a, b int64) (int64, error) { return a - b, nil }),
+		"mul":          mathFunc(func(a, b int64) (int64, error) { return a * b, nil }),
+		"div": mathFunc(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		}),
+		"mod": mathFunc(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a % b, nil
+		}),
+		"min": mathFunc(func(a, b int64) (int64, error) {
+			if a < b {
+				return a, nil
+			}
+			return b, nil
+		}),
+		"max": mathFunc(func(a, b int64) (int64, error) {
+			if a > b {
+				return a, nil
+			}
+			return b, nil
+		}),
+	}
+}
+
+func replace(old, new, s string) string {
+	return strings.Replace(s, old, new, -1)
+}
+
+func quote(s string) (string, error) {
+	return toJSON(s)
+}
+
+// toJSON encodes v without escaping HTML characters (e.g. <, >, &)
+func toJSON(v interface{}) (string, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func defaultValue(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	switch val := v.(type) {
+	case string:
+		if val == "" {
+			return def
+		}
+	case int:
+		if val == 0 {
+			return def
+		}
+	case uint:
+		if val == 0 {
+			return def
+		}
+	case bool:
+		if !val {
+			return def
+		}
+	}
+	return v
+}
+
+func regexCapture(rx, s string) (string, error) {
+	re, err := regexp.Compile(rx)
+	if err != nil {
+		return "", errors.Wrapf(err, "regexCapture (%s)", rx)
+	}
+	m := re.FindStringSubmatch(s)
+	if len(m) < 2 {
+		return "", nil
+	}
+	return m[1], nil
+}
+
+// mathFunc adapts integer math to the numeric types used in template
+// variables (e.g. NumCPU int, CheckID uint, meta values as strings)
+func mathFunc(fn func(a, b int64) (int64, error)) func(a, b interface{}) (int64, error) {
+	return func(a, b interface{}) (int64, error) {
+		x, err := toInt(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toInt(b)
+		if err != nil {
+			return 0, err
+		}
+		return fn(x, y)
+	}
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	case uint:
+		return int64(val), nil
+	case uint32:
+		return int64(val), nil
+	case uint64:
+		return int64(val), nil
+	case float64:
+		return int64(val), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		if err != nil {
+			return 0, errors.Errorf("invalid number (%s)", val)
+		}
+		return i, nil
+	default:
+		return 0, errors.Errorf("invalid number (%v)", v)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestExpand(t *testing.T) {
+	t.Log("Testing Expand")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	vars := map[string]interface{}{
+		"HostName": "db01.example.com",
+		"NumCPU":   4,
+		"CheckID":  uint(123),
+		"Item":     "sda1",
+		"Port":     "",
+		"Tag":      `a<b>&"c"`,
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		expected    string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (parse)", "{{.HostName", "", true, `parsing template: template: test:1: unclosed action`},
+		{"invalid (missing key)", "{{.Foo}}", "", true, `executing template: template: test:1:2: executing "test" at <.Foo>: map has no entry for key "Foo"`},
+		{"no escaping", "{{.Tag}}", `a<b>&"c"`, false, ""},
+		{"lower", "{{lower .HostName | upper}}", "DB01.EXAMPLE.COM", false, ""},
+		{"title", `{{title "cpu usage"}}`, "Cpu Usage", false, ""},
+		{"trim", `{{trim "  x  "}}`, "x", false, ""},
+		{"replace", `{{.HostName | replace "." "_"}}`, "db01_example_com", false, ""},
+		{"quote", `{{quote .Tag}}`, `"a<b>&\"c\""`, false, ""},
+		{"json", `{{json .NumCPU}}`, "4", false, ""},
+		{"default (empty)", `{{.Port | default "5432"}}`, "5432", false, ""},
+		{"default (set)", `{{.Item | default "sda"}}`, "sda1", false, ""},
+		{"regexCapture", `{{regexCapture "^([^.]+)\\." .HostName}}`, "db01", false, ""},
+		{"regexCapture (no match)", `{{regexCapture "^x(.)" .HostName}}`, "", false, ""},
+		{"invalid (regexCapture)", `{{regexCapture "(" .HostName}}`, "", true, "executing template: template: test:1:2: executing \"test\" at <regexCapture \"(\" .HostName>: error calling regexCapture: regexCapture ((): error parsing regexp: missing closing ): `(`"},
+		{"add", "{{add .NumCPU 1}}", "5", false, ""},
+		{"sub", "{{sub .CheckID 3}}", "120", false, ""},
+		{"mul", "{{mul .NumCPU 100}}", "400", false, ""},
+		{"div", "{{div 100 .NumCPU}}", "25", false, ""},
+		{"mod", `{{mod "10" 4}}`, "2", false, ""},
+		{"min", "{{min .NumCPU 2}}", "2", false, ""},
+		{"max", "{{max .NumCPU 2}}", "4", false, ""},
+		{"invalid (div zero)", "{{div .NumCPU 0}}", "", true, `executing template: template: test:1:2: executing "test" at <div .NumCPU 0>: error calling div: division by zero`},
+		{"invalid (number)", "{{add .HostName 1}}", "", true, `executing template: template: test:1:2: executing "test" at <add .HostName 1>: error calling add: invalid number (db01.example.com)`},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			data, err := Expand("test", tst.text, vars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if string(data) != tst.expected {
+				t.Fatalf("unexpected result (%s) expected (%s)", string(data), tst.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// FromOptions defines the template created from an existing asset and the
+// host specific values replaced with template variables
+type FromOptions struct {
+	Name        string            // template name, the template id is <type>-<name>
+	Config      string            // config name (configs.<name>), default is the template name
+	Description string            // template description
+	HostName    string            // replaced with {{.HostName}}
+	CheckID     uint              // system check id, replaced with {{.CheckID}} and {{.CheckCID}}
+	CheckUUID   string            // system check uuid, replaced with {{.CheckUUID}}
+	Item        string            // graph metric name item, the graph becomes a variable graph ({{.Item}})
+	Graphs      map[string]string // graph uuid -> graph id (widget graph_name), widget graph uuids are replaced with {{.GraphUUID}}
+	Notes       string            // notes prefix added by cosi during registration, removed
+	Tags        []string          // tags added by cosi during registration, removed
+}
+
+var checkIDRx = regexp.MustCompile(`("_?check_id": )([0-9]+)\b`)
+
+// LoadRegistrations sets the system check id and uuid (if not already set)
+// from the system check registration and adds the graph uuids of the
+// registered graphs (if not already set) from the registration directory
+func (o *FromOptions) LoadRegistrations(regDir string) error {
+	if regDir == "" {
+		return errors.New("invalid registration directory (empty)")
+	}
+
+	var bundle circapi.CheckBundle
+	found, err := regfiles.Load(regfiles.File(regDir, "check-system"), &bundle)
+	if err != nil {
+		return errors.Wrap(err, "loading system check registration")
+	}
+	if found {
+		if o.CheckID == 0 && len(bundle.Checks) > 0 {
+			id, err := strconv.ParseUint(strings.TrimPrefix(bundle.Checks[0], "/check/"), 10, 32)
+			if err != nil {
+				return errors.Wrapf(err, "parsing system check id (%s)", bundle.Checks[0])
+			}
+			o.CheckID = uint(id)
+		}
+		if o.CheckUUID == "" && len(bundle.CheckUUIDs) > 0 {
+			o.CheckUUID = bundle.CheckUUIDs[0]
+		}
+	}
+
+	regFiles, err := regfiles.Find(regDir, "graph")
+	if err != nil {
+		return err
+	}
+	if o.Graphs == nil {
+		o.Graphs = make(map[string]string)
+	}
+	for _, rf := range *regFiles {
+		var g circapi.Graph
+		if _, err := regfiles.Load(path.Join(regDir, rf), &g); err != nil {
+			return err
+		}
+		uuid := strings.Replace(g.CID, "/graph/", "", 1)
+		if _, ok := o.Graphs[uuid]; ok || uuid == "" {
+			continue
+		}
+		o.Graphs[uuid] = strings.TrimSuffix(strings.TrimPrefix(rf, "registration-"), path.Ext(rf))
+	}
+
+	return nil
+}
+
+// FromGraph creates a graph template from an existing graph. Each
+// datapoint becomes a template datapoint. If an item is set the graph
+// becomes a variable graph, datapoints with the item in the metric name
+// match a metric_regex capturing the item.
+func FromGraph(g *circapi.Graph, o *FromOptions) (*cosiapi.Template, error) {
+	if g == nil {
+		return nil, errors.New("invalid graph (nil)")
+	}
+	tmpl, err := newFromTemplate("graph", g.CID, o)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := *g
+	graph.CID = ""
+	graph.Datapoints = nil
+	graph.Tags = o.removeTags(graph.Tags)
+	if graph.Notes != nil {
+		notes := strings.TrimPrefix(*graph.Notes, o.Notes)
+		graph.Notes = &notes
+	}
+
+	cfg := cosiapi.TemplateConfig{Variable: o.Item != ""}
+	if cfg.Template, err = o.templateBody(graph); err != nil {
+		return nil, err
+	}
+
+	itemFound := false
+	for _, dp := range g.Datapoints {
+		tdp := cosiapi.TemplateDatapoint{}
+		metric := dp.MetricName
+		if idx := strings.Index(metric, "|ST["); idx != -1 {
+			metric = metric[:idx] // metric_regex matches metric names without stream tags
+		}
+		if idx := tokenIndex(metric, o.Item); o.Item != "" && idx != -1 {
+			tdp.MetricRx = "^" + regexp.QuoteMeta(metric[:idx]) + "([^`]+)" + regexp.QuoteMeta(metric[idx+len(o.Item):]) + "$"
+			dp.MetricName = "{{.MetricName}}"
+			itemFound = true
+		}
+		if tdp.Template, err = o.templateBody(dp); err != nil {
+			return nil, err
+		}
+		cfg.Datapoints = append(cfg.Datapoints, tdp)
+	}
+	if o.Item != "" && !itemFound {
+		return nil, errors.Errorf("item (%s) not found in any datapoint metric name", o.Item)
+	}
+
+	tmpl.Configs[o.config()] = cfg
+	return tmpl, nil
+}
+
+// FromDashboard creates a dashboard template from an existing dashboard.
+// Each widget becomes a template widget, graph widgets are linked to the
+// graph (graph_name) using the graph uuids.
+func FromDashboard(d *circapi.Dashboard, o *FromOptions) (*cosiapi.Template, error) {
+	if d == nil {
+		return nil, errors.New("invalid dashboard (nil)")
+	}
+	tmpl, err := newFromTemplate("dashboard", d.CID, o)
+	if err != nil {
+		return nil, err
+	}
+
+	dash := *d
+	dash.CID = ""
+	dash.UUID = ""
+	dash.Active = false
+	dash.Created = 0
+	dash.CreatedBy = ""
+	dash.LastModified = 0
+	dash.Widgets = []circapi.DashboardWidget{}
+
+	cfg := cosiapi.TemplateConfig{}
+	if cfg.Template, err = o.templateBody(dash); err != nil {
+		return nil, err
+	}
+
+	for _, widget := range d.Widgets {
+		tw := cosiapi.TemplateWidget{}
+		graphUUID := widget.Settings.GraphUUID
+		if graphUUID != "" {
+			if graphID, ok := o.Graphs[graphUUID]; ok {
+				tw.GraphName = graphID
+				widget.Settings.GraphUUID = "{{.GraphUUID}}"
+			} else {
+				log.Warn().Str("widget", widget.WidgetID).Str("graph_uuid", graphUUID).Msg("graph not registered on this host, uuid not replaced")
+			}
+		}
+		if tw.Template, err = o.templateBody(widget); err != nil {
+			return nil, err
+		}
+		cfg.Widgets = append(cfg.Widgets, tw)
+	}
+
+	tmpl.Configs[o.config()] = cfg
+	return tmpl, nil
+}
+
+// FromWorksheet creates a worksheet template from an existing worksheet.
+// The graphs and smart queries are not included, registration adds a
+// smart query for the graphs created by cosi.
+func FromWorksheet(w *circapi.Worksheet, o *FromOptions) (*cosiapi.Template, error) {
+	if w == nil {
+		return nil, errors.New("invalid worksheet (nil)")
+	}
+	tmpl, err := newFromTemplate("worksheet", w.CID, o)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := *w
+	sheet.CID = ""
+	sheet.Graphs = []circapi.WorksheetGraph{}
+	sheet.SmartQueries = nil
+	sheet.Tags = o.removeTags(sheet.Tags)
+	if sheet.Notes != nil {
+		notes := strings.TrimPrefix(*sheet.Notes, o.Notes)
+		sheet.Notes = &notes
+	}
+
+	cfg := cosiapi.TemplateConfig{}
+	if cfg.Template, err = o.templateBody(sheet); err != nil {
+		return nil, err
+	}
+
+	tmpl.Configs[o.config()] = cfg
+	return tmpl, nil
+}
+
+func newFromTemplate(kind, cid string, o *FromOptions) (*cosiapi.Template, error) {
+	if o == nil {
+		return nil, errors.New("invalid options (nil)")
+	}
+	if o.Name == "" {
+		return nil, errors.New("invalid template name (empty)")
+	}
+	desc := o.Description
+	if desc == "" {
+		desc = fmt.Sprintf("%s template created from %s", kind, cid)
+	}
+	return &cosiapi.Template{
+		Type:        kind,
+		Name:        o.Name,
+		Version:     "1.0.0",
+		Description: desc,
+		Configs:     make(map[string]cosiapi.TemplateConfig),
+	}, nil
+}
+
+func (o *FromOptions) config() string {
+	if o.Config != "" {
+		return o.Config
+	}
+	return o.Name
+}
+
+func (o *FromOptions) removeTags(tags []string) []string {
+	if len(o.Tags) == 0 {
+		return tags
+	}
+	remove := make(map[string]bool, len(o.Tags))
+	for _, tag := range o.Tags {
+		remove[tag] = true
+	}
+	kept := []string{}
+	for _, tag := range tags {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// templateBody formats an API object as a template, existing template
+// delimiters are escaped and the host specific values are replaced with
+// template variables
+func (o *FromOptions) templateBody(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		return "", errors.Wrap(err, "formatting template")
+	}
+	body := buf.String()
+
+	// NOTE: {{.MetricName}} and {{.GraphUUID}} are set before formatting
+	body = strings.Replace(body, "{{", `{{"{{"}}`, -1)
+	body = strings.Replace(body, `{{"{{"}}.MetricName}}`, "{{.MetricName}}", -1)
+	body = strings.Replace(body, `{{"{{"}}.GraphUUID}}`, "{{.GraphUUID}}", -1)
+
+	if o.CheckUUID != "" {
+		body = strings.Replace(body, o.CheckUUID, "{{.CheckUUID}}", -1)
+	}
+	if o.CheckID != 0 {
+		id := strconv.FormatUint(uint64(o.CheckID), 10)
+		body = strings.Replace(body, `"/check/`+id+`"`, `"{{.CheckCID}}"`, -1)
+		body = checkIDRx.ReplaceAllStringFunc(body, func(m string) string {
+			parts := checkIDRx.FindStringSubmatch(m)
+			if parts[2] != id {
+				return m
+			}
+			return parts[1] + "{{.CheckID}}"
+		})
+	}
+	if o.HostName != "" {
+		body = replaceToken(body, o.HostName, "{{.HostName}}")
+	}
+	if o.Item != "" {
+		body = replaceToken(body, o.Item, "{{.Item}}")
+	}
+
+	return body, nil
+}
+
+// tokenRx matches value along with any adjoining name characters, a match
+// which is not exactly value is part of a longer name (e.g. host "mongo"
+// in metric "mongodb`opcounters") and is not replaced. Names are delimited
+// by any other character (e.g. '.', '`' or '"').
+func tokenRx(value string) *regexp.Regexp {
+	return regexp.MustCompile(`[A-Za-z0-9_-]*` + regexp.QuoteMeta(value) + `[A-Za-z0-9_-]*`)
+}
+
+// replaceToken replaces whole token occurrences of value in s
+func replaceToken(s, value, repl string) string {
+	return tokenRx(value).ReplaceAllStringFunc(s, func(m string) string {
+		if m != value {
+			return m
+		}
+		return repl
+	})
+}
+
+// tokenIndex returns the index of the first whole token occurrence of
+// value in s or -1
+func tokenIndex(s, value string) int {
+	if value == "" {
+		return -1
+	}
+	for _, loc := range tokenRx(value).FindAllStringIndex(s, -1) {
+		if s[loc[0]:loc[1]] == value {
+			return loc[0]
+		}
+	}
+	return -1
+}
+
+// Format returns a template as toml, template bodies are written as
+// multi-line literal strings so the template can be edited by hand
+func Format(tmpl *cosiapi.Template) ([]byte, error) {
+	if tmpl == nil {
+		return nil, errors.New("invalid template (nil)")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type = %s\n", tomlString(tmpl.Type))
+	fmt.Fprintf(&buf, "name = %s\n", tomlString(tmpl.Name))
+	fmt.Fprintf(&buf, "version = %s\n", tomlString(tmpl.Version))
+	fmt.Fprintf(&buf, "description = %s\n", tomlString(tmpl.Description))
+	if tmpl.Variable {
+		buf.WriteString("variable = true\n")
+	}
+	writeTOMLFilter(&buf, "", "filters", tmpl.Filter)
+
+	names := make([]string, 0, len(tmpl.Configs))
+	for name := range tmpl.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg := tmpl.Configs[name]
+		table := "configs." + tomlKey(name)
+		fmt.Fprintf(&buf, "\n[%s]\n", table)
+		if cfg.Variable {
+			buf.WriteString("variable = true\n")
+		}
+		fmt.Fprintf(&buf, "template = %s\n", tomlBody(cfg.Template))
+		for _, dp := range cfg.Datapoints {
+			fmt.Fprintf(&buf, "\n    [[%s.datapoints]]\n", table)
+			if dp.Variable {
+				buf.WriteString("    variable = true\n")
+			}
+			if dp.MetricRx != "" {
+				fmt.Fprintf(&buf, "    metric_regex = %s\n", tomlString(dp.MetricRx))
+			}
+			fmt.Fprintf(&buf, "    template = %s\n", tomlBody(dp.Template))
+			writeTOMLFilter(&buf, "    ", table+".datapoints.filter", dp.Filter)
+		}
+		for _, w := range cfg.Widgets {
+			fmt.Fprintf(&buf, "\n    [[%s.widgets]]\n", table)
+			if w.GraphName != "" {
+				fmt.Fprintf(&buf, "    graph_name = %s\n", tomlString(w.GraphName))
+			}
+			fmt.Fprintf(&buf, "    template = %s\n", tomlBody(w.Template))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeTOMLFilter(buf *bytes.Buffer, indent, table string, f cosiapi.TemplateFilter) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "\n%s[%s]\n", indent, table)
+	for _, l := range []struct {
+		key  string
+		list []string
+	}{{"include", f.Include}, {"exclude", f.Exclude}} {
+		if len(l.list) == 0 {
+			continue
+		}
+		quoted := make([]string, len(l.list))
+		for i, s := range l.list {
+			quoted[i] = tomlString(s)
+		}
+		fmt.Fprintf(buf, "%s%s = [%s]\n", indent, l.key, strings.Join(quoted, ", "))
+	}
+}
+
+var bareKeyRx = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareKeyRx.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString returns s as a toml basic string (json string escapes are
+// valid toml escapes)
+func tomlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// tomlBody returns a template body as a multi-line literal string, if
+// possible
+func tomlBody(s string) string {
+	if strings.Contains(s, "'''") || strings.ContainsAny(s, "\r\x00") {
+		return tomlString(s)
+	}
+	return "'''\n" + s + "'''"
+}
@@ -0,0 +1,357 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pelletier/go-toml"
+	"github.com/rs/zerolog"
+)
+
+func TestFromGraph(t *testing.T) {
+	t.Log("Testing FromGraph")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	notes := "cosi:register,cosi_id:abc{{tuned}}"
+	style := "line"
+	g := &circapi.Graph{
+		CID:   "/graph/0b7c6e27-7e7f-4c4c-8a44-7b0d0d4e6c1a",
+		Title: "db01 disk sda",
+		Notes: &notes,
+		Style: &style,
+		Tags:  []string{"cosi:install", "team:db"},
+		Datapoints: []circapi.GraphDatapoint{
+			{CheckID: 123, MetricName: "disk`sda`reads", MetricType: "numeric", Name: "reads sda"},
+			{CheckID: 456, MetricName: "load`1min|ST[host:db01]", MetricType: "numeric", Name: "load"},
+		},
+	}
+	opts := &FromOptions{
+		Name:     "disk-tuned",
+		HostName: "db01",
+		CheckID:  123,
+		Notes:    "cosi:register,cosi_id:abc",
+		Tags:     []string{"cosi:install"},
+	}
+
+	tests := []struct {
+		name        string
+		graph       *circapi.Graph
+		opts        *FromOptions
+		item        string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (nil graph)", nil, opts, "", true, "invalid graph (nil)"},
+		{"invalid (nil options)", g, nil, "", true, "invalid options (nil)"},
+		{"invalid (name)", g, &FromOptions{}, "", true, "invalid template name (empty)"},
+		{"invalid (item)", g, opts, "sdb", true, "item (sdb) not found in any datapoint metric name"},
+		{"valid (static)", g, opts, "", false, ""},
+		{"valid (item)", g, opts, "sda", false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			if tst.opts != nil {
+				tst.opts.Item = tst.item
+			}
+			tmpl, err := FromGraph(tst.graph, tst.opts)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+
+			tmpl = roundTrip(t, tmpl)
+			cfg, ok := tmpl.Configs["disk-tuned"]
+			if !ok {
+				t.Fatalf("missing config (%#v)", tmpl.Configs)
+			}
+			if cfg.Variable != (tst.item != "") {
+				t.Fatalf("unexpected variable (%t)", cfg.Variable)
+			}
+
+			vars := map[string]interface{}{"HostName": "web02", "CheckID": uint(789), "Item": "sdb", "MetricName": "disk`sdb`reads"}
+			var graph circapi.Graph
+			expand(t, cfg.Template, vars, &graph)
+			expectedTitle := "web02 disk sda"
+			if tst.item != "" {
+				expectedTitle = "web02 disk sdb"
+			}
+			if graph.Title != expectedTitle {
+				t.Fatalf("unexpected title (%s)", graph.Title)
+			}
+			if graph.CID != "" || len(graph.Datapoints) != 0 {
+				t.Fatalf("unexpected cid/datapoints (%#v)", graph)
+			}
+			if *graph.Notes != "{{tuned}}" {
+				t.Fatalf("unexpected notes (%s)", *graph.Notes)
+			}
+			if !reflect.DeepEqual(graph.Tags, []string{"team:db"}) {
+				t.Fatalf("unexpected tags (%v)", graph.Tags)
+			}
+
+			if len(cfg.Datapoints) != 2 {
+				t.Fatalf("unexpected datapoints (%d)", len(cfg.Datapoints))
+			}
+			var dp circapi.GraphDatapoint
+			expand(t, cfg.Datapoints[0].Template, vars, &dp)
+			if dp.CheckID != 789 {
+				t.Fatalf("unexpected check id (%d)", dp.CheckID)
+			}
+			if tst.item != "" {
+				if cfg.Datapoints[0].MetricRx != "^disk`([^`]+)`reads$" {
+					t.Fatalf("unexpected metric_regex (%s)", cfg.Datapoints[0].MetricRx)
+				}
+				if dp.MetricName != "disk`sdb`reads" || dp.Name != "reads sdb" {
+					t.Fatalf("unexpected datapoint (%#v)", dp)
+				}
+			} else if dp.MetricName != "disk`sda`reads" {
+				t.Fatalf("unexpected metric name (%s)", dp.MetricName)
+			}
+
+			// other check, metric stream tags reference the host
+			expand(t, cfg.Datapoints[1].Template, vars, &dp)
+			if cfg.Datapoints[1].MetricRx != "" || dp.CheckID != 456 || dp.MetricName != "load`1min|ST[host:web02]" {
+				t.Fatalf("unexpected datapoint (%s) %#v", cfg.Datapoints[1].MetricRx, dp)
+			}
+		})
+	}
+}
+
+func TestFromGraphTokens(t *testing.T) {
+	t.Log("Testing FromGraph host name/item substrings of metric names")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	g := &circapi.Graph{
+		Title: "mongo mongodb queries",
+		Datapoints: []circapi.GraphDatapoint{
+			{CheckID: 123, MetricName: "mongodb`opcounters`query", MetricType: "numeric", Name: "mongo query"},
+			{CheckID: 123, MetricName: "mongodb`opcounters`query_total", MetricType: "numeric", Name: "total"},
+		},
+	}
+	opts := &FromOptions{
+		Name:     "mongo-ops",
+		HostName: "mongo",
+		CheckID:  123,
+		Item:     "query",
+	}
+
+	tmpl, err := FromGraph(g, opts)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	tmpl = roundTrip(t, tmpl)
+	cfg, ok := tmpl.Configs["mongo-ops"]
+	if !ok {
+		t.Fatalf("missing config (%#v)", tmpl.Configs)
+	}
+
+	vars := map[string]interface{}{"HostName": "db02", "CheckID": uint(789), "Item": "insert", "MetricName": "mongodb`opcounters`insert"}
+	var graph circapi.Graph
+	expand(t, cfg.Template, vars, &graph)
+	if graph.Title != "db02 mongodb queries" {
+		t.Fatalf("unexpected title (%s)", graph.Title)
+	}
+
+	if len(cfg.Datapoints) != 2 {
+		t.Fatalf("unexpected datapoints (%d)", len(cfg.Datapoints))
+	}
+	if cfg.Datapoints[0].MetricRx != "^mongodb`opcounters`([^`]+)$" {
+		t.Fatalf("unexpected metric_regex (%s)", cfg.Datapoints[0].MetricRx)
+	}
+	var dp circapi.GraphDatapoint
+	expand(t, cfg.Datapoints[0].Template, vars, &dp)
+	if dp.MetricName != "mongodb`opcounters`insert" || dp.Name != "db02 insert" {
+		t.Fatalf("unexpected datapoint (%#v)", dp)
+	}
+
+	// item is only part of the metric name, not variable
+	if cfg.Datapoints[1].MetricRx != "" {
+		t.Fatalf("unexpected metric_regex (%s)", cfg.Datapoints[1].MetricRx)
+	}
+	expand(t, cfg.Datapoints[1].Template, vars, &dp)
+	if dp.MetricName != "mongodb`opcounters`query_total" || dp.Name != "total" {
+		t.Fatalf("unexpected datapoint (%#v)", dp)
+	}
+}
+
+func TestFromDashboard(t *testing.T) {
+	t.Log("Testing FromDashboard")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	d := &circapi.Dashboard{
+		CID:   "/dashboard/56",
+		UUID:  "9b2d0b8e-0a9b-4a69-9c5f-3d8c3a4b5c6d",
+		Title: "db01 overview",
+		Widgets: []circapi.DashboardWidget{
+			{Name: "Graph", Type: "graph", WidgetID: "w1", Settings: circapi.DashboardWidgetSettings{GraphUUID: "graph-uuid-1", Label: "cpu"}},
+			{Name: "Graph", Type: "graph", WidgetID: "w2", Settings: circapi.DashboardWidgetSettings{GraphUUID: "graph-uuid-2"}},
+			{Name: "Gauge", Type: "gauge", WidgetID: "w3", Settings: circapi.DashboardWidgetSettings{CheckUUID: "check-uuid-1", MetricName: "load`1min"}},
+		},
+	}
+	opts := &FromOptions{
+		Name:      "system-tuned",
+		Config:    "overview",
+		HostName:  "db01",
+		CheckUUID: "check-uuid-1",
+		Graphs:    map[string]string{"graph-uuid-1": "graph-cpu-cpu"},
+	}
+
+	tmpl, err := FromDashboard(d, opts)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	tmpl = roundTrip(t, tmpl)
+	if tmpl.Description != "dashboard template created from /dashboard/56" {
+		t.Fatalf("unexpected description (%s)", tmpl.Description)
+	}
+	cfg, ok := tmpl.Configs["overview"]
+	if !ok {
+		t.Fatalf("missing config (%#v)", tmpl.Configs)
+	}
+
+	vars := map[string]interface{}{"HostName": "web02", "CheckUUID": "check-uuid-2", "GraphUUID": "graph-uuid-3"}
+	var dash circapi.Dashboard
+	expand(t, cfg.Template, vars, &dash)
+	if dash.Title != "web02 overview" || dash.CID != "" || dash.UUID != "" || len(dash.Widgets) != 0 {
+		t.Fatalf("unexpected dashboard (%#v)", dash)
+	}
+
+	if len(cfg.Widgets) != 3 {
+		t.Fatalf("unexpected widgets (%d)", len(cfg.Widgets))
+	}
+	expected := []struct {
+		graphName string
+		graphUUID string
+		checkUUID string
+	}{
+		{"graph-cpu-cpu", "graph-uuid-3", ""},
+		{"", "graph-uuid-2", ""}, // not registered, left as is
+		{"", "", "check-uuid-2"},
+	}
+	for i, e := range expected {
+		var w circapi.DashboardWidget
+		expand(t, cfg.Widgets[i].Template, vars, &w)
+		if cfg.Widgets[i].GraphName != e.graphName || w.Settings.GraphUUID != e.graphUUID || w.Settings.CheckUUID != e.checkUUID {
+			t.Fatalf("unexpected widget %d (%s) %#v", i, cfg.Widgets[i].GraphName, w.Settings)
+		}
+	}
+}
+
+func TestFromWorksheet(t *testing.T) {
+	t.Log("Testing FromWorksheet")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	desc := "db01 worksheet"
+	w := &circapi.Worksheet{
+		CID:          "/worksheet/78",
+		Description:  &desc,
+		Graphs:       []circapi.WorksheetGraph{{GraphCID: "/graph/1"}},
+		SmartQueries: []circapi.WorksheetSmartQuery{{Name: "cosi", Query: "x"}},
+		Tags:         []string{"os:linux", "team:db"},
+		Title:        "db01",
+	}
+
+	tmpl, err := FromWorksheet(w, &FromOptions{Name: "system-tuned", Description: "tuned", HostName: "db01", Tags: []string{"os:linux"}})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	tmpl = roundTrip(t, tmpl)
+	if tmpl.Description != "tuned" {
+		t.Fatalf("unexpected description (%s)", tmpl.Description)
+	}
+
+	var sheet circapi.Worksheet
+	expand(t, tmpl.Configs["system-tuned"].Template, map[string]interface{}{"HostName": "web02"}, &sheet)
+	if sheet.Title != "web02" || *sheet.Description != "web02 worksheet" || sheet.CID != "" {
+		t.Fatalf("unexpected worksheet (%#v)", sheet)
+	}
+	if sheet.Graphs == nil || len(sheet.Graphs) != 0 || len(sheet.SmartQueries) != 0 {
+		t.Fatalf("unexpected graphs/smart queries (%#v)", sheet)
+	}
+	if !reflect.DeepEqual(sheet.Tags, []string{"team:db"}) {
+		t.Fatalf("unexpected tags (%v)", sheet.Tags)
+	}
+}
+
+func TestLoadRegistrations(t *testing.T) {
+	t.Log("Testing LoadRegistrations")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	t.Log("invalid (empty)")
+	{
+		o := &FromOptions{}
+		err := o.LoadRegistrations("")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err.Error() != "invalid registration directory (empty)" {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+	}
+
+	t.Log("valid")
+	{
+		o := &FromOptions{Graphs: map[string]string{"1a2b": "graph-flag"}}
+		if err := o.LoadRegistrations("testdata/from"); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if o.CheckID != 123 || o.CheckUUID != "5f2a4b8e-1c3d-4e5f-8a9b-0c1d2e3f4a5b" {
+			t.Fatalf("unexpected check (%d/%s)", o.CheckID, o.CheckUUID)
+		}
+		expected := map[string]string{
+			"1a2b":                                 "graph-flag",
+			"0b7c6e27-7e7f-4c4c-8a44-7b0d0d4e6c1a": "graph-cpu-cpu",
+		}
+		if !reflect.DeepEqual(o.Graphs, expected) {
+			t.Fatalf("unexpected graphs (%v)", o.Graphs)
+		}
+	}
+}
+
+// roundTrip formats a template and parses the result
+func roundTrip(t *testing.T, tmpl *cosiapi.Template) *cosiapi.Template {
+	t.Helper()
+
+	data, err := Format(tmpl)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	var parsed cosiapi.Template
+	if err := toml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("parsing formatted template (%s)\n%s", err, string(data))
+	}
+	if !reflect.DeepEqual(*tmpl, parsed) {
+		t.Fatalf("formatted template differs\n%#v\n%#v\n%s", *tmpl, parsed, string(data))
+	}
+	return &parsed
+}
+
+// expand expands a template body and parses the result into v
+func expand(t *testing.T, text string, vars map[string]interface{}, v interface{}) {
+	t.Helper()
+
+	data, err := Expand("test", text, vars)
+	if err != nil {
+		t.Fatalf("expanding template (%s)\n%s", err, text)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("parsing expanded template (%s)\n%s", err, string(data))
+	}
+}
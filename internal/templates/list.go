@@ -0,0 +1,103 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/pkg/errors"
+)
+
+// LocalTemplate describes a template available locally
+type LocalTemplate struct {
+	ID         string
+	Source     string // custom|cache, the source used when loading the template
+	File       string // file the template will be loaded from
+	Overridden bool   // a cached copy exists but the custom template is used
+}
+
+// FindLocal returns the templates in the custom templates directory and
+// the cache directory, templates in the custom directory take precedence.
+func FindLocal(customDir, cacheDir string) ([]*LocalTemplate, error) {
+	custom, err := templateIDs(customDir)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := templateIDs(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*LocalTemplate{}
+	for id := range custom {
+		_, inCache := cached[id]
+		list = append(list, &LocalTemplate{ID: id, Source: SourceCustom, File: TemplateFile(customDir, id), Overridden: inCache})
+	}
+	for id := range cached {
+		if _, ok := custom[id]; ok {
+			continue
+		}
+		list = append(list, &LocalTemplate{ID: id, Source: SourceCache, File: TemplateFile(cacheDir, id)})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list, nil
+}
+
+// List displays the local templates and the source each will be loaded from.
+// Templates not found locally are fetched from cosi-server when used.
+func List(w io.Writer, customDir, cacheDir string, quiet bool) error {
+	list, err := FindLocal(customDir, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	format := "%-30s %-8s %s\n"
+	if !quiet {
+		fmt.Fprintf(w, format, "ID", "Source", "File")
+	}
+	for _, lt := range list {
+		file := lt.File
+		if lt.Overridden {
+			file += " (overrides cache)"
+		}
+		fmt.Fprintf(w, format, lt.ID, lt.Source, file)
+	}
+
+	return nil
+}
+
+// templateIDs returns the ids of the templates in dir, a missing directory
+// is treated as containing no templates
+func templateIDs(dir string) (map[string]string, error) {
+	ids := make(map[string]string)
+	if dir == "" {
+		return ids, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ids, nil
+		}
+		return nil, errors.Wrap(err, "reading template directory")
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !file.Mode().IsRegular() || !strings.HasPrefix(name, templateFilePrefix) || path.Ext(name) != cosiapi.TemplateFileExtension {
+			continue
+		}
+		ids[strings.TrimSuffix(strings.TrimPrefix(name, templateFilePrefix), cosiapi.TemplateFileExtension)] = name
+	}
+	return ids, nil
+}
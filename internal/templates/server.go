@@ -0,0 +1,113 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+)
+
+// serverClient fetches templates from cosi-server. It makes the same
+// requests as the cosi-server api client, which has no way to cancel a
+// request, so that a fetch can be limited by the fetch timeout.
+type serverClient struct {
+	cosiURL *url.URL
+	query   string
+	client  *http.Client
+}
+
+// newServerClient creates a cosi-server template client for the system
+func newServerClient(cfg *cosiapi.Config) (*serverClient, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid config (nil)")
+	}
+
+	u, err := url.Parse(cfg.CosiURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CosiURL")
+	}
+
+	// NOTE: values are escaped twice, the same as the cosi-server api client
+	q := url.Values{}
+	q.Set("type", url.QueryEscape(cfg.OSType))
+	q.Set("dist", url.QueryEscape(cfg.OSDistro))
+	q.Set("vers", url.QueryEscape(cfg.OSVersion))
+	q.Set("arch", url.QueryEscape(cfg.SysArch))
+
+	return &serverClient{
+		cosiURL: u,
+		query:   q.Encode(),
+		client:  &http.Client{},
+	}, nil
+}
+
+// FetchTemplate retrieves a template from cosi-server
+func (c *serverClient) FetchTemplate(id string) (*cosiapi.Template, error) {
+	return c.FetchTemplateContext(context.Background(), id)
+}
+
+// FetchTemplateContext retrieves a template from cosi-server, the request
+// is cancelled when the context is done
+func (c *serverClient) FetchTemplateContext(ctx context.Context, id string) (*cosiapi.Template, error) {
+	if id == "" {
+		return nil, errors.New("invalid id (empty)")
+	}
+
+	idParts := strings.SplitN(id, "-", 2)
+	if len(idParts) != 2 {
+		return nil, errors.Wrap(errors.Errorf("invalid id format (%s)", id), "parsing id")
+	}
+
+	u, err := c.cosiURL.Parse(fmt.Sprintf("/template/%s/%s/", idParts[0], idParts[1]))
+	if err != nil {
+		return nil, errors.Wrap(err, "setting URL path")
+	}
+	u.RawQuery = c.query
+
+	data, err := c.get(ctx, u)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching template")
+	}
+
+	var t cosiapi.Template
+	if err := toml.Unmarshal(data, &t); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s template", id)
+	}
+
+	return &t, nil
+}
+
+func (c *serverClient) get(ctx context.Context, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cosi-server preparing request")
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "cosi-server request")
+	}
+
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cosi-server response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s - %s - %s", resp.Status, u.String(), strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
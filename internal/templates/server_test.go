@@ -0,0 +1,83 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
+)
+
+func TestServerClient(t *testing.T) {
+	t.Log("Testing serverClient")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("dist") != "ubuntu" || r.URL.Query().Get("arch") != "x86_64" {
+			http.Error(w, "missing system query", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/template/graph/cpu/":
+			_, _ = w.Write([]byte("type = \"graph\"\nname = \"cpu\"\nversion = \"1.0.0\"\n"))
+		case "/template/graph/invalid/":
+			_, _ = w.Write([]byte("type = \n"))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	if _, err := newServerClient(nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	client, err := newServerClient(&cosiapi.Config{OSType: "linux", OSDistro: "ubuntu", OSVersion: "18.04", SysArch: "x86_64", CosiURL: ts.URL})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		shouldFail  bool
+		notFound    bool
+		expectedErr string
+	}{
+		{"invalid (empty)", "", true, false, "invalid id (empty)"},
+		{"invalid (format)", "graph", true, false, "parsing id: invalid id format (graph)"},
+		{"invalid (parse)", "graph-invalid", true, false, ""},
+		{"not found", "graph-none", true, true, ""},
+		{"valid", "graph-cpu", false, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			tmpl, err := client.FetchTemplate(tst.id)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if tst.expectedErr != "" && err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				if apierr.IsNotFound(err) != tst.notFound {
+					t.Fatalf("unexpected not found (%v) for (%s)", !tst.notFound, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if tmpl.Name != "cpu" || tmpl.Version != "1.0.0" {
+				t.Fatalf("unexpected template (%#v)", tmpl)
+			}
+		})
+	}
+}
@@ -7,23 +7,33 @@
 package templates
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
 	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/apierr"
 	"github.com/circonus-labs/cosi-tool/internal/config"
-	"github.com/circonus-labs/cosi-tool/internal/registration/regfiles"
 	"github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 // Templates defines the template object
 type Templates struct {
-	client CosiAPI
+	client       CosiAPI
+	customDir    string
+	cacheTTL     time.Duration
+	cacheMu      sync.Mutex
+	offline      bool
+	fetchWorkers int
+	fetchTimeout time.Duration
 }
 
 // FetchAllResult defines the result from a fetching attempt when
@@ -32,6 +42,7 @@ type FetchAllResult struct {
 	IDX      int               // input templateList index
 	Template *cosiapi.Template // a fetched template or nil
 	Err      error             // an error or nil
+	NotFound bool              // no template for the id (e.g. no template for a plugin), not a failure
 }
 
 const (
@@ -58,12 +69,31 @@ const (
 	// QuietDefault is the default value for the quiet flag
 	QuietDefault = false
 
+	// KeyFetchWorkers is the number of templates fetched concurrently by fetch all
+	KeyFetchWorkers = "template.fetch.workers"
+	// DefaultFetchWorkers is the default number of concurrent fetches
+	DefaultFetchWorkers = 4
+
+	// KeyFetchTimeout is the timeout for fetching each template in fetch all
+	KeyFetchTimeout = "template.fetch.timeout"
+	// DefaultFetchTimeout is the default timeout for fetching a template
+	DefaultFetchTimeout = 30 * time.Second
+
 	// KeyForce is a flag to force overwritting files
 	KeyForce = "template.force"
 	// ForceDefault is the default value for the force flag
 	ForceDefault = false
+
+	// SourceCustom template loaded from the custom templates directory
+	SourceCustom = "custom"
+	// SourceCache template loaded from the cache (registration directory)
+	SourceCache = "cache"
+	// SourceServer template fetched from cosi-server (and cached)
+	SourceServer = "cosi-server"
 )
 
+const templateFilePrefix = "template-"
+
 var (
 	// IDListDefault is the base list of default templates.
 	// Based on the metrics returned by the running agent, additional
@@ -73,7 +103,8 @@ var (
 
 // New returns a new templates instance
 func New(client CosiAPI) (*Templates, error) {
-	if client == nil {
+	offline := viper.GetBool(config.KeyOffline)
+	if client == nil && !offline {
 		osType := viper.GetString(config.KeySystemOSType)
 		osDist := viper.GetString(config.KeySystemOSDistro)
 		osVers := viper.GetString(config.KeySystemOSVersion)
@@ -96,7 +127,7 @@ func New(client CosiAPI) (*Templates, error) {
 			return nil, errors.Errorf("invalid cosi url (empty)")
 		}
 
-		cli, err := cosiapi.New(&cosiapi.Config{
+		cli, err := newServerClient(&cosiapi.Config{
 			OSType:    osType,
 			OSDistro:  osDist,
 			OSVersion: osVers,
@@ -110,7 +141,13 @@ func New(client CosiAPI) (*Templates, error) {
 	}
 
 	t := &Templates{
-		client: client,
+		client:    client,
+		customDir: viper.GetString(config.KeyTemplateDir),
+		cacheTTL:  viper.GetDuration(KeyCacheTTL),
+		offline:   offline,
+		// fetch all settings, defaults are used if not set
+		fetchWorkers: viper.GetInt(KeyFetchWorkers),
+		fetchTimeout: viper.GetDuration(KeyFetchTimeout),
 	}
 
 	return t, nil
@@ -121,6 +158,9 @@ func (t *Templates) Fetch(id string) (*cosiapi.Template, error) {
 	if id == "" {
 		return nil, errors.Errorf("invalid id (empty)")
 	}
+	if t.offline {
+		return nil, errors.Errorf("unable to fetch template (%s), cosi-server is not used in offline mode", id)
+	}
 
 	template, err := t.client.FetchTemplate(id)
 	if err != nil {
@@ -130,48 +170,132 @@ func (t *Templates) Fetch(id string) (*cosiapi.Template, error) {
 	return template, nil
 }
 
-// FetchAll retrieves all templates specified using the cosi-server API
+// FetchAll retrieves all templates specified using the cosi-server API.
+// Templates are fetched concurrently (a bounded number of workers), each
+// fetch is limited by the fetch timeout. Results are in the same order as
+// templateList. A template which does not exist (e.g. no template for a
+// plugin) is marked NotFound.
 func (t *Templates) FetchAll(templateList []string) (*[]FetchAllResult, error) {
 	if len(templateList) == 0 {
 		return nil, errors.Errorf("invalid template list (empty)")
 	}
 
-	ret := make([]FetchAllResult, 0, len(templateList))
-	for idx, id := range templateList {
-		template, err := t.Fetch(id)
-		ret = append(ret, FetchAllResult{idx, template, err})
+	workers := t.fetchWorkers
+	if workers <= 0 {
+		workers = DefaultFetchWorkers
+	}
+	if workers > len(templateList) {
+		workers = len(templateList)
+	}
+
+	ret := make([]FetchAllResult, len(templateList))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				template, err := t.fetchWithTimeout(templateList[idx])
+				ret[idx] = FetchAllResult{IDX: idx, Template: template, Err: err, NotFound: apierr.IsNotFound(err)}
+			}
+		}()
+	}
+	for idx := range templateList {
+		jobs <- idx
 	}
+	close(jobs)
+	wg.Wait()
 
 	return &ret, nil
 }
 
-// Load returns a cosi template. It will load the template specified by <id>
-// if found in the dir. If not, it will fetch the template from the cosi api
-// and save it into the dir.
+// fetchWithTimeout fetches a template, the fetch is cancelled if the fetch
+// timeout is exceeded (no timeout if the fetch timeout is not set or the
+// client is not able to cancel a fetch)
+func (t *Templates) fetchWithTimeout(id string) (*cosiapi.Template, error) {
+	client, ok := t.client.(CosiContextAPI)
+	if !ok || t.fetchTimeout <= 0 || id == "" || t.offline {
+		return t.Fetch(id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.fetchTimeout)
+	defer cancel()
+
+	template, err := client.FetchTemplateContext(ctx, id)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.Errorf("fetching template (%s) timed out after %s", id, t.fetchTimeout)
+	}
+	return template, err
+}
+
+// Load returns a cosi template. The template specified by <id> is loaded
+// from the custom templates directory if found, otherwise from the dir
+// (cache). If not found in either, it will fetch the template from the
+// cosi api and save it into the dir. In offline mode the template is
+// never fetched, a missing template is an error (not found).
 func (t *Templates) Load(dir string, id string) (*cosiapi.Template, bool, error) {
+	tmpl, _, found, err := t.LoadWithSource(dir, id)
+	return tmpl, found, err
+}
+
+// LoadWithSource returns a cosi template (see Load) along with the source
+// the template was loaded from (custom|cache|cosi-server)
+func (t *Templates) LoadWithSource(dir string, id string) (*cosiapi.Template, string, bool, error) {
 	if dir == "" {
-		return nil, false, errors.Errorf("invalid directory (empty)")
+		return nil, "", false, errors.Errorf("invalid directory (empty)")
 	}
 	if id == "" {
-		return nil, false, errors.New("invalid id (empty)")
+		return nil, "", false, errors.New("invalid id (empty)")
 	}
 
-	fn := path.Join(dir, "template-"+id+cosiapi.TemplateFileExtension)
+	if t.customDir != "" {
+		tmpl, found, err := readTemplate(TemplateFile(t.customDir, id))
+		if found || err != nil {
+			return tmpl, SourceCustom, true, err
+		}
+	}
 
-	data, err := ioutil.ReadFile(fn)
+	cached, found, err := readTemplate(TemplateFile(dir, id))
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return nil, true, errors.Wrap(err, "reading template")
-		}
-		// not found, retrieve from cosi api
-		tmpl, ferr := t.Fetch(id)
-		if ferr != nil {
-			return nil, !strings.Contains(ferr.Error(), "404 Not Found"), ferr
+		return nil, SourceCache, true, err
+	}
+	if found && (t.offline || !t.expired(dir, id)) {
+		return cached, SourceCache, true, nil
+	}
+	if t.offline {
+		return nil, SourceCache, false, errors.Errorf("template (%s) not found in the custom templates directory or the cache (offline mode, see 'cosi template import')", id)
+	}
+
+	// not found (or expired), retrieve from cosi api
+	tmpl, ferr := t.Fetch(id)
+	if ferr != nil {
+		if found {
+			log.Warn().Err(ferr).Str("id", id).Msg("refreshing expired cached template, using cached template")
+			return cached, SourceCache, true, nil
 		}
-		if err := regfiles.Save(fn, *tmpl, true); err != nil { // NOTE: deref ptr, toml.Marshal can't take &struct{}
-			return nil, false, err
+		return nil, SourceServer, !apierr.IsNotFound(ferr), ferr
+	}
+	if _, err := t.cacheTemplate(dir, id, tmpl); err != nil {
+		return nil, SourceServer, false, err
+	}
+	return tmpl, SourceServer, true, nil
+}
+
+// TemplateFile returns the template file name for <id> in dir
+func TemplateFile(dir, id string) string {
+	return path.Join(dir, templateFilePrefix+id+cosiapi.TemplateFileExtension)
+}
+
+// readTemplate reads and parses a template file, returns false if the file
+// does not exist
+func readTemplate(fn string) (*cosiapi.Template, bool, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
 		}
-		return tmpl, true, nil
+		return nil, true, errors.Wrap(err, "reading template")
 	}
 
 	tv := cosiapi.Template{}
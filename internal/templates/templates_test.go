@@ -6,9 +6,18 @@
 package templates
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	agentapi "github.com/circonus-labs/circonus-agent/api"
 	cosiapi "github.com/circonus-labs/cosi-server/api"
@@ -117,6 +126,97 @@ func TestFetchAll(t *testing.T) {
 	}
 }
 
+func TestFetchAllConcurrent(t *testing.T) {
+	t.Log("Testing FetchAll (concurrent)")
+
+	var active, maxActive int32
+	var slowCancelled int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/template/graph/"), "/")
+		switch name {
+		case "slow":
+			select {
+			case <-r.Context().Done():
+				atomic.StoreInt32(&slowCancelled, 1)
+				return
+			case <-time.After(time.Second):
+			}
+		case "none":
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		case "error":
+			http.Error(w, "forced error", http.StatusInternalServerError)
+			return
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+		fmt.Fprintf(w, "type = \"graph\"\nname = %q\n", name)
+	}))
+	defer ts.Close()
+
+	client, err := newServerClient(&cosiapi.Config{OSType: "linux", OSDistro: "ubuntu", OSVersion: "18.04", SysArch: "x86_64", CosiURL: ts.URL})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	tmpl := Templates{client: client, fetchWorkers: 2, fetchTimeout: 100 * time.Millisecond}
+
+	list := []string{"graph-slow", "graph-a", "graph-none", "graph-b", "graph-error", "graph-c"}
+	results, err := tmpl.FetchAll(list)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if len(*results) != len(list) {
+		t.Fatalf("expected %d results, got %d", len(list), len(*results))
+	}
+	if m := atomic.LoadInt32(&maxActive); m > 2 {
+		t.Fatalf("expected at most 2 concurrent fetches, got %d", m)
+	}
+
+	for idx, r := range *results {
+		if r.IDX != idx {
+			t.Fatalf("unexpected idx (%d) expected (%d)", r.IDX, idx)
+		}
+		switch list[idx] {
+		case "graph-slow":
+			if r.Err == nil || r.Err.Error() != "fetching template (graph-slow) timed out after 100ms" {
+				t.Fatalf("expected timeout, got (%v)", r.Err)
+			}
+			if r.NotFound {
+				t.Fatal("timeout should not be not found")
+			}
+			for i := 0; i < 100 && atomic.LoadInt32(&slowCancelled) == 0; i++ {
+				time.Sleep(time.Millisecond)
+			}
+			if atomic.LoadInt32(&slowCancelled) != 1 {
+				t.Fatal("expected slow fetch request to be cancelled")
+			}
+		case "graph-none":
+			if r.Err == nil || !r.NotFound {
+				t.Fatalf("expected not found (%v)", r.Err)
+			}
+		case "graph-error":
+			if r.Err == nil || r.NotFound {
+				t.Fatalf("expected error, not a not found (%v)", r.Err)
+			}
+		default:
+			if r.Err != nil {
+				t.Fatalf("unexpected error (%s)", r.Err)
+			}
+			if r.Template.Name != strings.TrimPrefix(list[idx], "graph-") {
+				t.Fatalf("unexpected template (%s) for %s", r.Template.Name, list[idx])
+			}
+		}
+	}
+}
+
 func TestLoad(t *testing.T) {
 	t.Log("Testing Load")
 
@@ -165,6 +265,92 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadWithSource(t *testing.T) {
+	t.Log("Testing LoadWithSource")
+
+	dir, err := ioutil.TempDir("", "cosi-templates-test")
+	if err != nil {
+		t.Fatalf("creating temp dir (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	customDir := filepath.Join(dir, "custom")
+	cacheDir := filepath.Join(dir, "cache")
+	for _, d := range []string{customDir, cacheDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("creating dir (%s)", err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "template-test-valid.toml"))
+	if err != nil {
+		t.Fatalf("reading template (%s)", err)
+	}
+	for _, fn := range []string{
+		TemplateFile(customDir, "graph-custom"),
+		TemplateFile(customDir, "graph-both"),
+		TemplateFile(cacheDir, "graph-both"),
+		TemplateFile(cacheDir, "graph-cached"),
+	} {
+		if err := ioutil.WriteFile(fn, data, 0644); err != nil {
+			t.Fatalf("writing template (%s)", err)
+		}
+	}
+
+	graphTemplate = &cosiapi.Template{Type: "graph", Name: "server"}
+	tmpl := Templates{client: genMockClient(), customDir: customDir}
+
+	tests := []struct {
+		id     string
+		source string
+	}{
+		{"graph-custom", SourceCustom},
+		{"graph-both", SourceCustom},
+		{"graph-cached", SourceCache},
+		{"graph-server", SourceServer},
+	}
+
+	for _, tst := range tests {
+		_, source, found, err := tmpl.LoadWithSource(cacheDir, tst.id)
+		if err != nil {
+			t.Fatalf("%s unexpected error (%s)", tst.id, err)
+		}
+		if !found {
+			t.Fatalf("%s expected template to be found", tst.id)
+		}
+		if source != tst.source {
+			t.Fatalf("%s unexpected source (%s) expected (%s)", tst.id, source, tst.source)
+		}
+	}
+
+	t.Log("fetched template cached (not custom)")
+	{
+		if _, err := os.Stat(TemplateFile(cacheDir, "graph-server")); err != nil {
+			t.Fatalf("expected cached template (%s)", err)
+		}
+		if _, err := os.Stat(TemplateFile(customDir, "graph-server")); !os.IsNotExist(err) {
+			t.Fatalf("expected no custom template (%v)", err)
+		}
+	}
+
+	t.Log("List")
+	{
+		var buf bytes.Buffer
+		if err := List(&buf, customDir, cacheDir, true); err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		expected := []string{
+			"graph-both                     custom   " + TemplateFile(customDir, "graph-both") + " (overrides cache)",
+			"graph-cached                   cache    " + TemplateFile(cacheDir, "graph-cached"),
+			"graph-custom                   custom   " + TemplateFile(customDir, "graph-custom"),
+			"graph-server                   cache    " + TemplateFile(cacheDir, "graph-server"),
+		}
+		if strings.TrimSpace(buf.String()) != strings.Join(expected, "\n") {
+			t.Fatalf("unexpected output\n%s", buf.String())
+		}
+	}
+}
+
 func TestDefaultTemplateList(t *testing.T) {
 	t.Log("Testing DefaultTemplateList")
 
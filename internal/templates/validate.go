@@ -0,0 +1,457 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	cosiapi "github.com/circonus-labs/cosi-server/api"
+	"github.com/circonus-labs/cosi-tool/internal/dashboard"
+	circapi "github.com/circonus-labs/go-apiclient"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+)
+
+// Issue defines a problem found while validating a template
+type Issue struct {
+	Line int    // line in the template file (0 if unknown)
+	Key  string // template key the issue applies to (e.g. configs.cpu.template)
+	Msg  string
+}
+
+// String returns the issue formatted as line: key: message
+func (i Issue) String() string {
+	s := ""
+	if i.Line > 0 {
+		s = strconv.Itoa(i.Line) + ": "
+	}
+	if i.Key != "" {
+		s += i.Key + ": "
+	}
+	return s + i.Msg
+}
+
+type validator struct {
+	lines  []string
+	vars   map[string]string
+	issues []Issue
+}
+
+var (
+	// (line, col): message - go-toml parse and unmarshal errors
+	tomlErrRx = regexp.MustCompile(`^\((\d+), \d+\): (.*)$`)
+	// template: name:line[:col]: message - text/template and html/template errors
+	tmplErrRx = regexp.MustCompile(`^(?:html/)?template: ?[^:]+:(\d+):(?:\d+:)? ?(.*)$`)
+)
+
+// ParseVars parses a list of Name=value settings into template variables
+func ParseVars(settings []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, setting := range settings {
+		kv := strings.SplitN(setting, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid template variable (%s) - must be Name=value", setting)
+		}
+		vars[kv[0]] = kv[1]
+	}
+	return vars, nil
+}
+
+// ValidateFile reads and validates a template file, see Validate.
+func ValidateFile(fn string, vars map[string]string) ([]Issue, error) {
+	if fn == "" {
+		return nil, errors.New("invalid file name (empty)")
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading template")
+	}
+	return Validate(data, vars), nil
+}
+
+// Validate checks template data - the toml structure against a cosi
+// template, the metric_regex and filter regular expressions, and expands
+// each config, datapoint and widget template with sample variables
+// verifying the result parses into the Circonus API type for the template.
+// vars are added to (or override) the sample variables, e.g. for dashboard
+// meta variables. Returns a list of issues ordered by line.
+func Validate(data []byte, vars map[string]string) []Issue {
+	v := validator{
+		lines: strings.Split(string(data), "\n"),
+		vars:  vars,
+	}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		v.addTOMLErr(err)
+		return v.issues
+	}
+
+	v.checkKeys(tree)
+
+	var tv cosiapi.Template
+	if err := tree.Unmarshal(&tv); err != nil {
+		v.addTOMLErr(err)
+		return v.sorted()
+	}
+
+	v.checkTemplate(tree, &tv)
+
+	return v.sorted()
+}
+
+// ShowIssues writes the issues found in a template file, one per line
+func ShowIssues(w io.Writer, fn string, issues []Issue) {
+	for _, i := range issues {
+		fmt.Fprintf(w, "%s:%s\n", fn, i.String())
+	}
+}
+
+func (v *validator) add(line int, key, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Line: line, Key: key, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) addTOMLErr(err error) {
+	if m := tomlErrRx.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		v.add(line, "", "%s", m[2])
+		return
+	}
+	v.add(0, "", "%s", err.Error())
+}
+
+func (v *validator) sorted() []Issue {
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		return v.issues[i].Line < v.issues[j].Line
+	})
+	return v.issues
+}
+
+// checkKeys reports any keys which are not part of a cosi template
+func (v *validator) checkKeys(tree *toml.Tree) {
+	v.unknownKeys(tree, "", "type", "name", "version", "description", "configs", "variable", "filters")
+	if filters, ok := tree.Get("filters").(*toml.Tree); ok {
+		v.unknownKeys(filters, "filters", "include", "exclude")
+	}
+	configs, ok := tree.Get("configs").(*toml.Tree)
+	if !ok {
+		return
+	}
+	for _, name := range configs.Keys() {
+		cfg, ok := configs.GetPath([]string{name}).(*toml.Tree)
+		if !ok {
+			continue
+		}
+		prefix := "configs." + name
+		v.unknownKeys(cfg, prefix, "datapoints", "template", "variable", "widgets")
+		if dps, ok := cfg.Get("datapoints").([]*toml.Tree); ok {
+			for idx, dp := range dps {
+				dpPrefix := fmt.Sprintf("%s.datapoints[%d]", prefix, idx)
+				v.unknownKeys(dp, dpPrefix, "variable", "filter", "metric_regex", "template")
+				if filter, ok := dp.Get("filter").(*toml.Tree); ok {
+					v.unknownKeys(filter, dpPrefix+".filter", "include", "exclude")
+				}
+			}
+		}
+		if widgets, ok := cfg.Get("widgets").([]*toml.Tree); ok {
+			for idx, widget := range widgets {
+				v.unknownKeys(widget, fmt.Sprintf("%s.widgets[%d]", prefix, idx), "graph_name", "template")
+			}
+		}
+	}
+}
+
+func (v *validator) unknownKeys(tree *toml.Tree, prefix string, allowed ...string) {
+	valid := make(map[string]bool)
+	for _, k := range allowed {
+		valid[k] = true
+	}
+	for _, k := range tree.Keys() {
+		if valid[k] {
+			continue
+		}
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		v.add(tree.GetPositionPath([]string{k}).Line, key, "unknown key")
+	}
+}
+
+// checkTemplate verifies the template content
+func (v *validator) checkTemplate(tree *toml.Tree, tv *cosiapi.Template) {
+	line := func(keys ...string) int {
+		return keyLine(tree, keys...)
+	}
+
+	switch tv.Type {
+	case "":
+		v.add(0, "type", "required")
+	case "check", "dashboard", "graph", "worksheet":
+	default:
+		v.add(line("type"), "type", "invalid type (%s) - must be check|dashboard|graph|worksheet", tv.Type)
+	}
+	if tv.Name == "" {
+		v.add(0, "name", "required")
+	}
+	if tv.Version == "" {
+		v.add(0, "version", "required")
+	}
+	if len(tv.Configs) == 0 {
+		v.add(0, "configs", "at least one config required")
+	}
+
+	if tv.Type != "graph" {
+		if tv.Variable {
+			v.add(line("variable"), "variable", "only valid in graph templates")
+		}
+		if len(tv.Filter.Include) > 0 || len(tv.Filter.Exclude) > 0 {
+			v.add(line("filters"), "filters", "only valid in graph templates")
+		}
+	}
+	v.checkRegexList(line("filters", "include"), "filters.include", tv.Filter.Include)
+	v.checkRegexList(line("filters", "exclude"), "filters.exclude", tv.Filter.Exclude)
+
+	names := make([]string, 0, len(tv.Configs))
+	for name := range tv.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg := tv.Configs[name]
+		prefix := "configs." + name
+		cfgTree, _ := tree.GetPath([]string{"configs", name}).(*toml.Tree)
+		if cfgTree == nil {
+			continue
+		}
+		cfgLine := func(keys ...string) int {
+			return keyLine(cfgTree, keys...)
+		}
+
+		if cfg.Template == "" {
+			v.add(cfgTree.Position().Line, prefix+".template", "required")
+		}
+
+		if tv.Type != "graph" {
+			if cfg.Variable {
+				v.add(cfgLine("variable"), prefix+".variable", "only valid in graph templates")
+			}
+			if len(cfg.Datapoints) > 0 {
+				v.add(cfgLine("datapoints"), prefix+".datapoints", "only valid in graph templates")
+			}
+		}
+		if tv.Type != "dashboard" && len(cfg.Widgets) > 0 {
+			v.add(cfgLine("widgets"), prefix+".widgets", "only valid in dashboard templates")
+		}
+
+		switch tv.Type {
+		case "check":
+			v.expand(cfgLine("template"), prefix+".template", cfg.Template, sampleVars(false), &circapi.CheckBundle{})
+		case "graph":
+			v.checkGraph(prefix, cfgTree, &cfg)
+		case "dashboard":
+			v.checkDashboard(prefix, cfgTree, &cfg)
+		case "worksheet":
+			v.expand(cfgLine("template"), prefix+".template", cfg.Template, sampleVars(true), &circapi.Worksheet{})
+		}
+	}
+}
+
+// sampleVars returns sample values for the variables shared by all
+// templates (see registration options TemplateVars), check templates
+// are expanded before the check exists so have no check variables
+func sampleVars(withCheck bool) map[string]interface{} {
+	vars := map[string]interface{}{
+		"HostName":   "cosi-validate",
+		"HostIP":     "127.0.0.1",
+		"HostTarget": "127.0.0.1",
+		"OSType":     "linux",
+		"OSDistro":   "ubuntu",
+		"OSVersion":  "18.04",
+		"SysArch":    "x86_64",
+		"CosiID":     "00000000-0000-0000-0000-000000000000",
+		"BrokerID":   "/broker/1",
+		"GroupID":    "cosi-validate-group",
+		"NumCPU":     2,
+	}
+	if withCheck {
+		vars["BundleCID"] = "/check_bundle/1234"
+		vars["CheckCID"] = "/check/1234"
+		vars["CheckID"] = uint(1234)
+		vars["CheckUUID"] = "00000000-0000-0000-0000-000000000000"
+	}
+	return vars
+}
+
+// checkGraph verifies the datapoints and expands the templates for a graph config
+func (v *validator) checkGraph(prefix string, cfgTree *toml.Tree, cfg *cosiapi.TemplateConfig) {
+	if len(cfg.Datapoints) == 0 {
+		v.add(cfgTree.Position().Line, prefix+".datapoints", "at least one datapoint required")
+		return
+	}
+
+	gvars := sampleVars(true)
+	if cfg.Variable {
+		gvars["Item"] = "item"
+	}
+	v.expand(keyLine(cfgTree, "template"), prefix+".template", cfg.Template, gvars, &circapi.Graph{})
+
+	dpTrees, _ := cfgTree.Get("datapoints").([]*toml.Tree)
+	haveRegex := false
+	for idx, dp := range cfg.Datapoints {
+		dpPrefix := fmt.Sprintf("%s.datapoints[%d]", prefix, idx)
+		dpLine := func(keys ...string) int {
+			if idx >= len(dpTrees) {
+				return 0
+			}
+			return keyLine(dpTrees[idx], keys...)
+		}
+
+		if dp.Template == "" {
+			v.add(dpLine(), dpPrefix+".template", "required")
+		}
+		if dp.MetricRx != "" {
+			haveRegex = true
+			if rx, err := regexp.Compile(dp.MetricRx); err != nil {
+				v.add(dpLine("metric_regex"), dpPrefix+".metric_regex", "invalid regex (%s)", err)
+			} else if rx.NumSubexp() < 1 {
+				v.add(dpLine("metric_regex"), dpPrefix+".metric_regex", "invalid regex, need 1 subexpression (%s)", dp.MetricRx)
+			}
+		} else if dp.Variable {
+			v.add(dpLine(), dpPrefix+".metric_regex", "required for variable datapoint")
+		}
+		v.checkRegexList(dpLine("filter", "include"), dpPrefix+".filter.include", dp.Filter.Include)
+		v.checkRegexList(dpLine("filter", "exclude"), dpPrefix+".filter.exclude", dp.Filter.Exclude)
+
+		dpvars := gvars
+		if (cfg.Variable && dp.MetricRx != "") || (!cfg.Variable && dp.Variable) {
+			dpvars = sampleVars(true)
+			dpvars["Item"] = "item"
+			dpvars["ItemIndex"] = idx
+			dpvars["MetricName"] = "metric`item"
+		}
+		v.expand(dpLine("template"), dpPrefix+".template", dp.Template, dpvars, &circapi.GraphDatapoint{})
+	}
+
+	if cfg.Variable && !haveRegex {
+		v.add(keyLine(cfgTree, "variable"), prefix+".variable", "variable graph requires a datapoint with a metric_regex")
+	}
+}
+
+// checkDashboard expands the templates for a dashboard config
+func (v *validator) checkDashboard(prefix string, cfgTree *toml.Tree, cfg *cosiapi.TemplateConfig) {
+	vars := sampleVars(true)
+	vars[dashboard.InstanceVar] = "main" // set for dashboard instances
+	v.expand(keyLine(cfgTree, "template"), prefix+".template", cfg.Template, vars, &circapi.Dashboard{})
+
+	widgetTrees, _ := cfgTree.Get("widgets").([]*toml.Tree)
+	for idx, widget := range cfg.Widgets {
+		widgetPrefix := fmt.Sprintf("%s.widgets[%d]", prefix, idx)
+		widgetLine := 0
+		if idx < len(widgetTrees) {
+			widgetLine = keyLine(widgetTrees[idx], "template")
+		}
+		if widget.Template == "" {
+			v.add(widgetLine, widgetPrefix+".template", "required")
+			continue
+		}
+		wvars := make(map[string]interface{})
+		for k, val := range vars {
+			wvars[k] = val
+		}
+		if widget.GraphName != "" {
+			wvars["GraphUUID"] = "00000000-0000-0000-0000-000000000000"
+		}
+		v.expand(widgetLine, widgetPrefix+".template", widget.Template, wvars, &circapi.DashboardWidget{})
+	}
+}
+
+// keyLine returns the line of a key in the tree, falling back to the closest
+// parent key (positions within inline tables are not tracked) or the tree
+func keyLine(tree *toml.Tree, keys ...string) int {
+	for n := len(keys); n > 0; n-- {
+		if l := tree.GetPositionPath(keys[:n]).Line; l > 0 && l >= tree.Position().Line {
+			return l
+		}
+	}
+	return tree.Position().Line
+}
+
+func (v *validator) checkRegexList(line int, key string, list []string) {
+	for _, expr := range list {
+		if _, err := regexp.Compile(expr); err != nil {
+			v.add(line, key, "invalid regex (%s)", err)
+		}
+	}
+}
+
+// expand executes a template with the sample variables (using Parse, as
+// registration does) and verifies the result parses into target. Template errors are reported on the template file line.
+func (v *validator) expand(line int, key, text string, vars map[string]interface{}, target interface{}) {
+	if text == "" {
+		return
+	}
+	for k, val := range v.vars {
+		vars[k] = val
+	}
+
+	var b bytes.Buffer
+	tmpl, err := Parse(key, text)
+	if err == nil {
+		err = tmpl.Execute(&b, vars)
+	}
+	if err != nil {
+		if m := tmplErrRx.FindStringSubmatch(err.Error()); m != nil {
+			tline, _ := strconv.Atoi(m[1])
+			v.add(v.valueLine(line, tline), key, "%s", strings.TrimPrefix(m[2], fmt.Sprintf("executing %q at ", key)))
+			return
+		}
+		v.add(line, key, "%s", err)
+		return
+	}
+
+	if err := json.Unmarshal(b.Bytes(), target); err != nil {
+		offset := int64(-1)
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			offset = e.Offset
+		case *json.UnmarshalTypeError:
+			offset = e.Offset
+		}
+		if offset >= 0 {
+			eline := bytes.Count(b.Bytes()[:offset], []byte("\n")) + 1
+			v.add(v.valueLine(line, eline), key, "parsing expanded template result: %s", err)
+			return
+		}
+		v.add(line, key, "parsing expanded template result: %s", err)
+	}
+}
+
+// valueLine converts a line in a template value to the line in the template
+// file, the value starts on the line of the key or, for a multi-line string
+// starting with a newline, the following line
+func (v *validator) valueLine(keyLine, valueLine int) int {
+	if keyLine <= 0 || keyLine > len(v.lines) {
+		return keyLine
+	}
+	l := v.lines[keyLine-1]
+	if idx := strings.Index(l, "="); idx >= 0 {
+		switch strings.TrimSpace(l[idx+1:]) {
+		case `'''`, `"""`:
+			return keyLine + valueLine
+		}
+	}
+	return keyLine + valueLine - 1
+}
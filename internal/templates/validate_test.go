@@ -0,0 +1,102 @@
+// Copyright © 2018 Circonus, Inc. <support@circonus.com>
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package templates
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestValidateFile(t *testing.T) {
+	t.Log("Testing ValidateFile")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	tests := []struct {
+		name        string
+		file        string
+		vars        map[string]string
+		issues      []string
+		shouldFail  bool
+		expectedErr string
+	}{
+		{"invalid (empty)", "", nil, nil, true, "invalid file name (empty)"},
+		{"invalid (missing)", "missing.toml", nil, nil, true, "reading template: open testdata/missing.toml: no such file or directory"},
+		{"valid", "validate-graph-valid.toml", nil, []string{}, false, ""},
+		{"valid (dashboard instance)", "validate-dashboard-instance.toml", nil, []string{}, false, ""},
+		{"invalid (toml)", "template-test-invalid.toml", nil, []string{"1: was expecting token =, but got keys cannot contain : character instead"}, false, ""},
+		{"invalid", "validate-invalid.toml", nil, []string{
+			"4: colour: unknown key",
+			`10: configs.cpu.template: <.Missing>: map has no entry for key "Missing"`,
+			"15: configs.cpu.datapoints[0].metric_regex: invalid regex, need 1 subexpression (^cpu`user$)",
+			"20: configs.cpu.datapoints[0].template: parsing expanded template result: invalid character '}' looking for beginning of object key string",
+			"23: configs.cpu.datapoints[1].filter.include: invalid regex (error parsing regexp: missing closing ): `(unclosed`)",
+			"25: configs.cpu.datapoints[1].template: missing value for if",
+			"29: configs.widgets.widgets: only valid in dashboard templates",
+		}, false, ""},
+		{"invalid (vars)", "validate-invalid.toml", map[string]string{"Missing": "foo"}, []string{
+			"4: colour: unknown key",
+			"15: configs.cpu.datapoints[0].metric_regex: invalid regex, need 1 subexpression (^cpu`user$)",
+			"20: configs.cpu.datapoints[0].template: parsing expanded template result: invalid character '}' looking for beginning of object key string",
+			"23: configs.cpu.datapoints[1].filter.include: invalid regex (error parsing regexp: missing closing ): `(unclosed`)",
+			"25: configs.cpu.datapoints[1].template: missing value for if",
+			"29: configs.widgets.widgets: only valid in dashboard templates",
+		}, false, ""},
+	}
+
+	for _, test := range tests {
+		tst := test
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			fn := ""
+			if tst.file != "" {
+				fn = filepath.Join("testdata", tst.file)
+			}
+			issues, err := ValidateFile(fn, tst.vars)
+			if tst.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if err.Error() != tst.expectedErr {
+					t.Fatalf("unexpected error (%s)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error (%s)", err)
+			}
+			if len(issues) != len(tst.issues) {
+				t.Fatalf("expected %d issues, got %d (%v)", len(tst.issues), len(issues), issues)
+			}
+			for idx, issue := range issues {
+				if issue.String() != tst.issues[idx] {
+					t.Fatalf("expected (%s) got (%s)", tst.issues[idx], issue.String())
+				}
+			}
+		})
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	t.Log("Testing ParseVars")
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	vars, err := ParseVars([]string{"Foo=bar", "Baz=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if vars["Foo"] != "bar" || vars["Baz"] != "a=b" {
+		t.Fatalf("unexpected vars (%v)", vars)
+	}
+
+	_, err = ParseVars([]string{"=bar"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if err.Error() != "invalid template variable (=bar) - must be Name=value" {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+}
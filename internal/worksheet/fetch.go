@@ -50,7 +50,7 @@ func FetchByID(client CircAPI, id string) (*circapi.Worksheet, error) {
 	if !strings.HasPrefix(cid, "/worksheet/") {
 		cid = "/worksheet/" + id
 	}
-	if ok, err := regexp.MatchString(`^/worksheet/[0-9]+`, cid); err != nil {
+	if ok, err := regexp.MatchString(`^/worksheet/([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`, cid); err != nil {
 		return nil, errors.Wrap(err, "compile worksheet id regexp")
 	} else if !ok {
 		return nil, errors.Errorf("invalid worksheet id (%s)", id)
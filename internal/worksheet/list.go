@@ -6,10 +6,8 @@
 package worksheet
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path"
 	"strings"
 
@@ -68,13 +66,12 @@ func getDetail(regDir, regFile, uiURL string) (*detail, error) {
 
 	var w circapi.Worksheet
 
-	data, err := ioutil.ReadFile(path.Join(regDir, regFile))
+	found, err := regfiles.Load(path.Join(regDir, regFile), &w)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading worksheet registration file")
 	}
-
-	if err := json.Unmarshal(data, &w); err != nil {
-		return nil, errors.Wrap(err, "parsing worksheet registration json")
+	if !found {
+		return nil, errors.Errorf("worksheet registration file (%s) not found", regFile)
 	}
 
 	d := detail{
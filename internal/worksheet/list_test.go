@@ -62,7 +62,7 @@ func TestGetDetail(t *testing.T) {
 	t.Log("\tinvalid regfile (json parse)")
 	if _, err := getDetail("testdata/", "bad.json", uiURL); err == nil {
 		t.Fatal("expected error")
-	} else if err.Error() != "parsing worksheet registration json: unexpected end of JSON input" {
+	} else if err.Error() != "reading worksheet registration file: parsing registration (testdata/bad.json): unexpected end of JSON input" {
 		t.Fatalf("unexpected error (%v)", err)
 	}
 